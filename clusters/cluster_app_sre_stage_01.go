@@ -16,7 +16,7 @@ func init() {
 		Namespace:   "rhobs-stage",
 		GatewayConfig: NewGatewayConfig(
 			WithMetricsEnabled(),
-			WithAMS("https://api.stage.openshift.com"),
+			WithAMS("https://api.stage.openshift.com", DefaultAMSMappings()...),
 			WithTenants(appSreStage01Tenants()),
 			WithRBAC(appSreStage01RBAC()),
 			WithTracingEnabled(),
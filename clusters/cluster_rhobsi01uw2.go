@@ -1,6 +1,7 @@
 package clusters
 
 import (
+	lokiv1 "github.com/grafana/loki/operator/api/loki/v1"
 	"github.com/observatorium/api/rbac"
 	observatoriumapi "github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/observatorium/api"
 	cfgobservatorium "github.com/rhobs/configuration/configuration/observatorium"
@@ -20,10 +21,14 @@ func init() {
 			WithLoggingEnabled(),
 			WithSyntheticsEnabled(),
 			WithTracingEnabled(),
+			WithStaticRBAC(),
 			WithTenants(rhobsi01uw2Tenants()),
 			WithRBAC(rhobsi01uw2RBAC()),
 			WithCustomRoute("rhobs.us-west-2-0.api.integration.openshift.com"),
 		),
+		LokiSchemas: []LokiSchema{
+			{EffectiveDate: "2025-06-06", Version: lokiv1.ObjectStorageSchemaV13},
+		},
 		Templates:  rhobsi01uw2TemplateMaps(),
 		BuildSteps: rhobsi01uw2BuildSteps(),
 	})
@@ -53,7 +58,8 @@ func rhobsi01uw2RBAC() cfgobservatorium.ObservatoriumRBAC {
 		WithTenant(cfgobservatorium.HcpTenant).
 		WithSignals([]cfgobservatorium.Resource{cfgobservatorium.MetricsResource, cfgobservatorium.LogsResource, cfgobservatorium.ProbesResource}).
 		WithPerms([]rbac.Permission{rbac.Read, rbac.Write}).
-		WithRawSubjectName()
+		WithRawSubjectName().
+		WithOwner("hcp")
 
 	config := cfgobservatorium.GenerateClusterRBAC(opts)
 	return *config
@@ -63,6 +69,7 @@ func rhobsi01uw2BuildSteps() []string {
 	return []string{
 		StepGateway,
 		StepDefaultThanosStack,
+		StepTenantRules,
 		StepDefaultLokiStack,
 		StepSyntheticsApi,
 		StepAlertmanager,
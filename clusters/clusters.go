@@ -2,6 +2,18 @@ package clusters
 
 import (
 	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	lokiv1 "github.com/grafana/loki/operator/api/loki/v1"
+	"github.com/prometheus/common/model"
+	"github.com/thanos-community/thanos-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
 
 	cfgobservatorium "github.com/rhobs/configuration/configuration/observatorium"
 
@@ -34,11 +46,26 @@ const (
 
 // ClusterConfig holds the configuration for a specific cluster deployment
 type ClusterConfig struct {
-	Name               ClusterName
-	Environment        ClusterEnvironment
-	Namespace          string
-	Templates          TemplateMaps
-	GatewayConfig      *GatewayConfig
+	Name          ClusterName
+	Environment   ClusterEnvironment
+	Namespace     string
+	Templates     TemplateMaps
+	GatewayConfig *GatewayConfig
+	// Hashrings are the receive ingester hashrings, matched in order. Without any, a single default hashring takes every tenant.
+	Hashrings []Hashring
+	// Compaction configures the retention, downsampling and sharding of the compactor.
+	Compaction CompactionPolicy
+	// ReceiveLimits are the write limits enforced by the receive router. Without any, writes are not limited.
+	ReceiveLimits *ReceiveLimits
+	// StoreAPIHost is the external host serving the StoreAPI of the cluster's query to the clusters federating it.
+	StoreAPIHost string
+	// QueryFederation opts the cluster into a global query layer spanning other registered clusters.
+	QueryFederation *QueryFederation
+	// Caches selects the backend of each cache role. Roles left out run on in-cluster memcached.
+	Caches map[CacheRole]Cache
+	// LokiSchemas is the schema history of the LokiStack storage, oldest first.
+	// Append entries with `mage loki:addSchema`, never edit the ones in effect.
+	LokiSchemas        []LokiSchema
 	BuildSteps         []string
 	MonitoringAPIGroup MonitoringAPIGroup
 }
@@ -47,14 +74,233 @@ type GatewayConfig struct {
 	metricsEnabled    bool
 	logsEnabled       bool
 	syntheticsEnabled bool
+	tracesEnabled     bool
 	// tracing in this instance refers to internal tracing of the gateway itself
 	tracingEnabled bool
-	amsURL         string
+	authorizers    []Authorizer
+	disruption     *DisruptionBudget
+	topologySpread []TopologySpread
 	tenants        observatoriumapi.Tenants
 	rbac           cfgobservatorium.ObservatoriumRBAC
 	customRoute    string
 }
 
+// TenantMatcherType selects how the tenants of a hashring are matched against incoming tenant IDs
+type TenantMatcherType string
+
+const (
+	TenantMatcherExact TenantMatcherType = "exact"
+	TenantMatcherGlob  TenantMatcherType = "glob"
+)
+
+// ReceiveDefaultTenantID is the tenant ID the receivers ingest the requests without a tenant header under
+const ReceiveDefaultTenantID = "FB870BF3-9F3A-44FF-9BF7-D7A047A52F43"
+
+// Hashring is a receive ingester hashring and the tenants routed to it
+type Hashring struct {
+	Name string
+	// Tenants are the tenant IDs routed to the hashring. A hashring without tenants takes every tenant
+	// not matched by another hashring and must be the last one.
+	Tenants     []string
+	MatcherType TenantMatcherType
+	Replicas    int32
+	Resources   corev1.ResourceRequirements
+	// Retention is the local TSDB retention of the ingesters, e.g. "1d"
+	Retention   string
+	StorageSize v1alpha1.StorageSize
+	// Bucket is the key of the hashring's object storage bucket in the templates ObjectStorageBucket map
+	Bucket string
+	// ReceiveBucket leaves the bucket of the hashring unset, its ingesters upload to the default bucket of the receivers.
+	ReceiveBucket bool
+	// Component is the key of the image, version and log level of the ingesters in the templates,
+	// and of their replicas, resources and storage size when the hashring does not set them.
+	// Defaults to RECEIVE_INGESTOR_DEFAULT.
+	Component string
+	// StoreLimits bound the Series requests served by the ingesters, zero values do not limit them.
+	StoreLimits v1alpha1.StoreLimitsOptions
+	// AsyncForwardWorkers is the number of workers forwarding writes to the other ingesters, 50 if unset.
+	AsyncForwardWorkers uint64
+}
+
+// Matches returns whether the tenant ID is routed to the hashring
+func (h Hashring) Matches(tenantID string) bool {
+	if len(h.Tenants) == 0 {
+		return true
+	}
+	for _, t := range h.Tenants {
+		if h.MatcherType == TenantMatcherGlob {
+			if ok, _ := path.Match(t, tenantID); ok {
+				return true
+			}
+			continue
+		}
+		if t == tenantID {
+			return true
+		}
+	}
+	return false
+}
+
+// CompactionPolicy configures the compactor of a cluster. Unset retentions default to 365d.
+type CompactionPolicy struct {
+	// RawRetention, FiveMinutesRetention and OneHourRetention are the retentions of each resolution, e.g. "365d".
+	// A retention of "0d" keeps the blocks forever.
+	RawRetention         string
+	FiveMinutesRetention string
+	OneHourRetention     string
+	DisableDownsampling  bool
+	// Shards split the compaction by tenant. Without any, a single compactor processes every block.
+	Shards []CompactionShard
+}
+
+// CompactionShard is a compactor shard processing the blocks of the given gateway tenants
+type CompactionShard struct {
+	Name string
+	// Tenants are the names of the gateway tenants whose blocks are compacted by the shard
+	Tenants []string
+	// DefaultTenant also compacts the blocks of the requests without a tenant, ingested under ReceiveDefaultTenantID
+	DefaultTenant bool
+	// ExternalLabels further select the blocks of the shard, the values are regular expressions.
+	// A shard without tenants selects its blocks by these labels only.
+	ExternalLabels []v1alpha1.ExternalLabelShardingConfig
+}
+
+// Minimum retentions accepted by the compactor when downsampling, as blocks are only downsampled
+// once they span 40h (raw to 5m) and 10d (5m to 1h).
+const (
+	minRawRetentionWithDownsampling         = model.Duration(40 * time.Hour)
+	minFiveMinutesRetentionWithDownsampling = model.Duration(10 * 24 * time.Hour)
+)
+
+// ReceiveLimits are the default and per-tenant write limits of the receive router
+type ReceiveLimits struct {
+	// MaxConcurrency bounds the write requests handled concurrently by each router across tenants. Zero is unlimited.
+	MaxConcurrency int64
+	// MetaMonitoringURL is the Prometheus-compatible API queried for the head series of each tenant.
+	// It is required by head series limits.
+	MetaMonitoringURL string
+	// Default limits apply to every tenant. Zero values are unlimited.
+	Default WriteLimits
+	// Tenants override the default limits, keyed by gateway tenant name. Zero values inherit the default.
+	Tenants map[string]WriteLimits
+}
+
+// WriteLimits limit the remote write requests and head series of a tenant
+type WriteLimits struct {
+	// SizeBytesLimit, SeriesLimit and SamplesLimit bound a single remote write request.
+	SizeBytesLimit int64
+	SeriesLimit    int64
+	SamplesLimit   int64
+	// HeadSeriesLimit bounds the active series of the tenant across the ingesters.
+	HeadSeriesLimit uint64
+}
+
+// QueryFederation federates the StoreAPI of other registered clusters into a global query
+type QueryFederation struct {
+	Remotes []FederatedCluster
+}
+
+// FederatedCluster is a registered cluster queried by the global query
+type FederatedCluster struct {
+	Name ClusterName
+	// ResponseTimeout ignores the remote for a query once it has not sent any data for this long, e.g. "30s".
+	// Empty waits for the remote indefinitely.
+	ResponseTimeout string
+	// PartialResponse answers with the data of the other clusters when the remote fails instead of failing the query.
+	PartialResponse bool
+}
+
+// FederationEndpoint is a federated cluster resolved against the registry
+type FederationEndpoint struct {
+	FederatedCluster
+	// Host serves the remote StoreAPI over TLS on port 443
+	Host string
+}
+
+// CacheRole identifies what a cache is used for
+type CacheRole string
+
+const (
+	CacheRoleIndex      CacheRole = "index"
+	CacheRoleBucket     CacheRole = "bucket"
+	CacheRoleQueryRange CacheRole = "query-range"
+)
+
+// CacheBackend selects where a cache runs
+type CacheBackend string
+
+const (
+	// CacheBackendMemcached runs the cache as an in-cluster memcached StatefulSet (it is the default).
+	CacheBackendMemcached CacheBackend = "memcached"
+	// CacheBackendRedis points the cache at an external Redis, such as ElastiCache.
+	CacheBackendRedis CacheBackend = "redis"
+)
+
+// Cache configures the backend of a cache role
+type Cache struct {
+	Backend CacheBackend
+	// Address is the host:port of the external Redis. Left empty, the host and port are template parameters.
+	// The auth token of the Redis is always a template parameter.
+	Address string
+}
+
+// LokiSchema is an entry of the LokiStack schema history. It takes effect at 00:00 UTC of its effective date,
+// from when Loki writes with it. Loki keeps reading the data written before with the previous entries, so an entry
+// in effect must never be edited or removed.
+type LokiSchema struct {
+	// EffectiveDate is formatted as YYYY-MM-DD.
+	EffectiveDate string
+	Version       lokiv1.ObjectStorageSchemaVersion
+}
+
+// lokiSchemaVersions are the schema versions supported by the Loki operator.
+var lokiSchemaVersions = []lokiv1.ObjectStorageSchemaVersion{
+	lokiv1.ObjectStorageSchemaV11,
+	lokiv1.ObjectStorageSchemaV12,
+	lokiv1.ObjectStorageSchemaV13,
+}
+
+// DisruptionBudget limits voluntary disruptions of the gateway pods
+type DisruptionBudget struct {
+	MaxUnavailable int32
+}
+
+// TopologySpread spreads the gateway pods across the given topology domain
+type TopologySpread struct {
+	TopologyKey string
+	MaxSkew     int32
+	// HardConstraint refuses to schedule pods that would violate the skew instead of scheduling them anyway.
+	HardConstraint bool
+}
+
+// AuthorizerType identifies the backend the gateway uses to authorize requests
+type AuthorizerType string
+
+const (
+	// AuthorizerStaticRBAC authorizes requests against the rbac.yaml generated from the gateway RBAC.
+	AuthorizerStaticRBAC AuthorizerType = "static-rbac"
+	// AuthorizerOPAAMS authorizes requests through the opa-ams sidecar backed by the Account Management Service.
+	AuthorizerOPAAMS AuthorizerType = "opa-ams"
+	// AuthorizerExternalOPA authorizes requests against an OPA server outside the gateway pod.
+	AuthorizerExternalOPA AuthorizerType = "external-opa"
+)
+
+// AMSMapping maps an AMS organization ID onto an observatorium group
+type AMSMapping struct {
+	Group          string
+	OrganizationID string
+}
+
+// Authorizer holds the authorizer selected for the gateway and its settings
+type Authorizer struct {
+	Type AuthorizerType
+	// AMSURL and AMSMappings are only used by AuthorizerOPAAMS.
+	AMSURL      string
+	AMSMappings []AMSMapping
+	// OPAURL is only used by AuthorizerExternalOPA and must point at the allow rule of the observatorium package.
+	OPAURL string
+}
+
 // String returns the string representation of ClusterName
 func (c ClusterName) String() string {
 	return string(c)
@@ -89,9 +335,469 @@ func (c ClusterConfig) Validate() error {
 	if len(c.BuildSteps) == 0 {
 		return fmt.Errorf("cluster must have at least one build step")
 	}
+	if c.GatewayConfig != nil {
+		if err := c.GatewayConfig.Validate(); err != nil {
+			return fmt.Errorf("invalid gateway config: %w", err)
+		}
+	}
+	for component, autoscaling := range c.Templates.Autoscaling {
+		if err := autoscaling.Validate(); err != nil {
+			return fmt.Errorf("invalid autoscaling for %s: %w", component, err)
+		}
+	}
+	for key, bucket := range c.Templates.ObjectStorageBucket {
+		if err := bucket.Storage.Validate(); err != nil {
+			return fmt.Errorf("invalid object storage for %s: %w", key, err)
+		}
+	}
+	if bucket, ok := c.Templates.ObjectStorageBucket[LokiBucket]; ok && bucket.Storage.Provider == ObjectStorageFilesystem {
+		return fmt.Errorf("the Loki operator does not support the %s object storage provider", ObjectStorageFilesystem)
+	}
+	if bucket, ok := c.Templates.ObjectStorageBucket[TracesBucket]; ok && bucket.Storage.Provider == ObjectStorageFilesystem {
+		return fmt.Errorf("the Tempo operator does not support the %s object storage provider", ObjectStorageFilesystem)
+	}
+	for component, policy := range c.Templates.QueryFrontendPolicy {
+		if err := policy.Validate(); err != nil {
+			return fmt.Errorf("invalid query frontend policy for %s: %w", component, err)
+		}
+	}
+	for component, tiers := range c.Templates.StoreTiers {
+		if err := ValidateStoreTiers(tiers); err != nil {
+			return fmt.Errorf("invalid store tiers for %s: %w", component, err)
+		}
+	}
+	if err := c.validateHashrings(); err != nil {
+		return fmt.Errorf("invalid hashrings: %w", err)
+	}
+	if err := c.validateCompaction(); err != nil {
+		return fmt.Errorf("invalid compaction policy: %w", err)
+	}
+	if err := c.validateReceiveLimits(); err != nil {
+		return fmt.Errorf("invalid receive limits: %w", err)
+	}
+	if err := c.validateQueryFederation(); err != nil {
+		return fmt.Errorf("invalid query federation: %w", err)
+	}
+	if err := c.validateLoki(); err != nil {
+		return fmt.Errorf("invalid loki overrides: %w", err)
+	}
+	if err := c.validateLokiSchemas(); err != nil {
+		return fmt.Errorf("invalid loki schemas: %w", err)
+	}
+	if err := c.validateCaches(); err != nil {
+		return fmt.Errorf("invalid caches: %w", err)
+	}
+	for _, step := range c.BuildSteps {
+		if step == StepDefaultTracesStack && (c.GatewayConfig == nil || !c.GatewayConfig.TracesEnabled()) {
+			return fmt.Errorf("build step %s requires traces to be enabled in the gateway config", step)
+		}
+		if step == StepLokiTenantRules {
+			for _, required := range []string{StepDefaultLokiStack, StepAlertmanager} {
+				if !slices.Contains(c.BuildSteps, required) {
+					return fmt.Errorf("build step %s requires the %s build step", step, required)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// validateHashrings checks the hashring definitions and that every gateway tenant, and the requests without a tenant,
+// land on exactly one hashring
+func (c ClusterConfig) validateHashrings() error {
+	if len(c.Hashrings) == 0 {
+		return nil
+	}
+
+	names := map[string]struct{}{}
+	for i, h := range c.Hashrings {
+		if h.Name == "" {
+			return fmt.Errorf("hashring %d has no name", i)
+		}
+		if _, ok := names[h.Name]; ok {
+			return fmt.Errorf("duplicate hashring name %q", h.Name)
+		}
+		names[h.Name] = struct{}{}
+
+		switch h.MatcherType {
+		case "", TenantMatcherExact:
+		case TenantMatcherGlob:
+			for _, t := range h.Tenants {
+				if _, err := path.Match(t, ""); err != nil {
+					return fmt.Errorf("hashring %q: invalid tenant glob %q: %w", h.Name, t, err)
+				}
+			}
+		default:
+			return fmt.Errorf("hashring %q: unknown tenant matcher type %q", h.Name, h.MatcherType)
+		}
+		if len(h.Tenants) == 0 && i != len(c.Hashrings)-1 {
+			return fmt.Errorf("hashring %q: only the last hashring can match every tenant", h.Name)
+		}
+		if h.Replicas < 0 {
+			return fmt.Errorf("hashring %q: replicas cannot be negative", h.Name)
+		}
+		if h.Retention != "" {
+			if _, err := model.ParseDuration(h.Retention); err != nil {
+				return fmt.Errorf("hashring %q: invalid retention: %w", h.Name, err)
+			}
+		}
+		if h.Bucket != "" {
+			if h.ReceiveBucket {
+				return fmt.Errorf("hashring %q: a bucket cannot be set with the bucket of the receivers", h.Name)
+			}
+			if _, ok := c.Templates.ObjectStorageBucket[h.Bucket]; !ok {
+				return fmt.Errorf("hashring %q: unknown bucket %q", h.Name, h.Bucket)
+			}
+		}
+		if h.Component != "" {
+			if _, ok := c.Templates.Images[h.Component]; !ok {
+				return fmt.Errorf("hashring %q: unknown component %q", h.Name, h.Component)
+			}
+		}
+	}
+
+	// Requests without a tenant header are ingested under the default tenant ID
+	if err := c.validateHashringRoute("requests without a tenant", ReceiveDefaultTenantID); err != nil {
+		return err
+	}
+	if c.GatewayConfig == nil {
+		return nil
+	}
+	for _, tenant := range c.GatewayConfig.Tenants().Tenants {
+		if err := c.validateHashringRoute("tenant "+tenant.Name, tenant.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateHashringRoute checks that the tenant ID lands on exactly one hashring
+func (c ClusterConfig) validateHashringRoute(name, tenantID string) error {
+	var matched []string
+	for _, h := range c.Hashrings {
+		// The catch-all hashring only takes the tenants left over by the others.
+		if len(h.Tenants) == 0 && len(matched) > 0 {
+			continue
+		}
+		if h.Matches(tenantID) {
+			matched = append(matched, h.Name)
+		}
+	}
+	switch len(matched) {
+	case 0:
+		return fmt.Errorf("%s (%s) is not routed to any hashring", name, tenantID)
+	case 1:
+		return nil
+	default:
+		return fmt.Errorf("%s (%s) is routed to several hashrings: %s", name, tenantID, strings.Join(matched, ", "))
+	}
+}
+
+// validateCompaction checks the compaction policy against the gateway tenants of the cluster
+func (c ClusterConfig) validateCompaction() error {
+	var tenants observatoriumapi.Tenants
+	if c.GatewayConfig != nil {
+		tenants = c.GatewayConfig.Tenants()
+	}
+	return c.Compaction.Validate(tenants)
+}
+
+// Validate checks the retentions and that no block is compacted by two shards. The shards may only reference
+// the given tenants.
+func (p CompactionPolicy) Validate(tenants observatoriumapi.Tenants) error {
+	retentions := []struct {
+		name, value string
+		min         model.Duration
+	}{
+		{"raw", p.RawRetention, minRawRetentionWithDownsampling},
+		{"5m", p.FiveMinutesRetention, minFiveMinutesRetentionWithDownsampling},
+		{"1h", p.OneHourRetention, 0},
+	}
+	for _, r := range retentions {
+		if r.value == "" {
+			continue
+		}
+		d, err := model.ParseDuration(r.value)
+		if err != nil {
+			return fmt.Errorf("invalid %s retention: %w", r.name, err)
+		}
+		if !p.DisableDownsampling && d != 0 && d < r.min {
+			return fmt.Errorf("%s retention %s is shorter than the %s needed before downsampling", r.name, d, r.min)
+		}
+	}
+
+	ids := map[string]string{}
+	for _, tenant := range tenants.Tenants {
+		ids[tenant.Name] = tenant.ID
+	}
+	// owners are keyed by tenant ID, as the blocks only carry the ID
+	owners := map[string]string{}
+	shardNames := map[string]struct{}{}
+	for i, shard := range p.Shards {
+		if shard.Name == "" {
+			return fmt.Errorf("shard %d has no name", i)
+		}
+		if _, ok := shardNames[shard.Name]; ok {
+			return fmt.Errorf("duplicate shard name %q", shard.Name)
+		}
+		shardNames[shard.Name] = struct{}{}
+		if len(shard.Tenants) == 0 && !shard.DefaultTenant && len(shard.ExternalLabels) == 0 {
+			return fmt.Errorf("shard %q has no tenants nor external labels", shard.Name)
+		}
+		for _, l := range shard.ExternalLabels {
+			if l.Label == "" {
+				return fmt.Errorf("shard %q: external label without a name", shard.Name)
+			}
+			if _, err := regexp.Compile(l.Value); err != nil {
+				return fmt.Errorf("shard %q: invalid value of external label %q: %w", shard.Name, l.Label, err)
+			}
+		}
+		shardIDs := map[string]string{}
+		for _, tenant := range shard.Tenants {
+			id, ok := ids[tenant]
+			if !ok {
+				return fmt.Errorf("shard %q: tenant %q is not registered in the gateway", shard.Name, tenant)
+			}
+			shardIDs[id] = "tenant " + tenant
+		}
+		if shard.DefaultTenant {
+			shardIDs[ReceiveDefaultTenantID] = "the requests without a tenant"
+		}
+		for id, name := range shardIDs {
+			if owner, ok := owners[id]; ok {
+				return fmt.Errorf("%s (%s) is compacted by both shards %q and %q", name, id, owner, shard.Name)
+			}
+			owners[id] = shard.Name
+		}
+	}
+	return nil
+}
+
+// validateReceiveLimits checks the limits and that they only reference gateway tenants
+func (c ClusterConfig) validateReceiveLimits() error {
+	l := c.ReceiveLimits
+	if l == nil {
+		return nil
+	}
+	if l.MaxConcurrency < 0 {
+		return fmt.Errorf("max concurrency cannot be negative")
+	}
+	if l.MetaMonitoringURL != "" {
+		if _, err := url.ParseRequestURI(l.MetaMonitoringURL); err != nil {
+			return fmt.Errorf("invalid meta-monitoring URL: %w", err)
+		}
+	}
+
+	headSeriesLimited := l.Default.HeadSeriesLimit != 0
+	if err := l.Default.validate(); err != nil {
+		return fmt.Errorf("default limits: %w", err)
+	}
+	if len(l.Tenants) > 0 && c.GatewayConfig == nil {
+		return fmt.Errorf("tenant limits are keyed by gateway tenant but the cluster has no gateway")
+	}
+	for name, limits := range l.Tenants {
+		if _, ok := c.GatewayConfig.TenantID(name); !ok {
+			return fmt.Errorf("tenant %q is not registered in the gateway", name)
+		}
+		if err := limits.validate(); err != nil {
+			return fmt.Errorf("tenant %q: %w", name, err)
+		}
+		headSeriesLimited = headSeriesLimited || limits.HeadSeriesLimit != 0
+	}
+	if headSeriesLimited && l.MetaMonitoringURL == "" {
+		return fmt.Errorf("head series limits require a meta-monitoring URL")
+	}
 	return nil
 }
 
+func (w WriteLimits) validate() error {
+	if w.SizeBytesLimit < 0 || w.SeriesLimit < 0 || w.SamplesLimit < 0 {
+		return fmt.Errorf("request limits cannot be negative")
+	}
+	return nil
+}
+
+// validateLoki checks the LokiStack settings, the global and per-tenant limits, and that the tenants are registered
+// in the gateway
+func (c ClusterConfig) validateLoki() error {
+	for key, overrides := range c.Templates.LokiOverrides {
+		if err := overrides.validateStack(); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		if err := overrides.validate(); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		if overrides.Retention != nil {
+			if err := overrides.Retention.validate(); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+		}
+		if overrides.OTLP != nil {
+			if err := overrides.OTLP.validate(nil); err != nil {
+				return fmt.Errorf("%s: invalid OTLP mapping: %w", key, err)
+			}
+		}
+		if len(overrides.Tenants) > 0 && c.GatewayConfig == nil {
+			return fmt.Errorf("%s: tenant limits are keyed by gateway tenant but the cluster has no gateway", key)
+		}
+		for name, limits := range overrides.Tenants {
+			if _, ok := c.GatewayConfig.TenantID(name); !ok {
+				return fmt.Errorf("%s: tenant %q is not registered in the gateway", key, name)
+			}
+			if err := limits.validate(); err != nil {
+				return fmt.Errorf("%s: tenant %q: %w", key, name, err)
+			}
+			if limits.Retention != nil {
+				if err := limits.Retention.validate(); err != nil {
+					return fmt.Errorf("%s: tenant %q: %w", key, name, err)
+				}
+			}
+			if limits.OTLP != nil {
+				if err := limits.OTLP.validate(overrides.OTLP); err != nil {
+					return fmt.Errorf("%s: tenant %q: invalid OTLP mapping: %w", key, name, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// validateLokiSchemas checks the schema history is well formed. Whether entries in effect were edited depends on the
+// build time and on what was last generated, which is checked by the build.
+func (c ClusterConfig) validateLokiSchemas() error {
+	if slices.Contains(c.BuildSteps, StepDefaultLokiStack) && len(c.LokiSchemas) == 0 {
+		return fmt.Errorf("build step %s requires at least one schema", StepDefaultLokiStack)
+	}
+
+	var previous time.Time
+	for i, schema := range c.LokiSchemas {
+		date, err := time.Parse(lokiv1.StorageSchemaEffectiveDateFormat, schema.EffectiveDate)
+		if err != nil {
+			return fmt.Errorf("schema %d: invalid effective date %q: %w", i, schema.EffectiveDate, err)
+		}
+		if !slices.Contains(lokiSchemaVersions, schema.Version) {
+			return fmt.Errorf("schema %s: unknown version %q, must be one of %v", schema.EffectiveDate, schema.Version, lokiSchemaVersions)
+		}
+		if i > 0 && !date.After(previous) {
+			return fmt.Errorf("schema %s: effective dates must be increasing, the previous schema is effective from %s",
+				schema.EffectiveDate, previous.Format(lokiv1.StorageSchemaEffectiveDateFormat))
+		}
+		previous = date
+	}
+	return nil
+}
+
+// validateQueryFederation checks what can be checked before every cluster is registered.
+// Remotes are resolved against the registry by FederationEndpoints.
+func (c ClusterConfig) validateQueryFederation() error {
+	if c.QueryFederation == nil {
+		return nil
+	}
+	if len(c.QueryFederation.Remotes) == 0 {
+		return fmt.Errorf("at least one remote cluster is required")
+	}
+
+	seen := map[ClusterName]struct{}{}
+	for _, remote := range c.QueryFederation.Remotes {
+		if remote.Name == "" {
+			return fmt.Errorf("remote cluster name cannot be empty")
+		}
+		if remote.Name == c.Name {
+			return fmt.Errorf("a cluster cannot federate itself")
+		}
+		if _, ok := seen[remote.Name]; ok {
+			return fmt.Errorf("duplicate remote cluster %s", remote.Name)
+		}
+		seen[remote.Name] = struct{}{}
+		if remote.ResponseTimeout != "" {
+			if _, err := model.ParseDuration(remote.ResponseTimeout); err != nil {
+				return fmt.Errorf("remote %s: invalid response timeout: %w", remote.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateCaches checks the backend of each cache role
+func (c ClusterConfig) validateCaches() error {
+	for role, cache := range c.Caches {
+		switch role {
+		case CacheRoleIndex, CacheRoleBucket, CacheRoleQueryRange:
+		default:
+			return fmt.Errorf("unknown cache role %q", role)
+		}
+		switch cache.Backend {
+		case CacheBackendMemcached:
+			if cache.Address != "" {
+				return fmt.Errorf("%s cache: an address is only supported by the %s backend", role, CacheBackendRedis)
+			}
+		case CacheBackendRedis:
+			if cache.Address != "" {
+				if _, _, err := net.SplitHostPort(cache.Address); err != nil {
+					return fmt.Errorf("%s cache: invalid address: %w", role, err)
+				}
+			}
+		default:
+			return fmt.Errorf("%s cache: unknown backend %q", role, cache.Backend)
+		}
+	}
+	return nil
+}
+
+// Cache returns the cache configuration of the role, defaulting to in-cluster memcached
+func (c ClusterConfig) Cache(role CacheRole) Cache {
+	if cache, ok := c.Caches[role]; ok {
+		return cache
+	}
+	return Cache{Backend: CacheBackendMemcached}
+}
+
+// FederationEndpoints resolves the remotes federated by the cluster against the registry
+func FederationEndpoints(c ClusterConfig) ([]FederationEndpoint, error) {
+	if c.QueryFederation == nil {
+		return nil, nil
+	}
+
+	endpoints := make([]FederationEndpoint, 0, len(c.QueryFederation.Remotes))
+	for _, remote := range c.QueryFederation.Remotes {
+		rc, err := GetClusterByName(remote.Name)
+		if err != nil {
+			return nil, fmt.Errorf("cluster %s federates an unregistered cluster: %w", c.Name, err)
+		}
+		if rc.Environment != c.Environment {
+			return nil, fmt.Errorf("cluster %s (%s) cannot federate %s from another environment (%s)", c.Name, c.Environment, rc.Name, rc.Environment)
+		}
+		if rc.StoreAPIHost == "" {
+			return nil, fmt.Errorf("cluster %s federates %s, which does not expose a StoreAPI host", c.Name, rc.Name)
+		}
+		endpoints = append(endpoints, FederationEndpoint{
+			FederatedCluster: remote,
+			Host:             rc.StoreAPIHost,
+		})
+	}
+	return endpoints, nil
+}
+
+// FederatingClusters returns the registered clusters federating the given cluster, sorted by name
+func FederatingClusters(name ClusterName) []ClusterConfig {
+	var result []ClusterConfig
+	for _, c := range ClusterRegistry {
+		if c.QueryFederation == nil {
+			continue
+		}
+		for _, remote := range c.QueryFederation.Remotes {
+			if remote.Name == name {
+				result = append(result, c)
+				break
+			}
+		}
+	}
+	slices.SortFunc(result, func(a, b ClusterConfig) int {
+		return strings.Compare(string(a.Name), string(b.Name))
+	})
+	return result
+}
+
 // ClusterRegistry holds all registered clusters
 var ClusterRegistry = make(map[ClusterName]ClusterConfig)
 
@@ -103,10 +809,13 @@ const (
 	StepThanosOperatorCRDS = "thanos-operator-crds"
 	StepThanosOperator     = "thanos-operator"
 	StepDefaultThanosStack = "default-thanos-stack"
+	StepTenantRules        = "tenant-rules"
 
 	StepLokiOperatorCRDS = "loki-operator-crds"
 	StepLokiOperator     = "loki-operator"
 	StepDefaultLokiStack = "default-loki-stack"
+	// StepLokiTenantRules enables the LokiStack ruler and generates the LogQL rules of the tenants hosted by the cluster.
+	StepLokiTenantRules = "loki-tenant-rules"
 
 	StepServiceMonitors = "servicemonitors"
 
@@ -118,6 +827,8 @@ const (
 	StepSyntheticsApi  = "synthetics-api"
 	StepAlertmanagerCR = "alertmanager-cr"
 
+	StepDefaultTracesStack = "default-traces-stack"
+
 	StepNoOp = "noop"
 )
 
@@ -143,6 +854,7 @@ func DefaultMetricsBuildSteps() []string {
 		StepThanosOperatorCRDS,
 		StepThanosOperator,
 		StepDefaultThanosStack,
+		StepTenantRules,
 	}
 }
 
@@ -160,6 +872,12 @@ func DefaultSyntheticsBuildSteps() []string {
 	}
 }
 
+func DefaultTracesBuildSteps() []string {
+	return []string{
+		StepDefaultTracesStack,
+	}
+}
+
 func DefaultAlertingBuildSteps() []string {
 	return []string{
 		StepAlertmanager,
@@ -256,6 +974,13 @@ func WithSyntheticsEnabled() func(*GatewayConfig) {
 	}
 }
 
+// WithTracesEnabled enables the traces signal for the gateway
+func WithTracesEnabled() func(*GatewayConfig) {
+	return func(g *GatewayConfig) {
+		g.tracesEnabled = true
+	}
+}
+
 // WithTracingEnabled enables internal tracing for the gateway itself
 func WithTracingEnabled() func(*GatewayConfig) {
 	return func(g *GatewayConfig) {
@@ -263,10 +988,31 @@ func WithTracingEnabled() func(*GatewayConfig) {
 	}
 }
 
-// WithAMS configures the Account Management Service URL for the gateway
-func WithAMS(url string) func(*GatewayConfig) {
+// WithStaticRBAC selects the static rbac.yaml authorizer for the gateway
+func WithStaticRBAC() func(*GatewayConfig) {
+	return func(g *GatewayConfig) {
+		g.authorizers = append(g.authorizers, Authorizer{Type: AuthorizerStaticRBAC})
+	}
+}
+
+// WithAMS selects the OPA-AMS authorizer for the gateway, backed by the Account Management Service URL
+func WithAMS(url string, mappings ...AMSMapping) func(*GatewayConfig) {
+	return func(g *GatewayConfig) {
+		g.authorizers = append(g.authorizers, Authorizer{
+			Type:        AuthorizerOPAAMS,
+			AMSURL:      url,
+			AMSMappings: mappings,
+		})
+	}
+}
+
+// WithExternalOPA selects an external OPA endpoint as the authorizer for the gateway
+func WithExternalOPA(url string) func(*GatewayConfig) {
 	return func(g *GatewayConfig) {
-		g.amsURL = url
+		g.authorizers = append(g.authorizers, Authorizer{
+			Type:   AuthorizerExternalOPA,
+			OPAURL: url,
+		})
 	}
 }
 
@@ -284,6 +1030,20 @@ func WithRBAC(rbac cfgobservatorium.ObservatoriumRBAC) func(*GatewayConfig) {
 	}
 }
 
+// WithPodDisruptionBudget adds a PodDisruptionBudget allowing at most maxUnavailable gateway pods to be disrupted
+func WithPodDisruptionBudget(maxUnavailable int32) func(*GatewayConfig) {
+	return func(g *GatewayConfig) {
+		g.disruption = &DisruptionBudget{MaxUnavailable: maxUnavailable}
+	}
+}
+
+// WithTopologySpread spreads the gateway pods across the given topology domains
+func WithTopologySpread(spreads ...TopologySpread) func(*GatewayConfig) {
+	return func(g *GatewayConfig) {
+		g.topologySpread = append(g.topologySpread, spreads...)
+	}
+}
+
 func WithCustomRoute(route string) func(*GatewayConfig) {
 	return func(g *GatewayConfig) {
 		g.customRoute = route
@@ -307,14 +1067,22 @@ func (g *GatewayConfig) SyntheticsEnabled() bool {
 	return g.syntheticsEnabled
 }
 
+// TracesEnabled returns whether the traces signal is enabled for the gateway
+func (g *GatewayConfig) TracesEnabled() bool {
+	return g.tracesEnabled
+}
+
 // TracingEnabled returns whether tracing is enabled for the gateway
 func (g *GatewayConfig) TracingEnabled() bool {
 	return g.tracingEnabled
 }
 
-// AMSURL returns the AMS URL for the gateway
-func (g *GatewayConfig) AMSURL() string {
-	return g.amsURL
+// Authorizer returns the authorizer selected for the gateway
+func (g *GatewayConfig) Authorizer() Authorizer {
+	if len(g.authorizers) == 0 {
+		return Authorizer{}
+	}
+	return g.authorizers[0]
 }
 
 // Tenants returns the tenants configuration for the gateway
@@ -322,12 +1090,79 @@ func (g *GatewayConfig) Tenants() observatoriumapi.Tenants {
 	return g.tenants
 }
 
+// TenantID resolves the name of a gateway tenant to its ID
+func (g *GatewayConfig) TenantID(name string) (string, bool) {
+	for _, t := range g.tenants.Tenants {
+		if t.Name == name {
+			return t.ID, true
+		}
+	}
+	return "", false
+}
+
 // RBAC returns the RBAC configuration for the gateway
 func (g *GatewayConfig) RBAC() cfgobservatorium.ObservatoriumRBAC {
 	return g.rbac
 }
 
+// DisruptionBudget returns the disruption budget for the gateway, nil if none is configured
+func (g *GatewayConfig) DisruptionBudget() *DisruptionBudget {
+	return g.disruption
+}
+
+// TopologySpread returns the topology spread constraints for the gateway
+func (g *GatewayConfig) TopologySpread() []TopologySpread {
+	return g.topologySpread
+}
+
 // CustomRoute returns the custom route for the gateway
 func (g *GatewayConfig) CustomRoute() string {
 	return g.customRoute
 }
+
+// Validate checks the gateway configuration, including that exactly one authorizer is selected
+func (g *GatewayConfig) Validate() error {
+	if len(g.authorizers) != 1 {
+		return fmt.Errorf("exactly one authorizer must be selected, got %d", len(g.authorizers))
+	}
+
+	a := g.authorizers[0]
+	switch a.Type {
+	case AuthorizerStaticRBAC:
+	case AuthorizerOPAAMS:
+		if a.AMSURL == "" {
+			return fmt.Errorf("authorizer %s requires an AMS URL", a.Type)
+		}
+		for _, m := range a.AMSMappings {
+			if m.Group == "" || m.OrganizationID == "" {
+				return fmt.Errorf("authorizer %s has an incomplete AMS mapping: %+v", a.Type, m)
+			}
+		}
+	case AuthorizerExternalOPA:
+		if a.OPAURL == "" {
+			return fmt.Errorf("authorizer %s requires an OPA URL", a.Type)
+		}
+	default:
+		return fmt.Errorf("unknown authorizer: %q", a.Type)
+	}
+
+	if g.disruption != nil && g.disruption.MaxUnavailable < 1 {
+		return fmt.Errorf("pod disruption budget must allow at least one unavailable pod, got %d", g.disruption.MaxUnavailable)
+	}
+	for _, spread := range g.topologySpread {
+		if spread.TopologyKey == "" || spread.MaxSkew < 1 {
+			return fmt.Errorf("topology spread needs a topology key and a max skew of at least 1: %+v", spread)
+		}
+	}
+	return nil
+}
+
+// DefaultAMSMappings returns the AMS organization mappings shared by the OPA-AMS gateways.
+// The organization IDs are resolved from template parameters at deploy time.
+func DefaultAMSMappings() []AMSMapping {
+	return []AMSMapping{
+		{Group: "osd", OrganizationID: "${OSD_ORGANIZATION_ID}"},
+		{Group: "osd", OrganizationID: "${SD_OPS_ORGANIZATION_ID}"},
+		{Group: "cnvqe", OrganizationID: "${CNVQE_ORGANIZATION_ID}"},
+	}
+}
@@ -0,0 +1,355 @@
+package clusters
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	lokiv1 "github.com/grafana/loki/operator/api/loki/v1"
+	observatoriumapi "github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/observatorium/api"
+	"github.com/thanos-community/thanos-operator/api/v1alpha1"
+)
+
+// checkError fails the test unless err contains want, or is nil when want is empty
+func checkError(t *testing.T, err error, want string) {
+	t.Helper()
+	if want == "" {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return
+	}
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Fatalf("expected an error containing %q, got %v", want, err)
+	}
+}
+
+func testGateway() *GatewayConfig {
+	return NewGatewayConfig(WithTenants(observatoriumapi.Tenants{
+		Tenants: []observatoriumapi.Tenant{
+			{Name: "hcp", ID: "EFD08939-FE1D-41A1-A28A-BE9A9BC68003"},
+			{Name: "rhobs", ID: "0fc2b00e-201b-4c17-b9f2-19d91adc4fd2"},
+		},
+	}))
+}
+
+func otlpLabels(names ...string) []LokiOTLPAttribute {
+	attrs := make([]LokiOTLPAttribute, 0, len(names))
+	for _, name := range names {
+		attrs = append(attrs, LokiOTLPAttribute{Name: name})
+	}
+	return attrs
+}
+
+func numberedOTLPLabels(prefix string, n int) []LokiOTLPAttribute {
+	attrs := make([]LokiOTLPAttribute, 0, n)
+	for i := range n {
+		attrs = append(attrs, LokiOTLPAttribute{Name: fmt.Sprintf("%s.%d", prefix, i)})
+	}
+	return attrs
+}
+
+func TestLokiOTLPValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		otlp   LokiOTLP
+		global *LokiOTLP
+		err    string
+	}{
+		{
+			name: "stream labels and dropped attributes",
+			otlp: LokiOTLP{
+				StreamLabels:      otlpLabels("k8s.namespace.name", "service.name"),
+				DropLogAttributes: []LokiOTLPAttribute{{Name: "http\\..*", Regex: true}},
+			},
+		},
+		{
+			name: "high cardinality stream label",
+			otlp: LokiOTLP{StreamLabels: otlpLabels("k8s.namespace.name", "k8s.pod.name")},
+			err:  "stream label k8s.pod.name has a high cardinality",
+		},
+		{
+			name: "regular expression stream label",
+			otlp: LokiOTLP{StreamLabels: []LokiOTLPAttribute{{Name: "k8s\\..*", Regex: true}}},
+			err:  "regular expressions can index an unbounded number of labels",
+		},
+		{
+			name: "dropped stream label",
+			otlp: LokiOTLP{
+				StreamLabels:           otlpLabels("service.name"),
+				DropResourceAttributes: otlpLabels("service.name"),
+			},
+			err: "stream label service.name cannot be dropped",
+		},
+		{
+			name: "invalid regular expression",
+			otlp: LokiOTLP{DropScopeAttributes: []LokiOTLPAttribute{{Name: "(", Regex: true}}},
+			err:  "invalid regular expression",
+		},
+		{
+			name: "duplicate attribute",
+			otlp: LokiOTLP{StreamLabels: otlpLabels("service.name", "service.name")},
+			err:  "attribute service.name is listed more than once",
+		},
+		{
+			name: "fifteen stream labels",
+			otlp: LokiOTLP{StreamLabels: numberedOTLPLabels("tenant", 5)},
+			global: &LokiOTLP{
+				StreamLabels: numberedOTLPLabels("global", 10),
+			},
+		},
+		{
+			name: "more than fifteen stream labels with the global mapping",
+			otlp: LokiOTLP{StreamLabels: numberedOTLPLabels("tenant", 6)},
+			global: &LokiOTLP{
+				StreamLabels: numberedOTLPLabels("global", 10),
+			},
+			err: "16 stream labels, Loki recommends at most 15",
+		},
+		{
+			name: "stream labels shared with the global mapping counted once",
+			otlp: LokiOTLP{StreamLabels: append(numberedOTLPLabels("global", 10), numberedOTLPLabels("tenant", 5)...)},
+			global: &LokiOTLP{
+				StreamLabels: numberedOTLPLabels("global", 10),
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			checkError(t, tc.otlp.validate(tc.global), tc.err)
+		})
+	}
+}
+
+func TestValidateHashrings(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		hashrings []Hashring
+		err       string
+	}{
+		{
+			name: "tenant hashring and catch-all",
+			hashrings: []Hashring{
+				{Name: "hcp", Tenants: []string{"EFD08939-FE1D-41A1-A28A-BE9A9BC68003"}},
+				{Name: "default"},
+			},
+		},
+		{
+			name: "catch-all before the last hashring",
+			hashrings: []Hashring{
+				{Name: "default"},
+				{Name: "hcp", Tenants: []string{"EFD08939-FE1D-41A1-A28A-BE9A9BC68003"}},
+			},
+			err: `hashring "default": only the last hashring can match every tenant`,
+		},
+		{
+			name: "tenant routed twice",
+			hashrings: []Hashring{
+				{Name: "hcp", Tenants: []string{"EFD08939-FE1D-41A1-A28A-BE9A9BC68003"}},
+				{Name: "glob", Tenants: []string{"EFD08939-*"}, MatcherType: TenantMatcherGlob},
+				{Name: "default"},
+			},
+			err: "tenant hcp (EFD08939-FE1D-41A1-A28A-BE9A9BC68003) is routed to several hashrings: hcp, glob",
+		},
+		{
+			name: "tenant not routed",
+			hashrings: []Hashring{
+				{Name: "hcp", Tenants: []string{"EFD08939-FE1D-41A1-A28A-BE9A9BC68003", ReceiveDefaultTenantID}},
+			},
+			err: "tenant rhobs (0fc2b00e-201b-4c17-b9f2-19d91adc4fd2) is not routed to any hashring",
+		},
+		{
+			name:      "bucket with the bucket of the receivers",
+			hashrings: []Hashring{{Name: "default", Bucket: DefaultBucket, ReceiveBucket: true}},
+			err:       "a bucket cannot be set with the bucket of the receivers",
+		},
+		{
+			name:      "unknown component",
+			hashrings: []Hashring{{Name: "default", Component: "RECEIVE_UNKNOWN"}},
+			err:       `unknown component "RECEIVE_UNKNOWN"`,
+		},
+		{
+			name:      "invalid retention",
+			hashrings: []Hashring{{Name: "default", Retention: "1x"}},
+			err:       `hashring "default": invalid retention`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := ClusterConfig{
+				Templates:     DefaultBaseTemplate(),
+				GatewayConfig: testGateway(),
+				Hashrings:     tc.hashrings,
+			}
+			checkError(t, c.validateHashrings(), tc.err)
+		})
+	}
+}
+
+func TestCompactionPolicyValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		policy CompactionPolicy
+		err    string
+	}{
+		{
+			name: "tenant and external label shards",
+			policy: CompactionPolicy{Shards: []CompactionShard{
+				{Name: "hcp", Tenants: []string{"hcp"}, ExternalLabels: receiveBlocks},
+				{Name: "default", DefaultTenant: true},
+				{Name: "rules", ExternalLabels: []v1alpha1.ExternalLabelShardingConfig{{Label: "receive", Value: "!true"}}},
+			}},
+		},
+		{
+			name:   "shard selecting nothing",
+			policy: CompactionPolicy{Shards: []CompactionShard{{Name: "empty"}}},
+			err:    `shard "empty" has no tenants nor external labels`,
+		},
+		{
+			name: "invalid external label value",
+			policy: CompactionPolicy{Shards: []CompactionShard{
+				{Name: "rules", ExternalLabels: []v1alpha1.ExternalLabelShardingConfig{{Label: "receive", Value: "("}}},
+			}},
+			err: `shard "rules": invalid value of external label "receive"`,
+		},
+		{
+			name: "tenant compacted by two shards",
+			policy: CompactionPolicy{Shards: []CompactionShard{
+				{Name: "hcp", Tenants: []string{"hcp"}},
+				{Name: "all", Tenants: []string{"hcp", "rhobs"}},
+			}},
+			err: `is compacted by both shards "hcp" and "all"`,
+		},
+		{
+			name:   "raw retention shorter than the downsampling",
+			policy: CompactionPolicy{RawRetention: "1d"},
+			err:    "raw retention 1d is shorter than",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			checkError(t, tc.policy.Validate(testGateway().Tenants()), tc.err)
+		})
+	}
+}
+
+func TestValidateReceiveLimits(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		limits ReceiveLimits
+		err    string
+	}{
+		{
+			name: "default and tenant limits",
+			limits: ReceiveLimits{
+				MetaMonitoringURL: "http://prometheus:9090",
+				Default:           WriteLimits{SeriesLimit: 5000},
+				Tenants:           map[string]WriteLimits{"hcp": {HeadSeriesLimit: 1000000}},
+			},
+		},
+		{
+			name:   "unregistered tenant",
+			limits: ReceiveLimits{Tenants: map[string]WriteLimits{"unregistered": {SeriesLimit: 1}}},
+			err:    `tenant "unregistered" is not registered in the gateway`,
+		},
+		{
+			name:   "head series limit without meta-monitoring",
+			limits: ReceiveLimits{Tenants: map[string]WriteLimits{"hcp": {HeadSeriesLimit: 1}}},
+			err:    "head series limits require a meta-monitoring URL",
+		},
+		{
+			name:   "negative limit",
+			limits: ReceiveLimits{Default: WriteLimits{SamplesLimit: -1}},
+			err:    "default limits: request limits cannot be negative",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := ClusterConfig{GatewayConfig: testGateway(), ReceiveLimits: &tc.limits}
+			checkError(t, c.validateReceiveLimits(), tc.err)
+		})
+	}
+}
+
+func TestValidateLokiSchemas(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		schemas []LokiSchema
+		steps   []string
+		err     string
+	}{
+		{
+			name: "increasing dates",
+			schemas: []LokiSchema{
+				{EffectiveDate: "2024-01-01", Version: lokiv1.ObjectStorageSchemaV12},
+				{EffectiveDate: "2025-06-06", Version: lokiv1.ObjectStorageSchemaV13},
+			},
+			steps: []string{StepDefaultLokiStack},
+		},
+		{
+			name:  "LokiStack without schemas",
+			steps: []string{StepDefaultLokiStack},
+			err:   "requires at least one schema",
+		},
+		{
+			name: "non-monotonic dates",
+			schemas: []LokiSchema{
+				{EffectiveDate: "2025-06-06", Version: lokiv1.ObjectStorageSchemaV12},
+				{EffectiveDate: "2025-06-06", Version: lokiv1.ObjectStorageSchemaV13},
+			},
+			err: "effective dates must be increasing",
+		},
+		{
+			name:    "unknown version",
+			schemas: []LokiSchema{{EffectiveDate: "2025-06-06", Version: "v14"}},
+			err:     `unknown version "v14"`,
+		},
+		{
+			name:    "invalid date",
+			schemas: []LokiSchema{{EffectiveDate: "06/06/2025", Version: lokiv1.ObjectStorageSchemaV13}},
+			err:     "invalid effective date",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := ClusterConfig{LokiSchemas: tc.schemas, BuildSteps: tc.steps}
+			checkError(t, c.validateLokiSchemas(), tc.err)
+		})
+	}
+}
+
+func TestValidateCaches(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		caches map[CacheRole]Cache
+		err    string
+	}{
+		{
+			name: "memcached and redis",
+			caches: map[CacheRole]Cache{
+				CacheRoleIndex:      {Backend: CacheBackendMemcached},
+				CacheRoleQueryRange: {Backend: CacheBackendRedis, Address: "redis.example.com:6379"},
+			},
+		},
+		{
+			name:   "unknown role",
+			caches: map[CacheRole]Cache{"gateway-api": {Backend: CacheBackendMemcached}},
+			err:    `unknown cache role "gateway-api"`,
+		},
+		{
+			name:   "memcached with an address",
+			caches: map[CacheRole]Cache{CacheRoleBucket: {Backend: CacheBackendMemcached, Address: "memcached:11211"}},
+			err:    "an address is only supported by the redis backend",
+		},
+		{
+			name:   "redis address without a port",
+			caches: map[CacheRole]Cache{CacheRoleBucket: {Backend: CacheBackendRedis, Address: "redis.example.com"}},
+			err:    "bucket cache: invalid address",
+		},
+		{
+			name:   "unknown backend",
+			caches: map[CacheRole]Cache{CacheRoleIndex: {Backend: "valkey"}},
+			err:    `unknown backend "valkey"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := ClusterConfig{Caches: tc.caches}
+			checkError(t, c.validateCaches(), tc.err)
+		})
+	}
+}
@@ -1,8 +1,16 @@
 package clusters
 
 import (
+	"cmp"
 	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"time"
 
+	lokiv1 "github.com/grafana/loki/operator/api/loki/v1"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/promql/parser"
 	"github.com/thanos-community/thanos-operator/api/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -20,16 +28,226 @@ type TemplateMaps struct {
 	StorageSize          ParamMap[v1alpha1.StorageSize]
 	Replicas             ParamMap[int32]
 	ResourceRequirements ParamMap[corev1.ResourceRequirements]
-	ObjectStorageBucket  ParamMap[v1alpha1.ObjectStorageConfig]
+	ObjectStorageBucket  ParamMap[ObjectStorageBucket]
 	LokiOverrides        ParamMap[LokiOverrides]
+	Autoscaling          ParamMap[AutoscalingSpec]
+	StoreTiers           ParamMap[[]StoreTier]
+	QueryFrontendPolicy  ParamMap[QueryFrontendPolicy]
+}
+
+// ObjectStorageProvider is an object storage provider, named after the Thanos objstore client types
+type ObjectStorageProvider string
+
+const (
+	ObjectStorageS3    ObjectStorageProvider = "S3"
+	ObjectStorageGCS   ObjectStorageProvider = "GCS"
+	ObjectStorageAzure ObjectStorageProvider = "AZURE"
+	ObjectStorageSwift ObjectStorageProvider = "SWIFT"
+	// ObjectStorageFilesystem stores blocks on a local directory and is only meant for test clusters.
+	ObjectStorageFilesystem ObjectStorageProvider = "FILESYSTEM"
+)
+
+// ObjectStorageBucket is a bucket of the cluster: the secret its components read the objstore configuration from,
+// and the storage behind it, which that secret is generated from.
+type ObjectStorageBucket struct {
+	v1alpha1.ObjectStorageConfig
+	Storage ObjectStorage
+}
+
+// ObjectStorage describes the storage behind an object storage bucket.
+// Empty fields are rendered as template parameters and provided at deploy time along with the credentials.
+type ObjectStorage struct {
+	Provider ObjectStorageProvider
+	// Bucket is the bucket or container name, or the directory of the filesystem provider
+	Bucket string
+	// Region applies to S3 and Swift
+	Region string
+	// Endpoint is the S3 or Azure endpoint, or the Swift auth URL
+	Endpoint string
+}
+
+// Validate checks that the provider is known
+func (o ObjectStorage) Validate() error {
+	switch o.Provider {
+	case ObjectStorageS3, ObjectStorageGCS, ObjectStorageAzure, ObjectStorageSwift, ObjectStorageFilesystem:
+		return nil
+	default:
+		return fmt.Errorf("unknown object storage provider %q", o.Provider)
+	}
+}
+
+// AutoscalingSpec configures a HorizontalPodAutoscaler for a component.
+// When set for a component, its static replica count is ignored.
+type AutoscalingSpec struct {
+	MinReplicas int32
+	MaxReplicas int32
+	// TargetCPUUtilization and TargetMemoryUtilization are average utilization percentages of the container requests.
+	TargetCPUUtilization    int32
+	TargetMemoryUtilization int32
+	// CustomMetrics are per-pod metrics served through the custom metrics API.
+	CustomMetrics []CustomMetricTarget
+}
+
+// CustomMetricTarget scales on the per-pod average of a custom metric
+type CustomMetricTarget struct {
+	Name         string
+	AverageValue resource.Quantity
+}
+
+// Validate checks that the autoscaling bounds are consistent and that there is something to scale on
+func (a AutoscalingSpec) Validate() error {
+	if a.MinReplicas < 1 {
+		return fmt.Errorf("min replicas must be at least 1, got %d", a.MinReplicas)
+	}
+	if a.MaxReplicas < a.MinReplicas {
+		return fmt.Errorf("max replicas (%d) must not be lower than min replicas (%d)", a.MaxReplicas, a.MinReplicas)
+	}
+	if a.TargetCPUUtilization == 0 && a.TargetMemoryUtilization == 0 && len(a.CustomMetrics) == 0 {
+		return fmt.Errorf("at least one CPU, memory or custom metric target is required")
+	}
+	for _, m := range a.CustomMetrics {
+		if m.Name == "" || m.AverageValue.IsZero() {
+			return fmt.Errorf("custom metric targets need a name and a non-zero average value: %+v", m)
+		}
+	}
+	return nil
+}
+
+// StoreTier is a time partition of the blocks served by a store component.
+// Tiers are listed newest first, each one starting where the previous one ends.
+// Zero values fall back to the replicas, resources and storage size of the store component itself.
+type StoreTier struct {
+	// Name is appended to the store name to name the tier's ThanosStore.
+	Name string
+	// MinAge and MaxAge bound the age of the blocks served by the tier, e.g. "22h", "2w" or "90d".
+	// An empty bound keeps the one of the store component: an empty MinAge serves the most recent blocks
+	// the store serves, blocks older than 22h for the default store, and an empty MaxAge serves every block
+	// older than MinAge and is only allowed on the last tier.
+	MinAge      string
+	MaxAge      string
+	Replicas    int32
+	Shards      int32
+	Resources   corev1.ResourceRequirements
+	StorageSize v1alpha1.StorageSize
+	// IgnoreDeletionMarksDelay replaces the delay of the store after which blocks marked for deletion are ignored.
+	IgnoreDeletionMarksDelay v1alpha1.Duration
+	// EagerIndexHeaders downloads the index headers of the tier's blocks up front instead of lazily.
+	EagerIndexHeaders bool
+	// ExternalCaches caches the index and the chunks of the tier in memcached rather than in memory.
+	ExternalCaches bool
+	// DisablePodDisruptionBudget runs the tier without a PodDisruptionBudget.
+	DisablePodDisruptionBudget bool
+}
+
+// ValidateStoreTiers checks that the tiers are named and cover a contiguous time range without gaps or overlaps.
+func ValidateStoreTiers(tiers []StoreTier) error {
+	names := map[string]struct{}{}
+	var prevMaxAge model.Duration
+	for i, tier := range tiers {
+		if tier.Name == "" {
+			return fmt.Errorf("tier %d has no name", i)
+		}
+		if _, ok := names[tier.Name]; ok {
+			return fmt.Errorf("duplicate tier name %q", tier.Name)
+		}
+		names[tier.Name] = struct{}{}
+
+		if tier.Replicas < 0 || tier.Shards < 0 {
+			return fmt.Errorf("tier %q: replicas and shards cannot be negative", tier.Name)
+		}
+		if tier.IgnoreDeletionMarksDelay != "" {
+			if _, err := model.ParseDuration(string(tier.IgnoreDeletionMarksDelay)); err != nil {
+				return fmt.Errorf("tier %q: invalid ignore deletion marks delay: %w", tier.Name, err)
+			}
+		}
+
+		minAge, err := parseTierAge(tier.MinAge)
+		if err != nil {
+			return fmt.Errorf("tier %q: invalid min age: %w", tier.Name, err)
+		}
+		if i > 0 && minAge != prevMaxAge {
+			if minAge > prevMaxAge {
+				return fmt.Errorf("tier %q: gap in coverage between %s and %s", tier.Name, prevMaxAge, minAge)
+			}
+			return fmt.Errorf("tier %q: coverage overlaps the previous tier between %s and %s", tier.Name, minAge, prevMaxAge)
+		}
+
+		if tier.MaxAge == "" {
+			if i != len(tiers)-1 {
+				return fmt.Errorf("tier %q: only the last tier can have an unbounded max age", tier.Name)
+			}
+			continue
+		}
+		maxAge, err := parseTierAge(tier.MaxAge)
+		if err != nil {
+			return fmt.Errorf("tier %q: invalid max age: %w", tier.Name, err)
+		}
+		if maxAge <= minAge {
+			return fmt.Errorf("tier %q: max age %s must be greater than min age %s", tier.Name, maxAge, minAge)
+		}
+		prevMaxAge = maxAge
+	}
+	return nil
+}
+
+func parseTierAge(age string) (model.Duration, error) {
+	if age == "" {
+		return 0, nil
+	}
+	return model.ParseDuration(age)
 }
 
 type LokiOverrides struct {
 	LokiLimitOverrides
+	// Retention applies to the tenants without a retention of their own. Nil keeps logs until the bucket expires them.
+	Retention *LokiRetention
+	// Tenants override the limits of single tenants, keyed by gateway tenant name.
+	Tenants map[string]LokiTenantLimits
+	// OTLP maps the attributes of the logs ingested over OTLP.
+	OTLP *LokiOTLP
+
+	// Size is the sizing preset of the LokiStack, the components take what is not set here from it.
+	Size lokiv1.LokiStackSizeType
+	// StorageClass provisions the volumes of the stateful components.
+	StorageClass string
+	// UseRequestsAsLimits sets the resource limits of every component to the requests of the preset.
+	UseRequestsAsLimits bool
+
 	Router        LokiComponentSpec
 	Ingest        LokiComponentSpec
 	Query         LokiComponentSpec
 	QueryFrontend LokiComponentSpec
+	Compactor     LokiComponentSpec
+	IndexGateway  LokiComponentSpec
+	Ruler         LokiComponentSpec
+	Gateway       LokiComponentSpec
+}
+
+// lokiStackSizes are the sizing presets of the Loki operator
+var lokiStackSizes = []lokiv1.LokiStackSizeType{
+	lokiv1.SizeOneXDemo,
+	lokiv1.SizeOneXPico,
+	lokiv1.SizeOneXExtraSmall,
+	lokiv1.SizeOneXSmall,
+	lokiv1.SizeOneXMedium,
+}
+
+func (l LokiOverrides) validateStack() error {
+	if !slices.Contains(lokiStackSizes, l.Size) {
+		return fmt.Errorf("unknown size %q, must be one of %v", l.Size, lokiStackSizes)
+	}
+	if l.StorageClass == "" {
+		return fmt.Errorf("storage class cannot be empty")
+	}
+	for name, component := range map[string]LokiComponentSpec{
+		"router": l.Router, "ingest": l.Ingest, "query": l.Query, "query frontend": l.QueryFrontend,
+		"compactor": l.Compactor, "index gateway": l.IndexGateway, "ruler": l.Ruler, "gateway": l.Gateway,
+	} {
+		if component.Replicas < 0 {
+			return fmt.Errorf("%s replicas cannot be negative", name)
+		}
+	}
+	return nil
 }
 
 type LokiLimitOverrides struct {
@@ -40,11 +258,152 @@ type LokiLimitOverrides struct {
 	PerStreamRateLimitMB int32
 	PerStreamBurstSizeMB int32
 
-	QueryTimeout string
+	QueryTimeout            string
+	MaxQuerySeries          int32
+	MaxChunksPerQuery       int32
+	MaxEntriesLimitPerQuery int32
+}
+
+// LokiTenantLimits override the global limits for a tenant. Zero values inherit the global limits.
+type LokiTenantLimits struct {
+	LokiLimitOverrides
+	// Retention replaces the global retention for the tenant.
+	Retention *LokiRetention
+	// OTLP is merged with the global mapping by the operator.
+	OTLP *LokiOTLP
+}
+
+// LokiOTLP maps the attributes of OTLP logs to Loki. Attributes that are neither stream labels nor dropped are
+// stored as structured metadata, which can be queried without indexing them.
+type LokiOTLP struct {
+	// StreamLabels are the resource attributes indexed as stream labels.
+	StreamLabels []LokiOTLPAttribute
+	// DropResourceAttributes, DropScopeAttributes and DropLogAttributes are dropped from the log entries.
+	DropResourceAttributes []LokiOTLPAttribute
+	DropScopeAttributes    []LokiOTLPAttribute
+	DropLogAttributes      []LokiOTLPAttribute
+}
+
+// LokiOTLPAttribute is the OpenTelemetry name of an attribute, or a regular expression matching attribute names.
+type LokiOTLPAttribute struct {
+	Name  string
+	Regex bool
 }
 
+const (
+	// maxLokiStreamLabels follows the Loki guidance to keep streams under 15 labels.
+	maxLokiStreamLabels = 15
+)
+
+// highCardinalityOTLPAttributes identify a pod, container or process, and would create a stream for each of them.
+// Loki keeps them in structured metadata by default.
+var highCardinalityOTLPAttributes = []string{
+	"k8s.pod.name",
+	"k8s.pod.uid",
+	"container.id",
+	"service.instance.id",
+	"process.pid",
+}
+
+// validate checks the mapping, merged with the global one for a tenant, against the label cardinality guidance of Loki.
+func (o LokiOTLP) validate(global *LokiOTLP) error {
+	for _, attrs := range [][]LokiOTLPAttribute{o.StreamLabels, o.DropResourceAttributes, o.DropScopeAttributes, o.DropLogAttributes} {
+		seen := map[LokiOTLPAttribute]bool{}
+		for _, attr := range attrs {
+			if attr.Name == "" {
+				return fmt.Errorf("attribute name cannot be empty")
+			}
+			if seen[attr] {
+				return fmt.Errorf("attribute %s is listed more than once", attr.Name)
+			}
+			seen[attr] = true
+			if attr.Regex {
+				if _, err := regexp.Compile(attr.Name); err != nil {
+					return fmt.Errorf("attribute %s: invalid regular expression: %w", attr.Name, err)
+				}
+			}
+		}
+	}
+
+	labels := map[string]bool{}
+	if global != nil {
+		for _, attr := range global.StreamLabels {
+			labels[attr.Name] = true
+		}
+	}
+	for _, attr := range o.StreamLabels {
+		if attr.Regex {
+			return fmt.Errorf("stream label %s: regular expressions can index an unbounded number of labels", attr.Name)
+		}
+		if slices.Contains(highCardinalityOTLPAttributes, attr.Name) {
+			return fmt.Errorf("stream label %s has a high cardinality, keep it in structured metadata", attr.Name)
+		}
+		if slices.Contains(o.DropResourceAttributes, attr) {
+			return fmt.Errorf("stream label %s cannot be dropped", attr.Name)
+		}
+		labels[attr.Name] = true
+	}
+	if len(labels) > maxLokiStreamLabels {
+		return fmt.Errorf("%d stream labels, Loki recommends at most %d", len(labels), maxLokiStreamLabels)
+	}
+	return nil
+}
+
+// LokiRetention controls how long logs are kept in storage
+type LokiRetention struct {
+	// Days logs are kept, at least one.
+	Days uint
+	// Streams keep the logs matching their selector for a different period.
+	Streams []LokiStreamRetention
+}
+
+// LokiStreamRetention keeps the streams matching a LogQL stream selector for a different period.
+// When several rules match a stream, the one with the highest priority applies.
+type LokiStreamRetention struct {
+	Selector string
+	Days     uint
+	Priority uint32
+}
+
+func (l LokiLimitOverrides) validate() error {
+	for _, limit := range []int32{
+		l.IngestionRateLimitMB, l.IngestionBurstSizeMB, l.MaxLineSize, l.PerStreamRateLimitMB, l.PerStreamBurstSizeMB,
+		l.MaxQuerySeries, l.MaxChunksPerQuery, l.MaxEntriesLimitPerQuery,
+	} {
+		if limit < 0 {
+			return fmt.Errorf("limits cannot be negative")
+		}
+	}
+	if l.QueryTimeout != "" {
+		if _, err := model.ParseDuration(l.QueryTimeout); err != nil {
+			return fmt.Errorf("invalid query timeout: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r LokiRetention) validate() error {
+	if r.Days == 0 {
+		return fmt.Errorf("retention must keep logs for at least one day")
+	}
+	for _, stream := range r.Streams {
+		if stream.Days == 0 {
+			return fmt.Errorf("stream %s: retention must keep logs for at least one day", stream.Selector)
+		}
+		if _, err := parser.ParseMetricSelector(stream.Selector); err != nil {
+			return fmt.Errorf("stream %q: invalid selector: %w", stream.Selector, err)
+		}
+	}
+	return nil
+}
+
+// LokiComponentSpec sizes and places the pods of a LokiStack component. Zero values keep the preset of the stack.
+// It sets no resource requests, as the LokiStack API only sizes the components through the Size preset.
 type LokiComponentSpec struct {
-	Replicas int32
+	Replicas        int32
+	NodeSelector    map[string]string
+	Tolerations     []corev1.Toleration
+	PodAntiAffinity *corev1.PodAntiAffinity
 }
 
 // Override applies overrides to a TemplateMaps and returns a new instance
@@ -56,6 +415,106 @@ func (t TemplateMaps) Override(overrides ...TemplateOverride) TemplateMaps {
 	return result
 }
 
+// QueryFrontendCache selects where the query frontend caches query range results
+type QueryFrontendCache string
+
+const (
+	// QueryFrontendCacheExternal caches results in the query-range cache role of the cluster.
+	QueryFrontendCacheExternal QueryFrontendCache = "external"
+	QueryFrontendCacheInMemory QueryFrontendCache = "in-memory"
+	QueryFrontendCacheDisabled QueryFrontendCache = "disabled"
+)
+
+// QueryFrontendPolicy configures how the query frontend splits, retries, caches and logs queries.
+// Durations use the Prometheus format, e.g. "48h" or "2w". Empty durations and zero values leave the Thanos default.
+type QueryFrontendPolicy struct {
+	QueryRangeSplitInterval string
+	QueryRangeMaxRetries    int
+	ResultsCache            QueryFrontendCache
+	// ResultsCacheTTL expires cached results after this long. It only applies to the external cache,
+	// which otherwise keeps results until they are evicted.
+	ResultsCacheTTL string
+	// InMemoryCacheMaxSize bounds the in-memory results cache.
+	InMemoryCacheMaxSize v1alpha1.StorageSize
+	// LogQueriesLongerThan logs the queries slower than this.
+	LogQueriesLongerThan   string
+	LabelsSplitInterval    string
+	LabelsMaxRetries       int
+	LabelsDefaultTimeRange string
+	// LabelsCacheMaxSize enables an in-memory cache of the label responses of this size, e.g. "256MB".
+	// LabelsCacheValidity expires the cached responses after this long.
+	LabelsCacheMaxSize  string
+	LabelsCacheValidity string
+	// VerticalShards splits each query by series into this many shards evaluated concurrently.
+	VerticalShards int32
+}
+
+// ResultsCacheExpiration parses the results cache TTL, zero when unset
+func (p QueryFrontendPolicy) ResultsCacheExpiration() (time.Duration, error) {
+	return parsePolicyDuration("results cache TTL", p.ResultsCacheTTL)
+}
+
+// LabelsCacheExpiration parses the labels cache validity, zero when unset
+func (p QueryFrontendPolicy) LabelsCacheExpiration() (time.Duration, error) {
+	return parsePolicyDuration("labels cache validity", p.LabelsCacheValidity)
+}
+
+func parsePolicyDuration(name, value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	d, err := model.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return time.Duration(d), nil
+}
+
+// Validate checks the durations and that the cache settings match the selected cache
+func (p QueryFrontendPolicy) Validate() error {
+	durations := []struct {
+		name, value string
+	}{
+		{"query range split interval", p.QueryRangeSplitInterval},
+		{"results cache TTL", p.ResultsCacheTTL},
+		{"slow query threshold", p.LogQueriesLongerThan},
+		{"labels split interval", p.LabelsSplitInterval},
+		{"labels default time range", p.LabelsDefaultTimeRange},
+		{"labels cache validity", p.LabelsCacheValidity},
+	}
+	for _, d := range durations {
+		if _, err := parsePolicyDuration(d.name, d.value); err != nil {
+			return err
+		}
+	}
+	if p.QueryRangeMaxRetries < 0 || p.LabelsMaxRetries < 0 || p.VerticalShards < 0 {
+		return fmt.Errorf("retries and vertical shards cannot be negative")
+	}
+
+	switch p.ResultsCache {
+	case QueryFrontendCacheExternal, QueryFrontendCacheDisabled:
+		if p.InMemoryCacheMaxSize != "" {
+			return fmt.Errorf("the in-memory cache size only applies to the %s results cache", QueryFrontendCacheInMemory)
+		}
+	case QueryFrontendCacheInMemory:
+		if p.InMemoryCacheMaxSize == "" {
+			return fmt.Errorf("the %s results cache needs a max size", QueryFrontendCacheInMemory)
+		}
+	default:
+		return fmt.Errorf("unknown results cache %q", p.ResultsCache)
+	}
+	if p.ResultsCacheTTL != "" && p.ResultsCache != QueryFrontendCacheExternal {
+		return fmt.Errorf("the results cache TTL only applies to the %s results cache", QueryFrontendCacheExternal)
+	}
+	if p.LabelsCacheValidity != "" && p.LabelsCacheMaxSize == "" {
+		return fmt.Errorf("the labels cache validity needs a labels cache max size")
+	}
+	if p.LabelsCacheMaxSize != "" && p.LabelsSplitInterval == "" {
+		return fmt.Errorf("the labels cache needs a labels split interval")
+	}
+	return nil
+}
+
 // TemplateOverride represents a set of overrides to apply to a TemplateMaps
 type TemplateOverride interface {
 	Apply(TemplateMaps) TemplateMaps
@@ -152,11 +611,29 @@ func (l LokiOverridesMap) Apply(t TemplateMaps) TemplateMaps {
 
 		// Merge the override with existing values
 		merged := LokiOverrides{
-			LokiLimitOverrides: mergeLokiLimitOverrides(existing.LokiLimitOverrides, v.LokiLimitOverrides),
-			Router:             mergeComponentSpec(existing.Router, v.Router),
-			Ingest:             mergeComponentSpec(existing.Ingest, v.Ingest),
-			Query:              mergeComponentSpec(existing.Query, v.Query),
-			QueryFrontend:      mergeComponentSpec(existing.QueryFrontend, v.QueryFrontend),
+			LokiLimitOverrides:  mergeLokiLimitOverrides(existing.LokiLimitOverrides, v.LokiLimitOverrides),
+			Retention:           cmp.Or(v.Retention, existing.Retention),
+			OTLP:                cmp.Or(v.OTLP, existing.OTLP),
+			Tenants:             existing.Tenants,
+			Size:                cmp.Or(v.Size, existing.Size),
+			StorageClass:        cmp.Or(v.StorageClass, existing.StorageClass),
+			UseRequestsAsLimits: existing.UseRequestsAsLimits || v.UseRequestsAsLimits,
+			Router:              mergeComponentSpec(existing.Router, v.Router),
+			Ingest:              mergeComponentSpec(existing.Ingest, v.Ingest),
+			Query:               mergeComponentSpec(existing.Query, v.Query),
+			QueryFrontend:       mergeComponentSpec(existing.QueryFrontend, v.QueryFrontend),
+			Compactor:           mergeComponentSpec(existing.Compactor, v.Compactor),
+			IndexGateway:        mergeComponentSpec(existing.IndexGateway, v.IndexGateway),
+			Ruler:               mergeComponentSpec(existing.Ruler, v.Ruler),
+			Gateway:             mergeComponentSpec(existing.Gateway, v.Gateway),
+		}
+		// Tenant limits replace the limits of the same tenant
+		if len(v.Tenants) > 0 {
+			merged.Tenants = maps.Clone(existing.Tenants)
+			if merged.Tenants == nil {
+				merged.Tenants = make(map[string]LokiTenantLimits, len(v.Tenants))
+			}
+			maps.Copy(merged.Tenants, v.Tenants)
 		}
 
 		t.LokiOverrides[k] = merged
@@ -164,12 +641,73 @@ func (l LokiOverridesMap) Apply(t TemplateMaps) TemplateMaps {
 	return t
 }
 
+// Autoscaling override
+type Autoscaling map[string]AutoscalingSpec
+
+func (a Autoscaling) Apply(t TemplateMaps) TemplateMaps {
+	if t.Autoscaling == nil {
+		t.Autoscaling = make(ParamMap[AutoscalingSpec])
+	}
+	for k, v := range a {
+		t.Autoscaling[k] = v
+	}
+	return t
+}
+
+// StoreTiers override replaces the tiers of a store component
+type StoreTiers map[string][]StoreTier
+
+func (s StoreTiers) Apply(t TemplateMaps) TemplateMaps {
+	if t.StoreTiers == nil {
+		t.StoreTiers = make(ParamMap[[]StoreTier])
+	}
+	for k, v := range s {
+		t.StoreTiers[k] = v
+	}
+	return t
+}
+
+// ObjectStorageBuckets override
+type ObjectStorageBuckets map[string]ObjectStorageBucket
+
+func (o ObjectStorageBuckets) Apply(t TemplateMaps) TemplateMaps {
+	if t.ObjectStorageBucket == nil {
+		t.ObjectStorageBucket = make(ParamMap[ObjectStorageBucket])
+	}
+	for k, v := range o {
+		t.ObjectStorageBucket[k] = v
+	}
+	return t
+}
+
+// QueryFrontendPolicies override replaces the policy of a query frontend component
+type QueryFrontendPolicies map[string]QueryFrontendPolicy
+
+func (q QueryFrontendPolicies) Apply(t TemplateMaps) TemplateMaps {
+	if t.QueryFrontendPolicy == nil {
+		t.QueryFrontendPolicy = make(ParamMap[QueryFrontendPolicy])
+	}
+	for k, v := range q {
+		t.QueryFrontendPolicy[k] = v
+	}
+	return t
+}
+
 // mergeComponentSpec merges two LokiComponentSpec, using override values when non-zero
 func mergeComponentSpec(existing, override LokiComponentSpec) LokiComponentSpec {
 	result := existing
 	if override.Replicas != 0 {
 		result.Replicas = override.Replicas
 	}
+	if override.NodeSelector != nil {
+		result.NodeSelector = override.NodeSelector
+	}
+	if override.Tolerations != nil {
+		result.Tolerations = override.Tolerations
+	}
+	if override.PodAntiAffinity != nil {
+		result.PodAntiAffinity = override.PodAntiAffinity
+	}
 	return result
 }
 
@@ -188,9 +726,21 @@ func mergeLokiLimitOverrides(existing, override LokiLimitOverrides) LokiLimitOve
 	if override.PerStreamBurstSizeMB != 0 {
 		result.PerStreamBurstSizeMB = override.PerStreamBurstSizeMB
 	}
+	if override.MaxLineSize != 0 {
+		result.MaxLineSize = override.MaxLineSize
+	}
 	if override.QueryTimeout != "" {
 		result.QueryTimeout = override.QueryTimeout
 	}
+	if override.MaxQuerySeries != 0 {
+		result.MaxQuerySeries = override.MaxQuerySeries
+	}
+	if override.MaxChunksPerQuery != 0 {
+		result.MaxChunksPerQuery = override.MaxChunksPerQuery
+	}
+	if override.MaxEntriesLimitPerQuery != 0 {
+		result.MaxEntriesLimitPerQuery = override.MaxEntriesLimitPerQuery
+	}
 	return result
 }
 
@@ -258,6 +808,7 @@ const (
 	ThanosOperator         = "THANOS_OPERATOR"
 	KubeRbacProxy          = "KUBE_RBAC_PROXY"
 	StoreDefault           = "STORE_DEFAULT"
+	StoreTelemeter         = "STORE_TELEMETER"
 	ReceiveRouter          = "RECEIVE_ROUTER"
 	ReceiveIngestorDefault = "RECEIVE_INGESTOR_DEFAULT"
 	Ruler                  = "RULER"
@@ -268,8 +819,12 @@ const (
 
 	LokiConfig = "LOKI_CONFIG"
 
+	TempoStack = "TEMPO_STACK"
+
 	// Object storage keys
 	DefaultBucket = "DEFAULT_BUCKET"
+	LokiBucket    = "LOKI_BUCKET"
+	TracesBucket  = "TRACES_BUCKET"
 )
 
 var logLevels = []string{"debug", "info", "warn", "error"}
@@ -402,6 +957,12 @@ func DefaultBaseTemplate() TemplateMaps {
 					corev1.ResourceMemory: resource.MustParse("32Mi"),
 				},
 			},
+			TempoStack: {
+				Limits: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("2"),
+					corev1.ResourceMemory: resource.MustParse("4Gi"),
+				},
+			},
 		},
 		Replicas: ParamMap[int32]{
 			StoreDefault:           1,
@@ -418,14 +979,47 @@ func DefaultBaseTemplate() TemplateMaps {
 			ReceiveIngestorDefault: "10Gi",
 			CompactDefault:         "50Gi",
 			Ruler:                  "10Gi",
-		},
-		ObjectStorageBucket: ParamMap[v1alpha1.ObjectStorageConfig]{
-			DefaultBucket: v1alpha1.ObjectStorageConfig{
-				Key: "thanos.yaml",
-				LocalObjectReference: corev1.LocalObjectReference{
-					Name: "default-thanos-bucket",
+			TempoStack:             "10Gi",
+		},
+		ObjectStorageBucket: ParamMap[ObjectStorageBucket]{
+			DefaultBucket: {
+				ObjectStorageConfig: v1alpha1.ObjectStorageConfig{
+					Key: "thanos.yaml",
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: "default-thanos-bucket",
+					},
+					Optional: ptr.To(false),
+				},
+				Storage: ObjectStorage{Provider: ObjectStorageS3},
+			},
+			// The Loki operator reads the whole secret, the key is left empty.
+			LokiBucket: {
+				ObjectStorageConfig: v1alpha1.ObjectStorageConfig{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: "loki-default-bucket",
+					},
+				},
+				Storage: ObjectStorage{Provider: ObjectStorageS3},
+			},
+			// The Tempo operator reads the whole secret as well.
+			TracesBucket: {
+				ObjectStorageConfig: v1alpha1.ObjectStorageConfig{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: "tempo-default-bucket",
+					},
 				},
-				Optional: ptr.To(false),
+				Storage: ObjectStorage{Provider: ObjectStorageS3},
+			},
+		},
+		QueryFrontendPolicy: ParamMap[QueryFrontendPolicy]{
+			QueryFrontend: {
+				QueryRangeSplitInterval: "48h",
+				QueryRangeMaxRetries:    3,
+				ResultsCache:            QueryFrontendCacheExternal,
+				LogQueriesLongerThan:    "10s",
+				LabelsSplitInterval:     "48h",
+				LabelsMaxRetries:        3,
+				LabelsDefaultTimeRange:  "336h",
 			},
 		},
 		LokiOverrides: ParamMap[LokiOverrides]{
@@ -438,6 +1032,16 @@ func DefaultBaseTemplate() TemplateMaps {
 					PerStreamBurstSizeMB: 15,
 					QueryTimeout:         "3m",
 				},
+				OTLP: &LokiOTLP{
+					StreamLabels: []LokiOTLPAttribute{
+						{Name: "k8s.namespace.name"},
+						{Name: "openshift.label.cluster_name"},
+						{Name: "openshift.log.source"},
+						{Name: "openshift.log.type"},
+					},
+				},
+				Size:         lokiv1.SizeOneXExtraSmall,
+				StorageClass: "gp3-csi",
 				Router: LokiComponentSpec{
 					Replicas: 3,
 				},
@@ -457,9 +1061,7 @@ func DefaultBaseTemplate() TemplateMaps {
 
 // Stage images.
 var StageImages = ParamMap[string]{
-	"STORE02W":                   thanosImage,
-	"STORE2W90D":                 thanosImage,
-	"STORE90D+":                  thanosImage,
+	StoreTelemeter:               thanosImage,
 	"STORE_ROS":                  thanosImage,
 	"STORE_DEFAULT":              thanosImage,
 	"RECEIVE_ROUTER":             thanosImage,
@@ -484,9 +1086,7 @@ var StageImages = ParamMap[string]{
 
 // Stage images.
 var StageVersions = ParamMap[string]{
-	"STORE02W":                   thanosVersionStage,
-	"STORE2W90D":                 thanosVersionStage,
-	"STORE90D+":                  thanosVersionStage,
+	StoreTelemeter:               thanosVersionStage,
 	"STORE_ROS":                  thanosVersionStage,
 	"STORE_DEFAULT":              thanosVersionStage,
 	"RECEIVE_ROUTER":             thanosVersionStage,
@@ -506,9 +1106,7 @@ var StageVersions = ParamMap[string]{
 
 // Stage log levels.
 var StageLogLevels = ParamMap[string]{
-	"STORE02W":                   logLevels[1],
-	"STORE2W90D":                 logLevels[1],
-	"STORE90D+":                  logLevels[1],
+	StoreTelemeter:               logLevels[1],
 	"STORE_ROS":                  logLevels[1],
 	"STORE_DEFAULT":              logLevels[1],
 	"RECEIVE_ROUTER":             logLevels[1],
@@ -527,9 +1125,6 @@ var StageLogLevels = ParamMap[string]{
 
 // Stage PV storage sizes.
 var StageStorageSize = ParamMap[v1alpha1.StorageSize]{
-	"STORE02W":          "512Mi",
-	"STORE2W90D":        "512Mi",
-	"STORE90D+":         "512Mi",
 	"STORE_ROS":         "512Mi",
 	"STORE_DEFAULT":     "3Gi",
 	"RECEIVE_TELEMETER": "3Gi",
@@ -542,9 +1137,6 @@ var StageStorageSize = ParamMap[v1alpha1.StorageSize]{
 
 // Stage replicas.
 var StageReplicas = ParamMap[int32]{
-	"STORE02W":                   3,
-	"STORE2W90D":                 3,
-	"STORE90D+":                  3,
 	"STORE_ROS":                  3,
 	"STORE_DEFAULT":              3,
 	"RECEIVE_ROUTER":             3,
@@ -560,36 +1152,6 @@ var StageReplicas = ParamMap[int32]{
 
 // Stage resource requirements.
 var StageResourceRequirements = ParamMap[corev1.ResourceRequirements]{
-	"STORE02W": corev1.ResourceRequirements{
-		Requests: corev1.ResourceList{
-			corev1.ResourceCPU:    resource.MustParse("50m"),
-			corev1.ResourceMemory: resource.MustParse("512Mi"),
-		},
-		Limits: corev1.ResourceList{
-			corev1.ResourceCPU:    resource.MustParse("250m"),
-			corev1.ResourceMemory: resource.MustParse("2Gi"),
-		},
-	},
-	"STORE2W90D": corev1.ResourceRequirements{
-		Requests: corev1.ResourceList{
-			corev1.ResourceCPU:    resource.MustParse("50m"),
-			corev1.ResourceMemory: resource.MustParse("512Mi"),
-		},
-		Limits: corev1.ResourceList{
-			corev1.ResourceCPU:    resource.MustParse("250m"),
-			corev1.ResourceMemory: resource.MustParse("2Gi"),
-		},
-	},
-	"STORE90D+": corev1.ResourceRequirements{
-		Requests: corev1.ResourceList{
-			corev1.ResourceCPU:    resource.MustParse("50m"),
-			corev1.ResourceMemory: resource.MustParse("512Mi"),
-		},
-		Limits: corev1.ResourceList{
-			corev1.ResourceCPU:    resource.MustParse("250m"),
-			corev1.ResourceMemory: resource.MustParse("2Gi"),
-		},
-	},
 	"STORE_ROS": corev1.ResourceRequirements{
 		Requests: corev1.ResourceList{
 			corev1.ResourceCPU:    resource.MustParse("50m"),
@@ -773,35 +1335,42 @@ var StageResourceRequirements = ParamMap[corev1.ResourceRequirements]{
 }
 
 // Stage object storage bucket.
-var StageObjectStorageBucket = ParamMap[v1alpha1.ObjectStorageConfig]{
-	"DEFAULT": v1alpha1.ObjectStorageConfig{
-		Key: "thanos.yaml",
-		LocalObjectReference: corev1.LocalObjectReference{
-			Name: "observatorium-mst-thanos-objectstorage",
+var StageObjectStorageBucket = ParamMap[ObjectStorageBucket]{
+	"DEFAULT": {
+		ObjectStorageConfig: v1alpha1.ObjectStorageConfig{
+			Key: "thanos.yaml",
+			LocalObjectReference: corev1.LocalObjectReference{
+				Name: "observatorium-mst-thanos-objectstorage",
+			},
+			Optional: ptr.To(false),
 		},
-		Optional: ptr.To(false),
+		Storage: ObjectStorage{Provider: ObjectStorageS3},
 	},
-	"TELEMETER": v1alpha1.ObjectStorageConfig{
-		Key: "thanos.yaml",
-		LocalObjectReference: corev1.LocalObjectReference{
-			Name: "thanos-objectstorage",
+	"TELEMETER": {
+		ObjectStorageConfig: v1alpha1.ObjectStorageConfig{
+			Key: "thanos.yaml",
+			LocalObjectReference: corev1.LocalObjectReference{
+				Name: "thanos-objectstorage",
+			},
+			Optional: ptr.To(false),
 		},
-		Optional: ptr.To(false),
+		Storage: ObjectStorage{Provider: ObjectStorageS3},
 	},
-	"ROS": v1alpha1.ObjectStorageConfig{
-		Key: "thanos.yaml",
-		LocalObjectReference: corev1.LocalObjectReference{
-			Name: "ros-thanos-objstore",
+	"ROS": {
+		ObjectStorageConfig: v1alpha1.ObjectStorageConfig{
+			Key: "thanos.yaml",
+			LocalObjectReference: corev1.LocalObjectReference{
+				Name: "ros-thanos-objstore",
+			},
+			Optional: ptr.To(false),
 		},
-		Optional: ptr.To(false),
+		Storage: ObjectStorage{Provider: ObjectStorageS3},
 	},
 }
 
 // ProductionImages is a map of production images.
 var ProductionImages = ParamMap[string]{
-	"STORE02W":                 thanosImage,
-	"STORE2W90D":               thanosImage,
-	"STORE90D+":                thanosImage,
+	StoreTelemeter:             thanosImage,
 	"STORE_ROS":                thanosImage,
 	"STORE_DEFAULT":            thanosImage,
 	"QUERY":                    thanosImage,
@@ -822,9 +1391,7 @@ var ProductionImages = ParamMap[string]{
 
 // ProductionVersions is a map of production versions.
 var ProductionVersions = ParamMap[string]{
-	"STORE02W":                 thanosVersionProd,
-	"STORE2W90D":               thanosVersionProd,
-	"STORE90D+":                thanosVersionProd,
+	StoreTelemeter:             thanosVersionProd,
 	"STORE_ROS":                thanosVersionProd,
 	"STORE_DEFAULT":            thanosVersionProd,
 	"RECEIVE_ROUTER":           thanosVersionProd,
@@ -840,9 +1407,7 @@ var ProductionVersions = ParamMap[string]{
 
 // ProductionLogLevels is a map of production log levels.
 var ProductionLogLevels = ParamMap[string]{
-	"STORE02W":                 logLevels[0],
-	"STORE2W90D":               logLevels[0],
-	"STORE90D+":                logLevels[0],
+	StoreTelemeter:             logLevels[0],
 	"STORE_ROS":                logLevels[0],
 	"STORE_DEFAULT":            logLevels[0],
 	"RECEIVE_ROUTER":           logLevels[0],
@@ -857,9 +1422,6 @@ var ProductionLogLevels = ParamMap[string]{
 
 // ProductionStorageSize is a map of production PV storage sizes.
 var ProductionStorageSize = ParamMap[v1alpha1.StorageSize]{
-	"STORE02W":        "300Gi",
-	"STORE2W90D":      "300Gi",
-	"STORE90D+":       "300Gi",
 	"STORE_ROS":       "300Gi",
 	"STORE_DEFAULT":   "300Gi",
 	"RECEIVE_DEFAULT": "3Gi",
@@ -868,9 +1430,6 @@ var ProductionStorageSize = ParamMap[v1alpha1.StorageSize]{
 
 // ProductionReplicas is a map of production replicas.
 var ProductionReplicas = ParamMap[int32]{
-	"STORE02W":                 2,
-	"STORE2W90D":               2,
-	"STORE90D+":                1,
 	"STORE_ROS":                0, //TODO @moadz RHOBS-904: Temporary stage-only configuration for ROS disabled in Production.
 	"STORE_DEFAULT":            2,
 	"RECEIVE_ROUTER":           2,
@@ -885,24 +1444,6 @@ var ProductionReplicas = ParamMap[int32]{
 
 // ProductionResourceRequirements is a map of production resource requirements.
 var ProductionResourceRequirements = ParamMap[corev1.ResourceRequirements]{
-	"STORE02W": corev1.ResourceRequirements{
-		Requests: corev1.ResourceList{
-			corev1.ResourceCPU:    resource.MustParse("50m"),
-			corev1.ResourceMemory: resource.MustParse("512Mi"),
-		},
-	},
-	"STORE2W90D": corev1.ResourceRequirements{
-		Requests: corev1.ResourceList{
-			corev1.ResourceCPU:    resource.MustParse("50m"),
-			corev1.ResourceMemory: resource.MustParse("512Mi"),
-		},
-	},
-	"STORE90D+": corev1.ResourceRequirements{
-		Requests: corev1.ResourceList{
-			corev1.ResourceCPU:    resource.MustParse("50m"),
-			corev1.ResourceMemory: resource.MustParse("512Mi"),
-		},
-	},
 	"STORE_ROS": corev1.ResourceRequirements{
 		Requests: corev1.ResourceList{
 			corev1.ResourceCPU:    resource.MustParse("50m"),
@@ -1030,27 +1571,106 @@ var ProductionResourceRequirements = ParamMap[corev1.ResourceRequirements]{
 }
 
 // ProductionObjectStorageBucket is a map of production object storage buckets.
-var ProductionObjectStorageBucket = ParamMap[v1alpha1.ObjectStorageConfig]{
-	"DEFAULT": v1alpha1.ObjectStorageConfig{
-		Key: "thanos.yaml",
-		LocalObjectReference: corev1.LocalObjectReference{
-			Name: "observatorium-mst-thanos-objectstorage",
+var ProductionObjectStorageBucket = ParamMap[ObjectStorageBucket]{
+	"DEFAULT": {
+		ObjectStorageConfig: v1alpha1.ObjectStorageConfig{
+			Key: "thanos.yaml",
+			LocalObjectReference: corev1.LocalObjectReference{
+				Name: "observatorium-mst-thanos-objectstorage",
+			},
+			Optional: ptr.To(false),
 		},
-		Optional: ptr.To(false),
+		Storage: ObjectStorage{Provider: ObjectStorageS3},
 	},
-	"TELEMETER": v1alpha1.ObjectStorageConfig{
-		Key: "thanos.yaml",
-		LocalObjectReference: corev1.LocalObjectReference{
-			Name: "thanos-objectstorage",
+	"TELEMETER": {
+		ObjectStorageConfig: v1alpha1.ObjectStorageConfig{
+			Key: "thanos.yaml",
+			LocalObjectReference: corev1.LocalObjectReference{
+				Name: "thanos-objectstorage",
+			},
+			Optional: ptr.To(false),
 		},
-		Optional: ptr.To(false),
+		Storage: ObjectStorage{Provider: ObjectStorageS3},
 	},
-	"ROS": v1alpha1.ObjectStorageConfig{
-		Key: "thanos.yaml",
-		LocalObjectReference: corev1.LocalObjectReference{
-			Name: "ros-thanos-objstore",
+	"ROS": {
+		ObjectStorageConfig: v1alpha1.ObjectStorageConfig{
+			Key: "thanos.yaml",
+			LocalObjectReference: corev1.LocalObjectReference{
+				Name: "ros-thanos-objstore",
+			},
+			Optional: ptr.To(false),
 		},
-		Optional: ptr.To(false),
+		Storage: ObjectStorage{Provider: ObjectStorageS3},
+	},
+}
+
+// StageStoreTiers partitions the telemeter store of the stage environment by block age.
+var StageStoreTiers = ParamMap[[]StoreTier]{
+	StoreTelemeter: {
+		{Name: "0to2w", MaxAge: "2w", Replicas: 3, Resources: stageTelemeterStoreResources, StorageSize: "512Mi"},
+		{Name: "2wto90d", MinAge: "2w", MaxAge: "90d", Replicas: 3, Resources: stageTelemeterStoreResources, StorageSize: "512Mi", DisablePodDisruptionBudget: true},
+		{Name: "90dplus", MinAge: "90d", Replicas: 3, Resources: stageTelemeterStoreResources, StorageSize: "512Mi"},
+	},
+}
+
+var stageTelemeterStoreResources = corev1.ResourceRequirements{
+	Requests: corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("50m"),
+		corev1.ResourceMemory: resource.MustParse("512Mi"),
+	},
+	Limits: corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("250m"),
+		corev1.ResourceMemory: resource.MustParse("2Gi"),
+	},
+}
+
+// ProductionStoreTiers partitions the telemeter store of the production environment by block age.
+var ProductionStoreTiers = ParamMap[[]StoreTier]{
+	StoreTelemeter: {
+		{
+			Name: "0to2w", MaxAge: "336h", Replicas: 2, Resources: productionTelemeterStoreResources, StorageSize: "300Gi",
+			IgnoreDeletionMarksDelay: "12h", EagerIndexHeaders: true, ExternalCaches: true,
+		},
+		{Name: "2wto90d", MinAge: "336h", MaxAge: "2160h", Replicas: 2, Resources: productionTelemeterStoreResources, StorageSize: "300Gi", DisablePodDisruptionBudget: true},
+		{Name: "90dplus", MinAge: "2160h", MaxAge: "8760h", Replicas: 1, Resources: productionTelemeterStoreResources, StorageSize: "300Gi"},
+	},
+}
+
+// ProductionCompaction is the compaction policy of the telemeter bucket of the production environment.
+// The receive shards compact the blocks of the receivers, the telemeter one the requests without a tenant,
+// and the rules shard the blocks of the rulers.
+var ProductionCompaction = CompactionPolicy{
+	RawRetention:         "3650d",
+	FiveMinutesRetention: "3650d",
+	OneHourRetention:     "3650d",
+	Shards: []CompactionShard{
+		{Name: "rhobs", Tenants: []string{"rhobs"}, ExternalLabels: receiveBlocks},
+		{Name: "telemeter", DefaultTenant: true, ExternalLabels: receiveBlocks},
+		{Name: "rules", ExternalLabels: []v1alpha1.ExternalLabelShardingConfig{{Label: "receive", Value: "!true"}}},
+	},
+}
+
+// receiveBlocks selects the blocks uploaded by the receivers
+var receiveBlocks = []v1alpha1.ExternalLabelShardingConfig{{Label: "receive", Value: "true"}}
+
+var productionTelemeterStoreResources = corev1.ResourceRequirements{
+	Requests: corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("50m"),
+		corev1.ResourceMemory: resource.MustParse("512Mi"),
+	},
+}
+
+// legacyQueryFrontendPolicy is the query frontend policy of the stage and production environments,
+// whose query range cache is not deployed yet.
+var legacyQueryFrontendPolicy = ParamMap[QueryFrontendPolicy]{
+	QueryFrontend: {
+		QueryRangeSplitInterval: "48h",
+		QueryRangeMaxRetries:    3,
+		ResultsCache:            QueryFrontendCacheDisabled,
+		LogQueriesLongerThan:    "10s",
+		LabelsSplitInterval:     "48h",
+		LabelsMaxRetries:        3,
+		LabelsDefaultTimeRange:  "336h",
 	},
 }
 
@@ -1062,6 +1682,8 @@ var StageMaps = TemplateMaps{
 	Replicas:             StageReplicas,
 	ResourceRequirements: StageResourceRequirements,
 	ObjectStorageBucket:  StageObjectStorageBucket,
+	StoreTiers:           StageStoreTiers,
+	QueryFrontendPolicy:  legacyQueryFrontendPolicy,
 }
 
 var ProductionMaps = TemplateMaps{
@@ -1072,4 +1694,6 @@ var ProductionMaps = TemplateMaps{
 	Replicas:             ProductionReplicas,
 	ResourceRequirements: ProductionResourceRequirements,
 	ObjectStorageBucket:  ProductionObjectStorageBucket,
+	StoreTiers:           ProductionStoreTiers,
+	QueryFrontendPolicy:  legacyQueryFrontendPolicy,
 }
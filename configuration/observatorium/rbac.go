@@ -3,6 +3,7 @@ package cfgobservatorium
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/bwplotka/mimic"
 	"github.com/bwplotka/mimic/encoding"
@@ -32,6 +33,7 @@ const (
 	MetricsResource Resource = "metrics"
 	LogsResource    Resource = "logs"
 	ProbesResource  Resource = "probes"
+	TracesResource  Resource = "traces"
 )
 
 type env string
@@ -61,6 +63,7 @@ func GenerateRBAC() *ObservatoriumRBAC {
 	// CNV-QE
 	attachBinding(&obsRBAC, BindingOpts{
 		name:    "observatorium-cnv-qe",
+		owner:   "cnv-qe",
 		tenant:  cnvqeTenant,
 		signals: []Resource{MetricsResource},
 		perms:   []rbac.Permission{rbac.Write, rbac.Read},
@@ -71,6 +74,7 @@ func GenerateRBAC() *ObservatoriumRBAC {
 	// Starbust write-only
 	attachBinding(&obsRBAC, BindingOpts{
 		name:    "observatorium-starburst-isv-write",
+		owner:   "starburst",
 		tenant:  rhodsTenant,
 		signals: []Resource{MetricsResource},
 		perms:   []rbac.Permission{rbac.Write},
@@ -79,6 +83,7 @@ func GenerateRBAC() *ObservatoriumRBAC {
 	// Starbust read-only
 	attachBinding(&obsRBAC, BindingOpts{
 		name:    "observatorium-starburst-isv-read",
+		owner:   "starburst",
 		tenant:  rhodsTenant,
 		signals: []Resource{MetricsResource},
 		perms:   []rbac.Permission{rbac.Read},
@@ -88,6 +93,7 @@ func GenerateRBAC() *ObservatoriumRBAC {
 	// RHACS
 	attachBinding(&obsRBAC, BindingOpts{
 		name:    "observatorium-rhacs-metrics",
+		owner:   "rhacs",
 		tenant:  rhacsTenant,
 		signals: []Resource{MetricsResource},
 		perms:   []rbac.Permission{rbac.Write, rbac.Read},
@@ -95,6 +101,7 @@ func GenerateRBAC() *ObservatoriumRBAC {
 	})
 	attachBinding(&obsRBAC, BindingOpts{
 		name:    "observatorium-rhacs-grafana",
+		owner:   "rhacs",
 		tenant:  rhacsTenant,
 		signals: []Resource{MetricsResource},
 		perms:   []rbac.Permission{rbac.Read},
@@ -104,6 +111,7 @@ func GenerateRBAC() *ObservatoriumRBAC {
 	// RHOBS
 	attachBinding(&obsRBAC, BindingOpts{
 		name:    "observatorium-rhobs",
+		owner:   "rhobs",
 		tenant:  rhobsTenant,
 		signals: []Resource{MetricsResource},
 		perms:   []rbac.Permission{rbac.Write, rbac.Read},
@@ -111,6 +119,7 @@ func GenerateRBAC() *ObservatoriumRBAC {
 	})
 	attachBinding(&obsRBAC, BindingOpts{
 		name:    "observatorium-rhobs-mst",
+		owner:   "rhobs",
 		tenant:  rhobsTenant,
 		signals: []Resource{MetricsResource},
 		perms:   []rbac.Permission{rbac.Write, rbac.Read},
@@ -129,6 +138,7 @@ func GenerateRBAC() *ObservatoriumRBAC {
 	// Telemeter
 	attachBinding(&obsRBAC, BindingOpts{
 		name:    "telemeter-service",
+		owner:   "telemeter",
 		tenant:  telemeterTenant,
 		signals: []Resource{MetricsResource},
 		perms:   []rbac.Permission{rbac.Write, rbac.Read},
@@ -138,6 +148,7 @@ func GenerateRBAC() *ObservatoriumRBAC {
 	// CCX Processing
 	attachBinding(&obsRBAC, BindingOpts{
 		name:    "observatorium-ccx-processing",
+		owner:   "ccx-processing",
 		tenant:  telemeterTenant,
 		signals: []Resource{MetricsResource},
 		perms:   []rbac.Permission{rbac.Read},
@@ -147,6 +158,7 @@ func GenerateRBAC() *ObservatoriumRBAC {
 	// SD TCS (App-interface progressive delivery feature)
 	attachBinding(&obsRBAC, BindingOpts{
 		name:    "observatorium-sdtcs",
+		owner:   "sd-tcs",
 		tenant:  telemeterTenant,
 		signals: []Resource{MetricsResource},
 		perms:   []rbac.Permission{rbac.Read},
@@ -156,6 +168,7 @@ func GenerateRBAC() *ObservatoriumRBAC {
 	// Subwatch
 	attachBinding(&obsRBAC, BindingOpts{
 		name:    "observatorium-subwatch",
+		owner:   "subwatch",
 		tenant:  telemeterTenant,
 		signals: []Resource{MetricsResource},
 		perms:   []rbac.Permission{rbac.Read},
@@ -165,6 +178,7 @@ func GenerateRBAC() *ObservatoriumRBAC {
 	// PSIOCP
 	attachBinding(&obsRBAC, BindingOpts{
 		name:    "observatorium-psiocp",
+		owner:   "psiocp",
 		tenant:  psiocpTenant,
 		signals: []Resource{MetricsResource},
 		perms:   []rbac.Permission{rbac.Write, rbac.Read},
@@ -174,6 +188,7 @@ func GenerateRBAC() *ObservatoriumRBAC {
 	// ODFMS
 	attachBinding(&obsRBAC, BindingOpts{
 		name:    "observatorium-odfms-write",
+		owner:   "odfms",
 		tenant:  odfmsTenant,
 		signals: []Resource{MetricsResource},
 		perms:   []rbac.Permission{rbac.Write}, // Write only.
@@ -183,6 +198,8 @@ func GenerateRBAC() *ObservatoriumRBAC {
 	// Ref: https://issues.redhat.com/browse/MON-2536?focusedCommentId=20492830&page=com.atlassian.jira.plugin.system.issuetabpanels:comment-tabpanel#comment-20492830
 	attachBinding(&obsRBAC, BindingOpts{
 		name:    "observatorium-odfms-read",
+		owner:   "odfms",
+		contact: "MON-2536",
 		tenant:  odfmsTenant,
 		signals: []Resource{MetricsResource},
 		perms:   []rbac.Permission{rbac.Read}, // Read only.
@@ -192,6 +209,7 @@ func GenerateRBAC() *ObservatoriumRBAC {
 	// ODFMS has one set of staging credentials that has read & write permissions
 	attachBinding(&obsRBAC, BindingOpts{
 		name:    "observatorium-odfms",
+		owner:   "odfms",
 		tenant:  odfmsTenant,
 		signals: []Resource{MetricsResource},
 		perms:   []rbac.Permission{rbac.Read, rbac.Write},
@@ -201,6 +219,7 @@ func GenerateRBAC() *ObservatoriumRBAC {
 	// reference-addon
 	attachBinding(&obsRBAC, BindingOpts{
 		name:    "observatorium-reference-addon",
+		owner:   "reference-addon",
 		tenant:  refAddonTenant,
 		signals: []Resource{MetricsResource},
 		perms:   []rbac.Permission{rbac.Write, rbac.Read},
@@ -212,6 +231,8 @@ func GenerateRBAC() *ObservatoriumRBAC {
 	// https://issues.redhat.com/browse/RHOBS-1116
 	attachBinding(&obsRBAC, BindingOpts{
 		name:                "7f7f912e-0429-4639-8e70-609ecf65b280",
+		owner:               "rhobs",
+		contact:             "RHOBS-1116",
 		tenant:              telemeterTenant,
 		signals:             []Resource{MetricsResource},
 		perms:               []rbac.Permission{rbac.Read}, // Read only.
@@ -224,6 +245,8 @@ func GenerateRBAC() *ObservatoriumRBAC {
 	// https://issues.redhat.com/browse/RHOBS-1116
 	attachBinding(&obsRBAC, BindingOpts{
 		name:                "8f7aa5e1-aa08-493d-82eb-cf24834fc08f",
+		owner:               "analytics",
+		contact:             "RHOBS-1116",
 		tenant:              telemeterTenant,
 		signals:             []Resource{MetricsResource},
 		perms:               []rbac.Permission{rbac.Read}, // Read only.
@@ -236,6 +259,8 @@ func GenerateRBAC() *ObservatoriumRBAC {
 	// https://issues.redhat.com/browse/RHOBS-1116
 	attachBinding(&obsRBAC, BindingOpts{
 		name:                "4bfe1a9f-e875-4d37-9c6a-d2faff2a69dc",
+		owner:               "data-foundation-pms",
+		contact:             "RHOBS-1116",
 		tenant:              telemeterTenant,
 		signals:             []Resource{MetricsResource},
 		perms:               []rbac.Permission{rbac.Read}, // Read only.
@@ -247,6 +272,7 @@ func GenerateRBAC() *ObservatoriumRBAC {
 	// Special request of extra read account.
 	attachBinding(&obsRBAC, BindingOpts{
 		name:                "f6b3e12c-bb50-4bfc-89fe-330a28820fa9",
+		owner:               "observability-pms",
 		tenant:              telemeterTenant,
 		signals:             []Resource{MetricsResource},
 		perms:               []rbac.Permission{rbac.Read}, // Read only.
@@ -258,6 +284,7 @@ func GenerateRBAC() *ObservatoriumRBAC {
 	// Special request of extra read account.
 	attachBinding(&obsRBAC, BindingOpts{
 		name:                "1a45eb31-bcc6-4bb7-8a38-88f00aa718ee",
+		owner:               "hybrid-platforms-pms",
 		tenant:              telemeterTenant,
 		signals:             []Resource{MetricsResource},
 		perms:               []rbac.Permission{rbac.Read}, // Read only.
@@ -270,6 +297,8 @@ func GenerateRBAC() *ObservatoriumRBAC {
 	// https://issues.redhat.com/browse/RHOBS-1116
 	attachBinding(&obsRBAC, BindingOpts{
 		name:                "e7c2f772-e418-4ef3-9568-ea09b1acb929",
+		owner:               "cnv",
+		contact:             "RHOBS-1116",
 		tenant:              telemeterTenant,
 		signals:             []Resource{MetricsResource},
 		perms:               []rbac.Permission{rbac.Read}, // Read only.
@@ -282,6 +311,8 @@ func GenerateRBAC() *ObservatoriumRBAC {
 	// https://issues.redhat.com/browse/RHOBS-1116
 	attachBinding(&obsRBAC, BindingOpts{
 		name:                "e07f5b10-e62b-47a2-9698-e245d1198a3b",
+		owner:               "dev-spaces",
+		contact:             "RHOBS-1116",
 		tenant:              telemeterTenant,
 		signals:             []Resource{MetricsResource},
 		perms:               []rbac.Permission{rbac.Read}, // Read only.
@@ -293,6 +324,7 @@ func GenerateRBAC() *ObservatoriumRBAC {
 	// Special request of extra read account.
 	attachBinding(&obsRBAC, BindingOpts{
 		name:                "8a5cc14c-570c-4106-9a3b-cb2fcf4e3de4",
+		owner:               "plmshift",
 		tenant:              telemeterTenant,
 		signals:             []Resource{MetricsResource},
 		perms:               []rbac.Permission{rbac.Read}, // Read only.
@@ -304,6 +336,7 @@ func GenerateRBAC() *ObservatoriumRBAC {
 	// Special request of extra read account.
 	attachBinding(&obsRBAC, BindingOpts{
 		name:                "plmshift",
+		owner:               "plmshift",
 		tenant:              telemeterTenant,
 		signals:             []Resource{MetricsResource},
 		perms:               []rbac.Permission{rbac.Read}, // Read only.
@@ -315,6 +348,7 @@ func GenerateRBAC() *ObservatoriumRBAC {
 	// Special request of extra read account.
 	attachBinding(&obsRBAC, BindingOpts{
 		name:                "9baf25c1-f61e-4b0d-b3a5-41802dbc061e",
+		owner:               "partner-accelerator-tools",
 		tenant:              telemeterTenant,
 		signals:             []Resource{MetricsResource},
 		perms:               []rbac.Permission{rbac.Read}, // Read only.
@@ -326,6 +360,7 @@ func GenerateRBAC() *ObservatoriumRBAC {
 	// Special request of extra read account.
 	attachBinding(&obsRBAC, BindingOpts{
 		name:                "cefb23fb-d0a2-4c8f-9180-d95c259e79a3",
+		owner:               "ai-bu-pms",
 		tenant:              telemeterTenant,
 		signals:             []Resource{MetricsResource},
 		perms:               []rbac.Permission{rbac.Read}, // Read only.
@@ -337,6 +372,7 @@ func GenerateRBAC() *ObservatoriumRBAC {
 	// Special request of extra read account.
 	attachBinding(&obsRBAC, BindingOpts{
 		name:                "875c08bc-d313-417f-a044-295212338e81",
+		owner:               "fedramp",
 		tenant:              telemeterTenant,
 		signals:             []Resource{MetricsResource},
 		perms:               []rbac.Permission{rbac.Write}, // Write only
@@ -348,6 +384,7 @@ func GenerateRBAC() *ObservatoriumRBAC {
 	// Special request of extra read account.
 	attachBinding(&obsRBAC, BindingOpts{
 		name:                "4cbd24b0-3aed-4b03-839a-f4515b199a5d",
+		owner:               "fedramp",
 		tenant:              telemeterTenant,
 		signals:             []Resource{MetricsResource},
 		perms:               []rbac.Permission{rbac.Write}, // Write only
@@ -359,6 +396,7 @@ func GenerateRBAC() *ObservatoriumRBAC {
 	// Special request of extra read account.
 	attachBinding(&obsRBAC, BindingOpts{
 		name:                "0174b0a8-649a-4a95-bdff-9592f41b0de4",
+		owner:               "rosa-core",
 		tenant:              telemeterTenant,
 		signals:             []Resource{MetricsResource},
 		perms:               []rbac.Permission{rbac.Read},
@@ -370,6 +408,7 @@ func GenerateRBAC() *ObservatoriumRBAC {
 	// Reader and Writer serviceaccount
 	attachBinding(&obsRBAC, BindingOpts{
 		name:    "observatorium-rhtap",
+		owner:   "rhtap",
 		tenant:  rhtapTenant,
 		signals: []Resource{MetricsResource},
 		perms:   []rbac.Permission{rbac.Read, rbac.Write},
@@ -380,6 +419,7 @@ func GenerateRBAC() *ObservatoriumRBAC {
 	// Reader and Writer serviceaccount
 	attachBinding(&obsRBAC, BindingOpts{
 		name:                "aed46b58-abb5-4b1e-831f-a5678de691e0",
+		owner:               "rhtap-srep",
 		tenant:              rhtapTenant,
 		signals:             []Resource{MetricsResource},
 		perms:               []rbac.Permission{rbac.Read, rbac.Write},
@@ -392,6 +432,7 @@ func GenerateRBAC() *ObservatoriumRBAC {
 	// Reader serviceaccount
 	attachBinding(&obsRBAC, BindingOpts{
 		name:    "observatorium-rhel-read",
+		owner:   "rhel",
 		tenant:  rhelTenant,
 		signals: []Resource{MetricsResource},
 		perms:   []rbac.Permission{rbac.Read},
@@ -401,6 +442,7 @@ func GenerateRBAC() *ObservatoriumRBAC {
 	// Writer serviceaccount
 	attachBinding(&obsRBAC, BindingOpts{
 		name:    "observatorium-rhel-write",
+		owner:   "rhel",
 		tenant:  rhelTenant,
 		signals: []Resource{MetricsResource},
 		perms:   []rbac.Permission{rbac.Write},
@@ -440,11 +482,32 @@ type RoleMapKey struct {
 type ObservatoriumRBAC struct {
 	// mappedRoleNames is used for deduplication logic.
 	mappedRoleNames map[RoleMapKey]string
+	// bindingMetadata is bookkeeping for humans and must not end up in the configuration of observatorium-api.
+	bindingMetadata []BindingMetadata
 
 	Roles        []rbac.Role        `json:"roles"`
 	RoleBindings []rbac.RoleBinding `json:"roleBindings"`
 }
 
+// BindingMetadata records who owns a role binding and until when it is granted.
+type BindingMetadata struct {
+	Name    string
+	Owner   string
+	Contact string
+	// Expires is the zero time for bindings that do not expire.
+	Expires time.Time
+}
+
+// Expired returns true if the binding is past its expiry date at the given time.
+func (m BindingMetadata) Expired(now time.Time) bool {
+	return !m.Expires.IsZero() && now.After(m.Expires)
+}
+
+// BindingMetadata returns the ownership metadata of every role binding, in binding order.
+func (o ObservatoriumRBAC) BindingMetadata() []BindingMetadata {
+	return o.bindingMetadata
+}
+
 type BindingOpts struct {
 	// NOTE(bwplotka): Name is strongly correlated to subject name that corresponds to the service account username (it has to match it)/
 	// Any change, require changes on tenant side, so be careful.
@@ -459,6 +522,11 @@ type BindingOpts struct {
 	// withRawSubjectName skips the "service-account-" prefix, using the name exactly as provided.
 	// Use this for OIDC client credentials flow where the token's client_id claim is the raw UUID.
 	withRawSubjectName bool
+
+	// owner, contact and expires are not rendered into the RBAC configuration, see BindingMetadata.
+	owner   string
+	contact string
+	expires time.Time
 }
 
 func (bo *BindingOpts) WithServiceAccountName(n string) *BindingOpts {
@@ -489,6 +557,24 @@ func (bo *BindingOpts) WithRawSubjectName() *BindingOpts {
 	return bo
 }
 
+// WithOwner records the team owning the binding.
+func (bo *BindingOpts) WithOwner(owner string) *BindingOpts {
+	bo.owner = owner
+	return bo
+}
+
+// WithContact records how to reach the owner of the binding, e.g. a ticket reference or a channel.
+func (bo *BindingOpts) WithContact(contact string) *BindingOpts {
+	bo.contact = contact
+	return bo
+}
+
+// WithExpiry marks the binding as a temporary grant that must be removed after the given time.
+func (bo *BindingOpts) WithExpiry(expires time.Time) *BindingOpts {
+	bo.expires = expires
+	return bo
+}
+
 func getOrCreateRoleName(o *ObservatoriumRBAC, tenant TenantID, s Resource, p rbac.Permission) string {
 	k := RoleMapKey{tenant: tenant, signal: s, perm: p}
 
@@ -530,6 +616,9 @@ func attachBinding(o *ObservatoriumRBAC, opts BindingOpts) {
 
 		}
 	}
+	if opts.owner == "" {
+		mimic.Panicf("binding %s has no owner, record the team owning it", opts.name)
+	}
 
 	// Is there role that satisfy this already? If not, create.
 	var roles []string
@@ -564,4 +653,10 @@ func attachBinding(o *ObservatoriumRBAC, opts BindingOpts) {
 		Roles:    roles,
 		Subjects: subs,
 	})
+	o.bindingMetadata = append(o.bindingMetadata, BindingMetadata{
+		Name:    opts.name,
+		Owner:   opts.owner,
+		Contact: opts.contact,
+		Expires: opts.expires,
+	})
 }
@@ -0,0 +1,613 @@
+// Package logql validates LogQL expressions without depending on Loki.
+//
+// A metric query is checked by rewriting every log range, a stream selector followed by its pipeline and range,
+// into a PromQL range selector, and by parsing the result with the PromQL parser. Range aggregations only LogQL
+// knows are mapped to PromQL functions of the same signature. Pipelines are checked stage by stage, with the
+// regular expressions of line filters, parsers and label filters compiled as Loki would. Log ranges may only be
+// aggregated by the range aggregations of LogQL, which take the unwrapped ranges or the others.
+package logql
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+// rangeFunctions maps the range aggregations only LogQL knows to a PromQL function of the same signature.
+var rangeFunctions = map[string]string{
+	"bytes_over_time": "count_over_time",
+	"bytes_rate":      "rate",
+	"rate_counter":    "rate",
+	"first_over_time": "last_over_time",
+}
+
+// unwrapping tells whether a range aggregation takes unwrapped log ranges.
+type unwrapping int
+
+const (
+	unwrapForbidden unwrapping = iota
+	unwrapAllowed
+	unwrapRequired
+)
+
+// rangeAggregations are the only functions aggregating log ranges. They accept a grouping after their arguments in
+// LogQL, which PromQL does not.
+var rangeAggregations = map[string]unwrapping{
+	"count_over_time":    unwrapForbidden,
+	"bytes_over_time":    unwrapForbidden,
+	"bytes_rate":         unwrapForbidden,
+	"rate":               unwrapAllowed,
+	"absent_over_time":   unwrapAllowed,
+	"rate_counter":       unwrapRequired,
+	"avg_over_time":      unwrapRequired,
+	"sum_over_time":      unwrapRequired,
+	"min_over_time":      unwrapRequired,
+	"max_over_time":      unwrapRequired,
+	"stdvar_over_time":   unwrapRequired,
+	"stddev_over_time":   unwrapRequired,
+	"quantile_over_time": unwrapRequired,
+	"first_over_time":    unwrapRequired,
+	"last_over_time":     unwrapRequired,
+}
+
+// parserStages are the pipeline stages that are not label filters.
+// Any other stage must be a label filter, so that a misspelled stage is rejected.
+var parserStages = map[string]bool{
+	"json":         true,
+	"logfmt":       true,
+	"regexp":       true,
+	"pattern":      true,
+	"unpack":       true,
+	"line_format":  true,
+	"label_format": true,
+	"drop":         true,
+	"keep":         true,
+	"decolorize":   true,
+	"unwrap":       true,
+}
+
+// ValidateMetricQuery checks that expr is a LogQL metric query, the only queries the Loki ruler evaluates.
+func ValidateMetricQuery(expr string) error {
+	s := &scanner{in: expr}
+	promQL, err := s.rewrite()
+	if err != nil {
+		return err
+	}
+	if s.logRanges == 0 {
+		return fmt.Errorf("expression does not select any log stream")
+	}
+
+	parsed, err := parser.ParseExpr(promQL)
+	if err != nil {
+		return err
+	}
+	if t := parsed.Type(); t != parser.ValueTypeVector && t != parser.ValueTypeScalar {
+		return fmt.Errorf("expression must return a vector or a scalar, got %s", t)
+	}
+
+	// Every selector left comes from a log range, anything else is a metric name LogQL does not know.
+	var selectorErr error
+	parser.Inspect(parsed, func(node parser.Node, path []parser.Node) error {
+		vs, ok := node.(*parser.VectorSelector)
+		if !ok {
+			return nil
+		}
+		if vs.Name != "" {
+			selectorErr = fmt.Errorf("unexpected identifier %q", vs.Name)
+		} else if len(path) == 0 {
+			selectorErr = fmt.Errorf("log stream selector must be aggregated over a range")
+		} else if _, ok := path[len(path)-1].(*parser.MatrixSelector); !ok {
+			selectorErr = fmt.Errorf("log stream selector must be aggregated over a range")
+		}
+		return nil
+	})
+	return selectorErr
+}
+
+// ValidateStreamSelector checks a LogQL stream selector, which needs a matcher that does not match the empty string.
+func ValidateStreamSelector(selector string) error {
+	matchers, err := parser.ParseMetricSelector(selector)
+	if err != nil {
+		return err
+	}
+	for _, m := range matchers {
+		if (m.Type == labels.MatchEqual || m.Type == labels.MatchRegexp) && !m.Matches("") {
+			return nil
+		}
+	}
+	return fmt.Errorf("stream selector %s needs at least one equality or regular expression matcher that does not match the empty string", selector)
+}
+
+type scanner struct {
+	in  string
+	pos int
+	out strings.Builder
+
+	// calls are the open parentheses, with the function each one calls if any.
+	calls     []string
+	logRanges int
+	// unwrapped tells whether the pipeline of the current log range unwraps a label.
+	unwrapped bool
+}
+
+// rewrite copies the expression with its log ranges turned into PromQL range selectors.
+func (s *scanner) rewrite() (string, error) {
+	var ident string
+	for s.pos < len(s.in) {
+		c := s.in[s.pos]
+		switch {
+		case c == '"' || c == '`':
+			lit, err := s.readString()
+			if err != nil {
+				return "", err
+			}
+			s.out.WriteString(lit)
+			ident = ""
+		case c == '{':
+			if err := s.rewriteLogRange(); err != nil {
+				return "", err
+			}
+			ident = ""
+		case c == '(':
+			s.calls = append(s.calls, ident)
+			s.out.WriteByte(c)
+			s.pos++
+			ident = ""
+		case c == ')':
+			if len(s.calls) == 0 {
+				return "", fmt.Errorf("unexpected ) at position %d", s.pos)
+			}
+			call := s.calls[len(s.calls)-1]
+			s.calls = s.calls[:len(s.calls)-1]
+			s.out.WriteByte(c)
+			s.pos++
+			if _, ok := rangeAggregations[call]; ok {
+				if err := s.skipGrouping(); err != nil {
+					return "", err
+				}
+			}
+			ident = ""
+		case isIdentStart(c):
+			ident = s.readIdent()
+			if name, ok := rangeFunctions[ident]; ok && s.peekNonSpace() == '(' {
+				s.out.WriteString(name)
+			} else {
+				s.out.WriteString(ident)
+			}
+		case c == '#':
+			// Comments run to the end of the line.
+			for s.pos < len(s.in) && s.in[s.pos] != '\n' {
+				s.pos++
+			}
+		default:
+			if !unicode.IsSpace(rune(c)) {
+				ident = ""
+			}
+			s.out.WriteByte(c)
+			s.pos++
+		}
+	}
+	return s.out.String(), nil
+}
+
+// rewriteLogRange validates a stream selector, its pipeline and its range, and writes them as a PromQL range selector.
+func (s *scanner) rewriteLogRange() error {
+	start := s.pos
+	if err := s.skipBalanced('{', '}'); err != nil {
+		return err
+	}
+	selector := s.in[start:s.pos]
+	if err := ValidateStreamSelector(selector); err != nil {
+		return err
+	}
+	s.unwrapped = false
+	if err := s.skipPipeline(); err != nil {
+		return fmt.Errorf("pipeline of %s: %w", selector, err)
+	}
+	if err := s.checkAggregation(selector); err != nil {
+		return err
+	}
+
+	if s.peekNonSpace() != '[' {
+		return fmt.Errorf("log stream selector %s must be aggregated over a range", selector)
+	}
+	s.skipSpace()
+	rangeStart := s.pos
+	end := strings.IndexByte(s.in[s.pos:], ']')
+	if end < 0 {
+		return fmt.Errorf("unterminated range after %s", selector)
+	}
+	s.pos += end + 1
+	rng := s.in[rangeStart:s.pos]
+	if _, err := model.ParseDuration(strings.TrimSpace(rng[1 : len(rng)-1])); err != nil {
+		return fmt.Errorf("invalid range %s: %w", rng, err)
+	}
+
+	s.out.WriteString(selector)
+	s.out.WriteString(rng)
+	s.logRanges++
+	return nil
+}
+
+// checkAggregation checks that the log range is aggregated by a range aggregation taking it, unwrapped or not.
+// A log range outside of any call is left to the PromQL parser, which rejects range vector results.
+func (s *scanner) checkAggregation(selector string) error {
+	if len(s.calls) == 0 {
+		return nil
+	}
+	call := s.calls[len(s.calls)-1]
+	unwrap, ok := rangeAggregations[call]
+	switch {
+	case !ok && call == "":
+		return fmt.Errorf("log stream selector %s must be aggregated by a range aggregation", selector)
+	case !ok:
+		return fmt.Errorf("%s is not a range aggregation of log streams", call)
+	case s.unwrapped && unwrap == unwrapForbidden:
+		return fmt.Errorf("%s cannot aggregate an unwrapped range", call)
+	case !s.unwrapped && unwrap == unwrapRequired:
+		return fmt.Errorf("%s needs an unwrapped range", call)
+	}
+	return nil
+}
+
+// skipPipeline validates the stages following a stream selector.
+func (s *scanner) skipPipeline() error {
+	for {
+		s.skipSpace()
+		rest := s.in[s.pos:]
+		switch {
+		case hasAnyPrefix(rest, "|=", "!=", "|~", "!~", "|>", "!>"):
+			op := rest[:2]
+			s.pos += 2
+			if err := s.skipLineFilter(op); err != nil {
+				return err
+			}
+		case strings.HasPrefix(rest, "|"):
+			s.pos++
+			if err := s.skipStage(); err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
+	}
+}
+
+// skipLineFilter validates the values of a line filter, which can be chained with or.
+func (s *scanner) skipLineFilter(op string) error {
+	for {
+		s.skipSpace()
+		if strings.HasPrefix(s.in[s.pos:], "ip(") {
+			s.pos += len("ip")
+			if err := s.skipBalanced('(', ')'); err != nil {
+				return err
+			}
+		} else {
+			lit, err := s.readString()
+			if err != nil {
+				return fmt.Errorf("line filter %s: %w", op, err)
+			}
+			if op[1] == '~' {
+				value, err := strconv.Unquote(lit)
+				if err != nil {
+					return fmt.Errorf("line filter %s: %w", op, err)
+				}
+				if _, err := regexp.Compile(value); err != nil {
+					return fmt.Errorf("line filter %s: invalid regular expression: %w", op, err)
+				}
+			}
+		}
+
+		s.skipSpace()
+		if !strings.HasPrefix(s.in[s.pos:], "or ") {
+			return nil
+		}
+		s.pos += len("or")
+	}
+}
+
+// skipStage validates a parser, formatter or label filter stage.
+func (s *scanner) skipStage() error {
+	s.skipSpace()
+	if s.pos >= len(s.in) {
+		return fmt.Errorf("empty pipeline stage")
+	}
+	if s.in[s.pos] == '(' {
+		return s.skipLabelFilter()
+	}
+	if !isIdentStart(s.in[s.pos]) {
+		return fmt.Errorf("unexpected %q in pipeline", s.in[s.pos])
+	}
+
+	start := s.pos
+	stage := s.readIdent()
+	if !parserStages[stage] {
+		s.pos = start
+		return s.skipLabelFilter()
+	}
+	switch stage {
+	case "unwrap":
+		s.unwrapped = true
+	case "regexp":
+		s.skipSpace()
+		lit, err := s.readString()
+		if err != nil {
+			return fmt.Errorf("regexp stage: %w", err)
+		}
+		value, err := strconv.Unquote(lit)
+		if err != nil {
+			return fmt.Errorf("regexp stage: %w", err)
+		}
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return fmt.Errorf("regexp stage: invalid regular expression: %w", err)
+		}
+		if !slicesContainsNamed(re.SubexpNames()) {
+			return fmt.Errorf("regexp stage: at least one named capture group is required")
+		}
+	case "pattern", "line_format":
+		s.skipSpace()
+		if _, err := s.readString(); err != nil {
+			return fmt.Errorf("%s stage: %w", stage, err)
+		}
+	}
+	return s.skipStageArgs()
+}
+
+// skipLabelFilter validates the predicates of a label filter, combined with and, or, commas or spaces.
+func (s *scanner) skipLabelFilter() error {
+	for {
+		if err := s.skipLabelPredicate(); err != nil {
+			return err
+		}
+		s.skipSpace()
+		rest := s.in[s.pos:]
+		switch {
+		case hasKeyword(rest, "and"):
+			s.pos += len("and")
+		case hasKeyword(rest, "or"):
+			s.pos += len("or")
+		case strings.HasPrefix(rest, ","):
+			s.pos++
+		case rest != "" && (isIdentStart(rest[0]) || rest[0] == '('):
+		default:
+			return nil
+		}
+	}
+}
+
+// labelOperators are the comparisons of label filters, longest first.
+var labelOperators = []string{"==", "=~", "=", "!=", "!~", ">=", ">", "<=", "<"}
+
+// skipLabelPredicate validates a label comparison, or a parenthesized label filter.
+func (s *scanner) skipLabelPredicate() error {
+	s.skipSpace()
+	if s.pos < len(s.in) && s.in[s.pos] == '(' {
+		s.pos++
+		if err := s.skipLabelFilter(); err != nil {
+			return err
+		}
+		s.skipSpace()
+		if s.pos >= len(s.in) || s.in[s.pos] != ')' {
+			return fmt.Errorf("unterminated ( in label filter")
+		}
+		s.pos++
+		return nil
+	}
+	if s.pos >= len(s.in) || !isIdentStart(s.in[s.pos]) {
+		return fmt.Errorf("expected a label filter at position %d", s.pos)
+	}
+
+	label := s.readIdent()
+	s.skipSpace()
+	var op string
+	for _, o := range labelOperators {
+		if strings.HasPrefix(s.in[s.pos:], o) {
+			op = o
+			break
+		}
+	}
+	if op == "" {
+		if c := s.peekNonSpace(); c == 0 || c == '|' || c == '[' || c == ')' {
+			return fmt.Errorf("unknown pipeline stage %q", label)
+		}
+		return fmt.Errorf("label filter on %s: expected a comparison at position %d", label, s.pos)
+	}
+	s.pos += len(op)
+	s.skipSpace()
+
+	rest := s.in[s.pos:]
+	switch {
+	case strings.HasPrefix(rest, "ip(") && (op == "=" || op == "!="):
+		s.pos += len("ip")
+		return s.skipBalanced('(', ')')
+	case rest != "" && (rest[0] == '"' || rest[0] == '`'):
+		lit, err := s.readString()
+		if err != nil {
+			return fmt.Errorf("label filter on %s: %w", label, err)
+		}
+		if !strings.HasSuffix(op, "~") {
+			return nil
+		}
+		value, err := strconv.Unquote(lit)
+		if err != nil {
+			return fmt.Errorf("label filter on %s: %w", label, err)
+		}
+		if _, err := regexp.Compile(value); err != nil {
+			return fmt.Errorf("label filter on %s: invalid regular expression: %w", label, err)
+		}
+		return nil
+	case strings.HasSuffix(op, "~"):
+		return fmt.Errorf("label filter on %s: %s needs a string", label, op)
+	}
+
+	// Numbers, durations and byte sizes
+	start := s.pos
+	if s.pos < len(s.in) && (s.in[s.pos] == '-' || s.in[s.pos] == '+') {
+		s.pos++
+	}
+	for s.pos < len(s.in) && (isIdentByte(s.in[s.pos]) || s.in[s.pos] == '.') {
+		s.pos++
+	}
+	if value := strings.TrimLeft(s.in[start:s.pos], "+-"); value == "" || !('0' <= value[0] && value[0] <= '9' || value[0] == '.') {
+		return fmt.Errorf("label filter on %s: expected a value at position %d", label, start)
+	}
+	return nil
+}
+
+// skipStageArgs skips to the end of a stage: the next stage, the range or the end of the enclosing call.
+func (s *scanner) skipStageArgs() error {
+	for s.pos < len(s.in) {
+		switch c := s.in[s.pos]; {
+		case c == '"' || c == '`':
+			if _, err := s.readString(); err != nil {
+				return err
+			}
+		case c == '(':
+			if err := s.skipBalanced('(', ')'); err != nil {
+				return err
+			}
+		case c == '|' || c == '[' || c == ')':
+			return nil
+		case c == '!' && strings.HasPrefix(s.in[s.pos:], "!>"):
+			return nil
+		case c == '!' && hasAnyPrefix(s.in[s.pos:], "!=", "!~"):
+			// != and !~ compare a label, unless they start a line filter.
+			if s.lineFilterFollows() {
+				return nil
+			}
+			s.pos += 2
+		default:
+			s.pos++
+		}
+	}
+	return nil
+}
+
+// lineFilterFollows reports whether the != at the current position follows the previous stage rather than
+// comparing a label, which is the case when no label name precedes it.
+func (s *scanner) lineFilterFollows() bool {
+	before := strings.TrimRightFunc(s.in[:s.pos], unicode.IsSpace)
+	return before == "" || !isIdentByte(before[len(before)-1])
+}
+
+// skipGrouping drops the grouping LogQL accepts after the arguments of a range aggregation.
+func (s *scanner) skipGrouping() error {
+	save := s.pos
+	s.skipSpace()
+	if s.pos < len(s.in) && isIdentStart(s.in[s.pos]) {
+		if ident := s.readIdent(); ident == "by" || ident == "without" {
+			if s.peekNonSpace() == '(' {
+				s.skipSpace()
+				return s.skipBalanced('(', ')')
+			}
+		}
+	}
+	s.pos = save
+	return nil
+}
+
+func (s *scanner) skipBalanced(open, close byte) error {
+	start := s.pos
+	depth := 0
+	for s.pos < len(s.in) {
+		switch c := s.in[s.pos]; c {
+		case '"', '`':
+			if _, err := s.readString(); err != nil {
+				return err
+			}
+			continue
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				s.pos++
+				return nil
+			}
+		}
+		s.pos++
+	}
+	return fmt.Errorf("unterminated %c at position %d", open, start)
+}
+
+// readString returns the string literal at the current position, quotes included.
+func (s *scanner) readString() (string, error) {
+	if s.pos >= len(s.in) || (s.in[s.pos] != '"' && s.in[s.pos] != '`') {
+		return "", fmt.Errorf("expected a string at position %d", s.pos)
+	}
+	start := s.pos
+	quote := s.in[s.pos]
+	for s.pos++; s.pos < len(s.in); s.pos++ {
+		switch s.in[s.pos] {
+		case '\\':
+			if quote == '"' {
+				s.pos++
+			}
+		case quote:
+			s.pos++
+			return s.in[start:s.pos], nil
+		}
+	}
+	return "", fmt.Errorf("unterminated string at position %d", start)
+}
+
+func (s *scanner) readIdent() string {
+	start := s.pos
+	for s.pos < len(s.in) && isIdentByte(s.in[s.pos]) {
+		s.pos++
+	}
+	return s.in[start:s.pos]
+}
+
+func (s *scanner) skipSpace() {
+	for s.pos < len(s.in) && unicode.IsSpace(rune(s.in[s.pos])) {
+		s.pos++
+	}
+}
+
+func (s *scanner) peekNonSpace() byte {
+	for i := s.pos; i < len(s.in); i++ {
+		if !unicode.IsSpace(rune(s.in[i])) {
+			return s.in[i]
+		}
+	}
+	return 0
+}
+
+// hasKeyword reports whether s starts with the keyword followed by a space or a parenthesis.
+func hasKeyword(s, keyword string) bool {
+	return strings.HasPrefix(s, keyword) && len(s) > len(keyword) && (unicode.IsSpace(rune(s[len(keyword)])) || s[len(keyword)] == '(')
+}
+
+func hasAnyPrefix(s string, prefixes ...string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func slicesContainsNamed(names []string) bool {
+	for _, n := range names {
+		if n != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isIdentByte(c byte) bool {
+	return isIdentStart(c) || '0' <= c && c <= '9' || c == ':'
+}
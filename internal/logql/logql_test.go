@@ -0,0 +1,200 @@
+package logql
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateMetricQuery(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		expr string
+		err  string
+	}{
+		{
+			name: "rate of a stream",
+			expr: `rate({app="api"}[5m])`,
+		},
+		{
+			name: "aggregation with comparison",
+			expr: `sum by (namespace) (count_over_time({app="api", namespace=~"rhobs.*"} |= "error" [5m])) > 10`,
+		},
+		{
+			name: "range aggregation only LogQL knows",
+			expr: `sum(bytes_over_time({app="api"}[1h]))`,
+		},
+		{
+			name: "grouping after the range aggregation arguments",
+			expr: `count_over_time({app="api"}[5m]) by (pod)`,
+		},
+		{
+			name: "chained line filters",
+			expr: `count_over_time({app="api"} |= "timeout" or "deadline" != "health" |~ "code=5.." [5m])`,
+		},
+		{
+			name: "parsers and label filters",
+			expr: `sum(count_over_time({app="api"} | json | status >= 500 | line_format "{{.msg}}" [5m]))`,
+		},
+		{
+			name: "regexp parser with a named group",
+			expr: `count_over_time({app="api"} | regexp "(?P<status>\\d{3})" | status != "200" [5m])`,
+		},
+		{
+			name: "unwrapped label",
+			expr: `quantile_over_time(0.99, {app="api"} | logfmt | unwrap duration [5m]) by (route)`,
+		},
+		{
+			name: "label filter followed by a line filter",
+			expr: `count_over_time({app="api"} | logfmt | level="error" != "retry" [5m])`,
+		},
+		{
+			name: "comment",
+			expr: "# errors of the api\nrate({app=\"api\"}[5m])",
+		},
+		{
+			name: "scalar arithmetic",
+			expr: `sum(rate({app="api"} |= "error" [5m])) / sum(rate({app="api"}[5m]))`,
+		},
+		{
+			name: "log query",
+			expr: `{app="api"} |= "error"`,
+			err:  "must be aggregated over a range",
+		},
+		{
+			name: "no log stream",
+			expr: `vector(1)`,
+			err:  "does not select any log stream",
+		},
+		{
+			name: "metric name",
+			expr: `rate({app="api"}[5m]) / up`,
+			err:  `unexpected identifier "up"`,
+		},
+		{
+			name: "selector matching the empty string",
+			expr: `rate({app=~".*"}[5m])`,
+			err:  "needs at least one equality or regular expression matcher",
+		},
+		{
+			name: "invalid range",
+			expr: `rate({app="api"}[5x])`,
+			err:  "invalid range [5x]",
+		},
+		{
+			name: "unterminated range",
+			expr: `rate({app="api"}[5m)`,
+			err:  "unterminated range",
+		},
+		{
+			name: "invalid line filter regular expression",
+			expr: `count_over_time({app="api"} |~ "(" [5m])`,
+			err:  "invalid regular expression",
+		},
+		{
+			name: "regexp parser without a named group",
+			expr: `count_over_time({app="api"} | regexp "(\\d{3})" [5m])`,
+			err:  "named capture group is required",
+		},
+		{
+			name: "empty pipeline stage",
+			expr: `count_over_time({app="api"} |`,
+			err:  "empty pipeline stage",
+		},
+		{
+			name: "unbalanced parentheses",
+			expr: `rate({app="api"}[5m]))`,
+			err:  "unexpected )",
+		},
+		{
+			name: "unterminated string",
+			expr: `count_over_time({app="api"} |= "error [5m])`,
+			err:  "unterminated string",
+		},
+		{
+			name: "range vector result",
+			expr: `{app="api"}[5m]`,
+			err:  "must return a vector or a scalar",
+		},
+		{
+			name: "label filters combined",
+			expr: `sum(count_over_time({app="api"} | logfmt | (status >= 500 or status == 429) and duration > 1.5s, method!~"GET|HEAD" size < 20kb [5m]))`,
+		},
+		{
+			name: "ip label filter",
+			expr: `count_over_time({app="api"} | logfmt | addr = ip("10.0.0.0/8") [5m])`,
+		},
+		{
+			name: "invalid label filter",
+			expr: `sum(rate({app="a"} | json | this is not valid at all [5m]))`,
+			err:  "label filter on this: expected a comparison",
+		},
+		{
+			name: "misspelled stage",
+			expr: `count_over_time({app="api"} | jsn [5m])`,
+			err:  `unknown pipeline stage "jsn"`,
+		},
+		{
+			name: "invalid label filter regular expression",
+			expr: `count_over_time({app="api"} | logfmt | level=~"(" [5m])`,
+			err:  "label filter on level: invalid regular expression",
+		},
+		{
+			name: "label filter regular expression without a string",
+			expr: `count_over_time({app="api"} | logfmt | status=~500 [5m])`,
+			err:  "=~ needs a string",
+		},
+		{
+			name: "deriv of a log range",
+			expr: `deriv({app="api"} | logfmt | unwrap latency [5m])`,
+			err:  "deriv is not a range aggregation of log streams",
+		},
+		{
+			name: "predict_linear of a log range",
+			expr: `predict_linear({app="api"} | logfmt | unwrap latency [5m], 3600)`,
+			err:  "predict_linear is not a range aggregation of log streams",
+		},
+		{
+			name: "unwrapped range counted",
+			expr: `count_over_time({app="api"} | logfmt | unwrap latency [5m])`,
+			err:  "count_over_time cannot aggregate an unwrapped range",
+		},
+		{
+			name: "sum of a range that is not unwrapped",
+			expr: `sum_over_time({app="api"}[5m])`,
+			err:  "sum_over_time needs an unwrapped range",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateMetricQuery(tc.expr)
+			if tc.err == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("expected an error containing %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestValidateStreamSelector(t *testing.T) {
+	for _, tc := range []struct {
+		selector string
+		valid    bool
+	}{
+		{selector: `{app="api"}`, valid: true},
+		{selector: `{app=~"api|gateway", pod!=""}`, valid: true},
+		{selector: `{app!="api"}`},
+		{selector: `{app=~".*"}`},
+		{selector: `{app=""}`},
+		{selector: `{app="api"`},
+	} {
+		t.Run(tc.selector, func(t *testing.T) {
+			if err := ValidateStreamSelector(tc.selector); (err == nil) != tc.valid {
+				t.Fatalf("got error %v, want valid %t", err, tc.valid)
+			}
+		})
+	}
+}
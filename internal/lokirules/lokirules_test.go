@@ -0,0 +1,75 @@
+package lokirules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rhobs/configuration/internal/ruletest"
+)
+
+func TestLokiRules(t *testing.T) {
+	builder, err := NewLokiRulesBuilder("", map[string]string{}, map[string]string{}, WithServiceLabelValue("rhobs.regional"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ruletest.Run(t, &builder.PrometheusRule,
+		ruletest.Test{
+			Name: "rate limited push requests",
+			// The recorded irate needs two samples in its 1m window.
+			Interval: 30 * time.Second,
+			Input: []ruletest.Series{
+				{Series: `loki_request_duration_seconds_count{job="distributor", namespace="rhobs", route="loki_api_v1_push", status_code="200"}`, Values: "0+40x60"},
+				{Series: `loki_request_duration_seconds_count{job="distributor", namespace="rhobs", route="loki_api_v1_push", status_code="429"}`, Values: "0+10x60"},
+			},
+			Exprs: []ruletest.ExprTest{
+				{
+					At:   5 * time.Minute,
+					Expr: `job_namespace_route_statuscode:loki_request_duration_seconds_count:irate1m{status_code="429"}`,
+					Expected: []ruletest.Sample{
+						{
+							Labels: map[string]string{
+								"__name__":    "job_namespace_route_statuscode:loki_request_duration_seconds_count:irate1m",
+								"job":         "distributor",
+								"namespace":   "rhobs",
+								"route":       "loki_api_v1_push",
+								"status_code": "429",
+							},
+							Value: 10.0 / 30,
+						},
+					},
+				},
+			},
+			Alerts: []ruletest.AlertTest{
+				// 20% of the requests are rate limited, pending for 15m.
+				{At: 10 * time.Minute, Name: "LokiTenantRateLimit"},
+				{
+					At:   20 * time.Minute,
+					Name: "LokiTenantRateLimit",
+					Firing: []ruletest.Alert{
+						{Labels: map[string]string{"job": "distributor", "namespace": "rhobs", "route": "loki_api_v1_push", "service": "rhobs.regional", "severity": "warning"}},
+					},
+				},
+				{At: 20 * time.Minute, Name: "LokiRequestErrors"},
+			},
+		},
+		ruletest.Test{
+			Name: "panic",
+			Input: []ruletest.Series{
+				{Series: `loki_panic_total{job="querier", namespace="rhobs"}`, Values: "0x3 1x20"},
+			},
+			Alerts: []ruletest.AlertTest{
+				{At: 3 * time.Minute, Name: "LokiRequestPanics"},
+				{
+					At:   5 * time.Minute,
+					Name: "LokiRequestPanics",
+					Firing: []ruletest.Alert{
+						{Labels: map[string]string{"job": "querier", "namespace": "rhobs", "service": "rhobs.regional", "severity": "critical"}},
+					},
+				},
+				// The panic is out of the 10m window.
+				{At: 16 * time.Minute, Name: "LokiRequestPanics"},
+			},
+		},
+	)
+}
@@ -0,0 +1,337 @@
+// Package ruletest evaluates the rules of a PrometheusRule in-process, with the Prometheus engine, against synthetic
+// series, so that the rules built in Go can be unit tested next to their builders, as promtool would test them.
+package ruletest
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/promql/promqltest"
+	"github.com/prometheus/prometheus/rules"
+	"github.com/prometheus/prometheus/storage"
+)
+
+const (
+	// defaultInterval is the interval of the input series and of the rule groups that do not set their own.
+	defaultInterval = time.Minute
+	// maxSamples bounds the samples loaded by a single query, large enough for the 4w SLO windows.
+	maxSamples = 50000000
+)
+
+// Series is an input series in the promtool notation, such as `up{job="a"}` with values `1+0x10 0 _ stale`.
+type Series struct {
+	Series string
+	Values string
+}
+
+// Alert is an alert of an AlertTest.
+type Alert struct {
+	// Labels are the labels of the alert, including the ones of its rule. The alert name is implied.
+	Labels map[string]string
+	// Annotations are the expanded annotations of the alert, left unchecked when nil.
+	Annotations map[string]string
+}
+
+// AlertTest asserts the alerts of a rule that are firing at a point in time.
+type AlertTest struct {
+	// At is the time of the evaluation, from the first sample of the input series.
+	At time.Duration
+	// Name is the name of the alerting rule.
+	Name string
+	// Firing are the alerts expected to fire, none when empty.
+	Firing []Alert
+}
+
+// Sample is a sample of an ExprTest.
+type Sample struct {
+	Labels map[string]string
+	Value  float64
+}
+
+// ExprTest asserts the result of a PromQL expression, such as a recorded series, at a point in time.
+type ExprTest struct {
+	// At is the time of the query, from the first sample of the input series.
+	At time.Duration
+	// Expr is the PromQL expression to query.
+	Expr string
+	// Expected are the samples expected out of the expression, in any order.
+	Expected []Sample
+}
+
+// Test is a unit test of the rules.
+type Test struct {
+	Name string
+	// Interval is the interval of the input series, a minute when empty.
+	Interval time.Duration
+	Input    []Series
+	Alerts   []AlertTest
+	Exprs    []ExprTest
+}
+
+// Run runs the tests against the rules of the PrometheusRule, each in its own subtest with fresh alert states.
+func Run(t *testing.T, rule *monitoringv1.PrometheusRule, tests ...Test) {
+	t.Helper()
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			groups, err := ruleGroups(rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			run(t, groups, test)
+		})
+	}
+}
+
+// group is a rule group evaluated by the tests.
+type group struct {
+	name     string
+	interval time.Duration
+	rules    []rules.Rule
+}
+
+// ruleGroups parses the rule groups of the PrometheusRule.
+func ruleGroups(rule *monitoringv1.PrometheusRule) ([]group, error) {
+	logger := slog.New(slog.DiscardHandler)
+	groups := make([]group, 0, len(rule.Spec.Groups))
+	for _, g := range rule.Spec.Groups {
+		grp := group{name: g.Name, interval: defaultInterval}
+		if g.Interval != nil {
+			interval, err := parseDuration(string(*g.Interval))
+			if err != nil {
+				return nil, fmt.Errorf("group %s: parse interval: %w", g.Name, err)
+			}
+			grp.interval = interval
+		}
+
+		for _, r := range g.Rules {
+			expr, err := parser.ParseExpr(r.Expr.String())
+			if err != nil {
+				return nil, fmt.Errorf("group %s: parse expression of %s%s: %w", g.Name, r.Record, r.Alert, err)
+			}
+
+			if r.Record != "" {
+				grp.rules = append(grp.rules, rules.NewRecordingRule(r.Record, expr, labels.FromMap(r.Labels)))
+				continue
+			}
+
+			var hold time.Duration
+			if r.For != nil {
+				if hold, err = parseDuration(string(*r.For)); err != nil {
+					return nil, fmt.Errorf("group %s: parse for of %s: %w", g.Name, r.Alert, err)
+				}
+			}
+			grp.rules = append(grp.rules, rules.NewAlertingRule(
+				r.Alert, expr, hold, 0,
+				labels.FromMap(r.Labels), labels.FromMap(r.Annotations), labels.EmptyLabels(), "",
+				true, logger,
+			))
+		}
+		groups = append(groups, grp)
+	}
+	return groups, nil
+}
+
+// run evaluates the rule groups against the input series of the test, up to its last assertion.
+func run(t *testing.T, groups []group, test Test) {
+	if len(groups) == 0 {
+		t.Fatal("no rule groups to evaluate")
+	}
+
+	interval := test.Interval
+	if interval == 0 {
+		interval = defaultInterval
+	}
+
+	load := []string{fmt.Sprintf("load %s", interval)}
+	for _, s := range test.Input {
+		load = append(load, fmt.Sprintf("  %s %s", s.Series, s.Values))
+	}
+	store := promqltest.LoadedStorage(t, strings.Join(load, "\n"))
+	t.Cleanup(func() { _ = store.Close() })
+
+	engine := promqltest.NewTestEngine(t, false, 0, maxSamples)
+	query := rules.EngineQueryFunc(engine, store)
+	ctx := context.Background()
+
+	var end time.Duration
+	for _, a := range test.Alerts {
+		end = max(end, a.At)
+	}
+	for _, e := range test.Exprs {
+		end = max(end, e.At)
+	}
+
+	// Rules are evaluated in steps of the greatest common divisor of the group intervals, each group at its own.
+	step := groups[0].interval
+	for _, g := range groups[1:] {
+		step = gcd(step, g.interval)
+	}
+
+	for ts := time.Duration(0); ts <= end; ts += step {
+		at := time.Unix(0, 0).UTC().Add(ts)
+		for _, g := range groups {
+			if ts%g.interval != 0 {
+				continue
+			}
+			for _, r := range g.rules {
+				vector, err := r.Eval(ctx, 0, at, query, nil, 0)
+				if err != nil {
+					t.Fatalf("%s: evaluate %s at %s: %v", g.name, r.Name(), ts, err)
+				}
+				if err := appendVector(ctx, store, vector, at); err != nil {
+					t.Fatalf("%s: append %s at %s: %v", g.name, r.Name(), ts, err)
+				}
+			}
+		}
+
+		for _, a := range test.Alerts {
+			if a.At == ts {
+				assertAlerts(t, groups, a)
+			}
+		}
+		for _, e := range test.Exprs {
+			if e.At == ts {
+				assertExpr(t, ctx, query, at, e)
+			}
+		}
+	}
+
+	for _, a := range test.Alerts {
+		if a.At%step != 0 {
+			t.Errorf("alert %s at %s: not on an evaluation step of %s", a.Name, a.At, step)
+		}
+	}
+	for _, e := range test.Exprs {
+		if e.At%step != 0 {
+			t.Errorf("expression %s at %s: not on an evaluation step of %s", e.Expr, e.At, step)
+		}
+	}
+}
+
+// appendVector stores the result of a rule evaluation, so that the following rules and queries see it.
+func appendVector(ctx context.Context, store storage.Appendable, vector promql.Vector, at time.Time) error {
+	app := store.Appender(ctx)
+	for _, s := range vector {
+		if _, err := app.Append(0, s.Metric, at.UnixMilli(), s.F); err != nil {
+			_ = app.Rollback()
+			return err
+		}
+	}
+	return app.Commit()
+}
+
+// assertAlerts checks the alerts firing at the time of the test.
+func assertAlerts(t *testing.T, groups []group, test AlertTest) {
+	t.Helper()
+
+	var got []*rules.Alert
+	found := false
+	for _, g := range groups {
+		for _, r := range g.rules {
+			alerting, ok := r.(*rules.AlertingRule)
+			if !ok || alerting.Name() != test.Name {
+				continue
+			}
+			found = true
+			for _, a := range alerting.ActiveAlerts() {
+				if a.State == rules.StateFiring {
+					got = append(got, a)
+				}
+			}
+		}
+	}
+	if !found {
+		t.Errorf("alert %s at %s: no such alerting rule", test.Name, test.At)
+		return
+	}
+	slices.SortFunc(got, func(a, b *rules.Alert) int { return labels.Compare(a.Labels, b.Labels) })
+
+	firing := slices.Clone(test.Firing)
+	slices.SortFunc(firing, func(a, b Alert) int {
+		return labels.Compare(labels.FromMap(a.Labels), labels.FromMap(b.Labels))
+	})
+	want := make([]labels.Labels, 0, len(firing))
+	for _, a := range firing {
+		want = append(want, labels.NewBuilder(labels.FromMap(a.Labels)).Set(labels.AlertName, test.Name).Labels())
+	}
+
+	equal := len(got) == len(want)
+	for i := 0; equal && i < len(want); i++ {
+		equal = labels.Equal(got[i].Labels, want[i]) &&
+			(firing[i].Annotations == nil || labels.Equal(got[i].Annotations, labels.FromMap(firing[i].Annotations)))
+	}
+	if !equal {
+		var gotAlerts, wantAlerts []string
+		for _, a := range got {
+			gotAlerts = append(gotAlerts, fmt.Sprintf("%s %s", a.Labels, a.Annotations))
+		}
+		for i, lbls := range want {
+			wantAlerts = append(wantAlerts, fmt.Sprintf("%s %s", lbls, labels.FromMap(firing[i].Annotations)))
+		}
+		t.Errorf("alert %s at %s:\nwant firing %v\ngot firing  %v", test.Name, test.At, wantAlerts, gotAlerts)
+	}
+}
+
+// assertExpr checks the result of the expression at the time of the test.
+func assertExpr(t *testing.T, ctx context.Context, query rules.QueryFunc, at time.Time, test ExprTest) {
+	t.Helper()
+
+	vector, err := query(ctx, test.Expr, at)
+	if err != nil {
+		t.Errorf("expression %s at %s: %v", test.Expr, at.Sub(time.Unix(0, 0)), err)
+		return
+	}
+
+	got := map[string]float64{}
+	for _, s := range vector {
+		got[s.Metric.String()] = s.F
+	}
+	want := map[string]float64{}
+	for _, s := range test.Expected {
+		want[labels.FromMap(s.Labels).String()] = s.Value
+	}
+
+	equal := len(got) == len(want)
+	for lbls, v := range want {
+		if g, ok := got[lbls]; !ok || !almostEqual(g, v) {
+			equal = false
+		}
+	}
+	if !equal {
+		t.Errorf("expression %s at %s:\nwant %v\ngot  %v", test.Expr, at.Sub(time.Unix(0, 0)), want, got)
+	}
+}
+
+// almostEqual compares floats within the precision lost by the engine.
+func almostEqual(a, b float64) bool {
+	if math.IsNaN(a) || math.IsNaN(b) {
+		return math.IsNaN(a) && math.IsNaN(b)
+	}
+	return math.Abs(a-b) <= 1e-9*math.Max(1, math.Abs(b))
+}
+
+// parseDuration parses a Prometheus duration, such as 1d or 5m.
+func parseDuration(s string) (time.Duration, error) {
+	d, err := model.ParseDuration(s)
+	return time.Duration(d), err
+}
+
+// gcd returns the greatest common divisor of the durations.
+func gcd(a, b time.Duration) time.Duration {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
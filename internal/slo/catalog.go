@@ -0,0 +1,393 @@
+package slo
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	cfgobservatorium "github.com/rhobs/configuration/configuration/observatorium"
+)
+
+// rhobsSLOs are the SLOs of every component, in the order they are generated.
+var rhobsSLOs = slices.Concat(
+	telemeterUploadSLOs(),
+	telemeterReceiveSLOs(ComponentTelemeterServer, "Telemeter"),
+	telemeterReceiveSLOs(ComponentRhelemeterServer, "Rhelemeter"),
+	observatoriumMetricsSLOs(),
+	observatoriumLogsSLOs(),
+	observatoriumProbesSLOs(),
+)
+
+// telemeterReceiveSLOs returns the openshift/telemeter specific SLOs we maintain for the remote write path. This path
+// runs on diferent instances, so we need to be able to customize the instance name.
+func telemeterReceiveSLOs(component Component, instanceName string) []rhobsSLO {
+	return []rhobsSLO{
+		{
+			name:      fmt.Sprintf("rhobs-%s-server-metrics-receive-availability-slo", strings.ToLower(instanceName)),
+			signal:    cfgobservatorium.MetricsResource,
+			component: component,
+			// This has to match a service known by app-interface, so we can't use the instance name because Rhelemeter
+			// is part of the Telemeter service definition for now.
+			service: "telemeter",
+			labels: map[string]string{
+				"route": fmt.Sprintf("%s-server-receive", strings.ToLower(instanceName)),
+			},
+			description:         fmt.Sprintf("%s Server /receive is burning too much error budget to guarantee availability SLOs.", instanceName),
+			successOrErrorsExpr: fmt.Sprintf("haproxy_server_http_responses_total{route=\"%s-server-metrics-v1-receive\", code=~\"5..\"}", strings.ToLower(instanceName)),
+			totalExpr:           fmt.Sprintf("haproxy_server_http_responses_total{route=\"%s-server-metrics-v1-receive\"}", strings.ToLower(instanceName)),
+			alertName:           fmt.Sprintf("%sServerMetricsReceiveWriteAvailabilityErrorBudgetBurning", instanceName),
+			sloType:             sloTypeAvailability,
+		},
+		{
+			name:      fmt.Sprintf("rhobs-%s-server-metrics-receive-latency-slo", strings.ToLower(instanceName)),
+			signal:    cfgobservatorium.MetricsResource,
+			component: component,
+			service:   "telemeter",
+			labels: map[string]string{
+				"route": fmt.Sprintf("%s-server-receive", strings.ToLower(instanceName)),
+			},
+			description:         fmt.Sprintf("%s Server /receive is burning too much error budget to guarantee latency SLOs.", instanceName),
+			successOrErrorsExpr: fmt.Sprintf("http_request_duration_seconds_bucket{job=\"%s-server\", handler=\"receive\", code=~\"^2..$\", le=\""+genericSLOLatencySeconds+"\"}", strings.ToLower(instanceName)),
+			totalExpr:           fmt.Sprintf("http_request_duration_seconds_count{job=\"%s-server\", handler=\"receive\", code=~\"^2..$\"}", strings.ToLower(instanceName)),
+			alertName:           fmt.Sprintf("%sServerMetricsReceiveWriteLatencyErrorBudgetBurning", instanceName),
+			sloType:             sloTypeLatency,
+		},
+	}
+}
+
+// telemeterUploadSLOs returns the openshift/telemeter specific SLOs we maintain for the upload path. These are only
+// available for the Telemeter instance, so there's no customization on instance name.
+func telemeterUploadSLOs() []rhobsSLO {
+	return []rhobsSLO{
+		{
+			name:      "rhobs-telemeter-server-metrics-upload-availability-slo",
+			signal:    cfgobservatorium.MetricsResource,
+			component: ComponentTelemeterServer,
+			service:   "telemeter",
+			labels: map[string]string{
+				"route": "telemeter-server-upload",
+			},
+			description:         "Telemeter Server /upload is burning too much error budget to guarantee availability SLOs.",
+			successOrErrorsExpr: "haproxy_server_http_responses_total{route=\"telemeter-server-upload\", code=~\"5..\"}",
+			totalExpr:           "haproxy_server_http_responses_total{route=\"telemeter-server-upload\"}",
+			alertName:           "TelemeterServerMetricsUploadWriteAvailabilityErrorBudgetBurning",
+			sloType:             sloTypeAvailability,
+		},
+		{
+			name:      "rhobs-telemeter-server-metrics-upload-latency-slo",
+			signal:    cfgobservatorium.MetricsResource,
+			component: ComponentTelemeterServer,
+			service:   "telemeter",
+			labels: map[string]string{
+				"route": "telemeter-server-upload",
+			},
+			description:         "Telemeter Server /upload is burning too much error budget to guarantee latency SLOs.",
+			successOrErrorsExpr: "http_request_duration_seconds_bucket{job=\"telemeter-server\", handler=\"upload\", code=~\"^2..$\", le=\"" + genericSLOLatencySeconds + "\"}",
+			totalExpr:           "http_request_duration_seconds_count{job=\"telemeter-server\", handler=\"upload\", code=~\"^2..$\"}",
+			alertName:           "TelemeterServerMetricsUploadWriteLatencyErrorBudgetBurning",
+			sloType:             sloTypeLatency,
+		},
+	}
+}
+
+// observatoriumMetricsSLOs returns the observatorium/observatorium specific SLOs we maintain for metrics.
+func observatoriumMetricsSLOs() []rhobsSLO {
+	return []rhobsSLO{
+		// Observatorium Metrics Availability SLOs.
+		{
+			name:                "api-metrics-write-availability-slo",
+			signal:              cfgobservatorium.MetricsResource,
+			component:           ComponentGateway,
+			description:         "API /receive handler is burning too much error budget to guarantee availability SLOs.",
+			successOrErrorsExpr: "http_requests_total{" + selector + ", handler=\"receive\", group=\"metricsv1\", code=~\"^5..$\"}",
+			totalExpr:           "http_requests_total{" + selector + ", handler=\"receive\", group=\"metricsv1\"}",
+			alertName:           "APIMetricsWriteAvailabilityErrorBudgetBurning",
+			sloType:             sloTypeAvailability,
+		},
+		// Queriers are deployed as separate instances for adhoc and rule queries.
+		// The read availability SLO is split to reflect this deployment topology.
+		{
+			name:                "api-metrics-rule-query-availability-slo",
+			signal:              cfgobservatorium.MetricsResource,
+			component:           ComponentRuleQuery,
+			description:         "API /query handler endpoint for rules evaluation is burning too much error budget to guarantee availability SLOs.",
+			successOrErrorsExpr: "http_requests_total{" + selector + ", handler=\"query\", code=~\"^5..$\"}",
+			totalExpr:           "http_requests_total{" + selector + ", handler=\"query\"}",
+			alertName:           "APIMetricsRulerQueryAvailabilityErrorBudgetBurning",
+			sloType:             sloTypeAvailability,
+		},
+		{
+			name:                "api-metrics-query-availability-slo",
+			signal:              cfgobservatorium.MetricsResource,
+			component:           ComponentGateway,
+			description:         "API /query handler is burning too much error budget to guarantee availability SLOs.",
+			successOrErrorsExpr: "http_requests_total{" + selector + ", handler=\"query\", group=\"metricsv1\", code=~\"^5..$\"}",
+			totalExpr:           "http_requests_total{" + selector + ", handler=\"query\", group=\"metricsv1\"}",
+			alertName:           "APIMetricsQueryAvailabilityErrorBudgetBurning",
+			sloType:             sloTypeAvailability,
+		},
+		{
+			name:                "api-metrics-query-range-availability-slo",
+			signal:              cfgobservatorium.MetricsResource,
+			component:           ComponentGateway,
+			description:         "API /query_range handler is burning too much error budget to guarantee availability SLOs.",
+			successOrErrorsExpr: "http_requests_total{" + selector + ", handler=\"query_range\", group=\"metricsv1\", code=~\"^5..$\"}",
+			totalExpr:           "http_requests_total{" + selector + ", handler=\"query_range\", group=\"metricsv1\"}",
+			alertName:           "APIMetricsQueryRangeAvailabilityErrorBudgetBurning",
+			sloType:             sloTypeAvailability,
+		},
+		{
+			name:                "api-rules-raw-write-availability-slo",
+			signal:              cfgobservatorium.MetricsResource,
+			component:           ComponentGatewayRules,
+			description:         "API /rules/raw endpoint for writes is burning too much error budget to guarantee availability SLOs.",
+			successOrErrorsExpr: "http_requests_total{" + selector + ", handler=\"rules-raw\", method=\"PUT\", group=\"metricsv1\", code=~\"^5..$\"}",
+			totalExpr:           "http_requests_total{" + selector + ", handler=\"rules-raw\", method=\"PUT\", group=\"metricsv1\"}",
+			alertName:           "APIRulesRawWriteAvailabilityErrorBudgetBurning",
+			sloType:             sloTypeAvailability,
+		},
+		{
+			name:                "api-rules-read-availability-slo",
+			signal:              cfgobservatorium.MetricsResource,
+			component:           ComponentGatewayRules,
+			description:         "API /rules endpoint is burning too much error budget to guarantee availability SLOs.",
+			successOrErrorsExpr: "http_requests_total{" + selector + ", handler=\"rules\", method=\"GET\", group=\"metricsv1\", code=~\"^5..$\"}",
+			totalExpr:           "http_requests_total{" + selector + ", handler=\"rules\", method=\"GET\", group=\"metricsv1\"}",
+			alertName:           "APIRulesReadAvailabilityErrorBudgetBurning",
+			sloType:             sloTypeAvailability,
+		},
+		{
+			name:                "api-rules-sync-availability-slo",
+			signal:              cfgobservatorium.MetricsResource,
+			component:           ComponentRuleSyncer,
+			description:         "Thanos Ruler /reload endpoint is burning too much error budget to guarantee availability SLOs.",
+			successOrErrorsExpr: "client_api_requests_total{client=\"reload\", " + selector + ", code=~\"^5..$\"}",
+			totalExpr:           "client_api_requests_total{client=\"reload\", " + selector + "}",
+			alertName:           "APIRulesSyncAvailabilityErrorBudgetBurning",
+			sloType:             sloTypeAvailability,
+		},
+		{
+			name:                "api-alerting-availability-slo",
+			signal:              cfgobservatorium.MetricsResource,
+			component:           ComponentRuler,
+			description:         "API Thanos Rule failing to send alerts to Alertmanager and is burning too much error budget to guarantee availability SLOs.",
+			summary:             "API Thanos Rule is burning too much error budget to guarantee availability SLOs.",
+			successOrErrorsExpr: "thanos_alert_sender_alerts_dropped_total{" + selector + ", code=~\"^5..$\"}",
+			totalExpr:           "thanos_alert_sender_alerts_dropped_total{" + selector + "}",
+			alertName:           "APIAlertmanagerAvailabilityErrorBudgetBurning",
+			sloType:             sloTypeAvailability,
+		},
+		{
+			name:                "api-alerting-notif-availability-slo",
+			signal:              cfgobservatorium.MetricsResource,
+			component:           ComponentAlertmanager,
+			description:         "API Alertmanager failing to deliver alerts to upstream targets and is burning too much error budget to guarantee availability SLOs.",
+			summary:             "API Alertmanager is burning too much error budget to guarantee availability SLOs.",
+			metricService:       true,
+			successOrErrorsExpr: "alertmanager_notifications_failed_total{" + selector + ", code=~\"^5..$\"}",
+			totalExpr:           "alertmanager_notifications_failed_total{" + selector + "}",
+			alertName:           "APIAlertmanagerNotificationsAvailabilityErrorBudgetBurning",
+			sloType:             sloTypeAvailability,
+		},
+
+		// Observatorium Metrics Latency SLOs.
+		{
+			name:                "api-metrics-write-latency-slo",
+			signal:              cfgobservatorium.MetricsResource,
+			component:           ComponentGateway,
+			description:         "API /receive handler is burning too much error budget to guarantee latency SLOs.",
+			summary:             "API /receive handler is burning too much latency error budget.",
+			successOrErrorsExpr: "http_request_duration_seconds_bucket{" + selector + ", handler=\"receive\", group=\"metricsv1\", code=~\"^2..$\", le=\"" + genericSLOLatencySeconds + "\"}",
+			totalExpr:           "http_request_duration_seconds_count{" + selector + ", handler=\"receive\", group=\"metricsv1\", code=~\"^2..$\"}",
+			alertName:           "APIMetricsWriteLatencyErrorBudgetBurning",
+			sloType:             sloTypeLatency,
+		},
+		// Queriers are deployed as separate instances for adhoc and rule queries.
+		// The read latencies SLO are split to reflect this deployment topology.
+		{
+			name:                "api-metrics-rule-read-1M-latency-slo",
+			signal:              cfgobservatorium.MetricsResource,
+			component:           ComponentUp,
+			description:         "API /query endpoint for rules evaluation is burning too much error budget for 1M samples, to guarantee latency SLOs.",
+			successOrErrorsExpr: "up_custom_query_duration_seconds_bucket{query=\"rule-query-path-sli-1M-samples\", " + selector + ", http_code=~\"^2..$\", le=\"10\"}",
+			totalExpr:           "up_custom_query_duration_seconds_count{query=\"rule-query-path-sli-1M-samples\", " + selector + ", http_code=~\"^2..$\"}",
+			alertName:           "APIMetricsRuleReadLatency1MErrorBudgetBurning",
+			sloType:             sloTypeLatency,
+		},
+		{
+			name:                "api-metrics-rule-read-10M-latency-slo",
+			signal:              cfgobservatorium.MetricsResource,
+			component:           ComponentUp,
+			description:         "API /query endpoint for rules evaluation is burning too much error budget for 100M samples, to guarantee latency SLOs.",
+			successOrErrorsExpr: "up_custom_query_duration_seconds_bucket{query=\"rule-query-path-sli-10M-samples\", " + selector + ", http_code=~\"^2..$\", le=\"30\"}",
+			totalExpr:           "up_custom_query_duration_seconds_count{query=\"rule-query-path-sli-10M-samples\", " + selector + ", http_code=~\"^2..$\"}",
+			alertName:           "APIMetricsRuleReadLatency10MErrorBudgetBurning",
+			sloType:             sloTypeLatency,
+		},
+		{
+			name:                "api-metrics-rule-read-100M-latency-slo",
+			signal:              cfgobservatorium.MetricsResource,
+			component:           ComponentUp,
+			description:         "API /query endpoint for rules evaluation is burning too much error budget for 100M samples, to guarantee latency SLOs.",
+			successOrErrorsExpr: "up_custom_query_duration_seconds_bucket{query=\"rule-query-path-sli-1M-samples\", " + selector + ", http_code=~\"^2..$\", le=\"120\"}",
+			totalExpr:           "up_custom_query_duration_seconds_count{query=\"rule-query-path-sli-1M-samples\", " + selector + ", http_code=~\"^2..$\"}",
+			alertName:           "APIMetricsRulenReadLatency100MErrorBudgetBurning",
+			sloType:             sloTypeLatency,
+		},
+		{
+			name:                "api-metrics-read-1M-latency-slo",
+			signal:              cfgobservatorium.MetricsResource,
+			component:           ComponentUp,
+			description:         "API /query endpoint is burning too much error budget for 1M samples, to guarantee latency SLOs.",
+			successOrErrorsExpr: "up_custom_query_duration_seconds_bucket{query=\"query-path-sli-1M-samples\", " + selector + ", http_code=~\"^2..$\", le=\"10\"}",
+			totalExpr:           "up_custom_query_duration_seconds_count{query=\"query-path-sli-1M-samples\", " + selector + ", http_code=~\"^2..$\"}",
+			alertName:           "APIMetricsReadLatency1MErrorBudgetBurning",
+			sloType:             sloTypeLatency,
+		},
+		{
+			name:                "api-metrics-read-10M-latency-slo",
+			signal:              cfgobservatorium.MetricsResource,
+			component:           ComponentUp,
+			description:         "API /query endpoint is burning too much error budget for 100M samples, to guarantee latency SLOs.",
+			successOrErrorsExpr: "up_custom_query_duration_seconds_bucket{query=\"query-path-sli-10M-samples\", " + selector + ", http_code=~\"^2..$\", le=\"30\"}",
+			totalExpr:           "up_custom_query_duration_seconds_count{query=\"query-path-sli-10M-samples\", " + selector + ", http_code=~\"^2..$\"}",
+			alertName:           "APIMetricsReadLatency10MErrorBudgetBurning",
+			sloType:             sloTypeLatency,
+		},
+		{
+			name:                "api-metrics-read-100M-latency-slo",
+			signal:              cfgobservatorium.MetricsResource,
+			component:           ComponentUp,
+			description:         "API /query endpoint is burning too much error budget for 100M samples, to guarantee latency SLOs.",
+			successOrErrorsExpr: "up_custom_query_duration_seconds_bucket{query=\"query-path-sli-1M-samples\", " + selector + ", http_code=~\"^2..$\", le=\"120\"}",
+			totalExpr:           "up_custom_query_duration_seconds_count{query=\"query-path-sli-1M-samples\", " + selector + ", http_code=~\"^2..$\"}",
+			alertName:           "APIMetricsReadLatency100MErrorBudgetBurning",
+			sloType:             sloTypeLatency,
+		},
+	}
+}
+
+// observatoriumLogsSLOs returns the SLOs we maintain for logs, on the gateway handlers and on the LokiStack
+// components behind them.
+func observatoriumLogsSLOs() []rhobsSLO {
+	return []rhobsSLO{
+		// Observatorium Logs Availability SLOs.
+		{
+			name:                "api-logs-push-availability-slo",
+			signal:              cfgobservatorium.LogsResource,
+			component:           ComponentGateway,
+			description:         "API /loki/api/v1/push handler is burning too much error budget to guarantee availability SLOs.",
+			successOrErrorsExpr: "http_requests_total{" + selector + ", handler=\"push\", group=\"logsv1\", code=~\"^5..$\"}",
+			totalExpr:           "http_requests_total{" + selector + ", handler=\"push\", group=\"logsv1\"}",
+			alertName:           "APILogsPushAvailabilityErrorBudgetBurning",
+			sloType:             sloTypeAvailability,
+		},
+		{
+			name:                "api-logs-query-range-availability-slo",
+			signal:              cfgobservatorium.LogsResource,
+			component:           ComponentGateway,
+			description:         "API /loki/api/v1/query_range handler is burning too much error budget to guarantee availability SLOs.",
+			successOrErrorsExpr: "http_requests_total{" + selector + ", handler=\"query_range\", group=\"logsv1\", code=~\"^5..$\"}",
+			totalExpr:           "http_requests_total{" + selector + ", handler=\"query_range\", group=\"logsv1\"}",
+			alertName:           "APILogsQueryRangeAvailabilityErrorBudgetBurning",
+			sloType:             sloTypeAvailability,
+		},
+		{
+			name:                "loki-distributor-push-availability-slo",
+			signal:              cfgobservatorium.LogsResource,
+			component:           ComponentLokiDistributor,
+			description:         "Loki distributor push route is burning too much error budget to guarantee availability SLOs.",
+			successOrErrorsExpr: "loki_request_duration_seconds_count{" + selector + ", route=\"loki_api_v1_push\", status_code=~\"^5..$\"}",
+			totalExpr:           "loki_request_duration_seconds_count{" + selector + ", route=\"loki_api_v1_push\"}",
+			alertName:           "LokiDistributorPushAvailabilityErrorBudgetBurning",
+			sloType:             sloTypeAvailability,
+		},
+		{
+			name:                "loki-query-frontend-query-range-availability-slo",
+			signal:              cfgobservatorium.LogsResource,
+			component:           ComponentLokiQueryFrontend,
+			description:         "Loki query-frontend query_range route is burning too much error budget to guarantee availability SLOs.",
+			successOrErrorsExpr: "loki_request_duration_seconds_count{" + selector + ", route=\"loki_api_v1_query_range\", status_code=~\"^5..$\"}",
+			totalExpr:           "loki_request_duration_seconds_count{" + selector + ", route=\"loki_api_v1_query_range\"}",
+			alertName:           "LokiQueryFrontendQueryRangeAvailabilityErrorBudgetBurning",
+			sloType:             sloTypeAvailability,
+		},
+
+		// Observatorium Logs Latency SLOs.
+		{
+			name:                "api-logs-push-latency-slo",
+			signal:              cfgobservatorium.LogsResource,
+			component:           ComponentGateway,
+			description:         "API /loki/api/v1/push handler is burning too much error budget to guarantee latency SLOs.",
+			summary:             "API /loki/api/v1/push handler is burning too much latency error budget.",
+			successOrErrorsExpr: "http_request_duration_seconds_bucket{" + selector + ", handler=\"push\", group=\"logsv1\", code=~\"^2..$\", le=\"" + genericSLOLatencySeconds + "\"}",
+			totalExpr:           "http_request_duration_seconds_count{" + selector + ", handler=\"push\", group=\"logsv1\", code=~\"^2..$\"}",
+			alertName:           "APILogsPushLatencyErrorBudgetBurning",
+			sloType:             sloTypeLatency,
+		},
+		{
+			name:                "api-logs-query-range-latency-slo",
+			signal:              cfgobservatorium.LogsResource,
+			component:           ComponentGateway,
+			description:         "API /loki/api/v1/query_range handler is burning too much error budget to guarantee latency SLOs.",
+			summary:             "API /loki/api/v1/query_range handler is burning too much latency error budget.",
+			successOrErrorsExpr: "http_request_duration_seconds_bucket{" + selector + ", handler=\"query_range\", group=\"logsv1\", code=~\"^2..$\", le=\"60\"}",
+			totalExpr:           "http_request_duration_seconds_count{" + selector + ", handler=\"query_range\", group=\"logsv1\", code=~\"^2..$\"}",
+			alertName:           "APILogsQueryRangeLatencyErrorBudgetBurning",
+			sloType:             sloTypeLatency,
+		},
+		{
+			name:                "loki-distributor-push-latency-slo",
+			signal:              cfgobservatorium.LogsResource,
+			component:           ComponentLokiDistributor,
+			description:         "Loki distributor push route is burning too much error budget to guarantee latency SLOs.",
+			summary:             "Loki distributor push route is burning too much latency error budget.",
+			successOrErrorsExpr: "loki_request_duration_seconds_bucket{" + selector + ", route=\"loki_api_v1_push\", status_code=~\"^2..$\", le=\"" + genericSLOLatencySeconds + "\"}",
+			totalExpr:           "loki_request_duration_seconds_count{" + selector + ", route=\"loki_api_v1_push\", status_code=~\"^2..$\"}",
+			alertName:           "LokiDistributorPushLatencyErrorBudgetBurning",
+			sloType:             sloTypeLatency,
+		},
+		// Loki request durations have no 60s bucket, 50s is the closest to the gateway's.
+		{
+			name:                "loki-query-frontend-query-range-latency-slo",
+			signal:              cfgobservatorium.LogsResource,
+			component:           ComponentLokiQueryFrontend,
+			description:         "Loki query-frontend query_range route is burning too much error budget to guarantee latency SLOs.",
+			summary:             "Loki query-frontend query_range route is burning too much latency error budget.",
+			successOrErrorsExpr: "loki_request_duration_seconds_bucket{" + selector + ", route=\"loki_api_v1_query_range\", status_code=~\"^2..$\", le=\"50\"}",
+			totalExpr:           "loki_request_duration_seconds_count{" + selector + ", route=\"loki_api_v1_query_range\", status_code=~\"^2..$\"}",
+			alertName:           "LokiQueryFrontendQueryRangeLatencyErrorBudgetBurning",
+			sloType:             sloTypeLatency,
+		},
+	}
+}
+
+// syntheticsAPIInternalHandlers are the handlers of the synthetics API scraped and probed by the cluster itself,
+// left out of its availability SLO.
+const syntheticsAPIInternalHandlers = "/metrics|/livez|/readyz"
+
+// observatoriumProbesSLOs returns the SLOs we maintain for probes, on the synthetics API managing the probes and on
+// the probes run by the blackbox exporter.
+func observatoriumProbesSLOs() []rhobsSLO {
+	return []rhobsSLO{
+		{
+			name:                "synthetics-api-availability-slo",
+			signal:              cfgobservatorium.ProbesResource,
+			component:           ComponentSyntheticsAPI,
+			description:         "Synthetics API is burning too much error budget to guarantee availability SLOs.",
+			successOrErrorsExpr: "http_requests_total{" + selector + ", handler!~\"" + syntheticsAPIInternalHandlers + "\", code=~\"^5..$\"}",
+			totalExpr:           "http_requests_total{" + selector + ", handler!~\"" + syntheticsAPIInternalHandlers + "\"}",
+			alertName:           "SyntheticsAPIAvailabilityErrorBudgetBurning",
+			sloType:             sloTypeAvailability,
+		},
+		{
+			name:                "synthetics-probes-success-slo",
+			signal:              cfgobservatorium.ProbesResource,
+			component:           ComponentProber,
+			description:         "Synthetics probes are failing too often and burning too much error budget to guarantee availability SLOs.",
+			successOrErrorsExpr: "probe_success{" + selector + "}",
+			alertName:           "SyntheticsProbesSuccessErrorBudgetBurning",
+			sloType:             sloTypeBoolGauge,
+		},
+	}
+}
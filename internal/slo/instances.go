@@ -0,0 +1,226 @@
+package slo
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/bwplotka/mimic"
+	"github.com/bwplotka/mimic/encoding"
+	monitoring "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring"
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	pyrrav1alpha1 "github.com/pyrra-dev/pyrra/kubernetes/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/rhobs/configuration/clusters"
+	cfgobservatorium "github.com/rhobs/configuration/configuration/observatorium"
+)
+
+const (
+	// clusterAvailabilityTargetPercent is the availability target of the SLOs of the registered clusters.
+	clusterAvailabilityTargetPercent = "99.9"
+	// clusterServiceLabel is the service of the SLOs of the registered clusters.
+	clusterServiceLabel = "rhobs.regional"
+	// clusterDashboardID is the Grafana SLO dashboard of the registered clusters.
+	clusterDashboardID = "283e7002d85c08126681241df2fdb22b"
+)
+
+// ForCluster returns the instance of the registered cluster. It serves the signals enabled in its gateway,
+// and runs the components of its build steps in its namespace.
+func ForCluster(config clusters.ClusterConfig) Instance {
+	i := Instance{
+		Name:               string(config.Name),
+		Service:            clusterServiceLabel,
+		Components:         map[Component]string{},
+		AvailabilityTarget: clusterAvailabilityTargetPercent,
+		Dashboard:          grafanaLink(clusterDashboardID, "rhobs-next", string(config.Name)+"-prometheus", config.Namespace),
+		RunbookURL:         runbookBaseURL,
+	}
+	if config.GatewayConfig != nil {
+		if config.GatewayConfig.MetricsEnabled() {
+			i.Signals = append(i.Signals, cfgobservatorium.MetricsResource)
+		}
+		if config.GatewayConfig.LogsEnabled() {
+			i.Signals = append(i.Signals, cfgobservatorium.LogsResource)
+		}
+		if config.GatewayConfig.SyntheticsEnabled() {
+			i.Signals = append(i.Signals, cfgobservatorium.ProbesResource)
+		}
+	}
+
+	namespace := fmt.Sprintf("namespace=%q", config.Namespace)
+	if slices.Contains(config.BuildSteps, clusters.StepGateway) {
+		i.Components[ComponentGateway] = `job="rhobs-gateway", ` + namespace
+	}
+	if slices.Contains(config.BuildSteps, clusters.StepDefaultThanosStack) {
+		i.Components[ComponentRuler] = `container="thanos-ruler", ` + namespace
+	}
+	if slices.Contains(config.BuildSteps, clusters.StepAlertmanager) {
+		i.Components[ComponentAlertmanager] = `job="alertmanager", ` + namespace
+	}
+	if slices.Contains(config.BuildSteps, clusters.StepDefaultLokiStack) {
+		i.Components[ComponentLokiDistributor] = `job="observatorium-lokistack-distributor-http", ` + namespace
+		i.Components[ComponentLokiQueryFrontend] = `job="observatorium-lokistack-query-frontend-http", ` + namespace
+	}
+	if slices.Contains(config.BuildSteps, clusters.StepSyntheticsApi) {
+		i.Components[ComponentSyntheticsAPI] = `job="synthetics-api", ` + namespace
+		// The synthetics agent turns the probes of the API into Probe resources of the namespace.
+		i.Components[ComponentProber] = fmt.Sprintf(`job=~"probe/%s/.+"`, config.Namespace)
+	}
+	return i
+}
+
+// rhobsInstanceEnv represents a particular RHOBS Instance environment, deployed before the registered clusters.
+type rhobsInstanceEnv string
+
+const (
+	telemeterProduction  rhobsInstanceEnv = "telemeter-production"  // Telemeter production Observatorium instance on telemeter-prod-01 cluster.
+	telemeterStaging     rhobsInstanceEnv = "telemeter-staging"     // Telemeter staging Observatorium instance on app-sre-stage-01 cluster.
+	rhelemeterProduction rhobsInstanceEnv = "rhelemeter-production" // Rhelemeter production instance on telemeter-prod-01 cluster.
+	rhelemeterStaging    rhobsInstanceEnv = "rhelemeter-stage"      // Rhelemeter staging instance on app-sre-stage-01 cluster.
+	mstProduction        rhobsInstanceEnv = "mst-production"        // MST production Observatorium instance on telemeter-prod-01 cluster.
+	mstStage             rhobsInstanceEnv = "mst-stage"             // MST staging Observatorium instance on app-sre-stage-01 cluster.
+)
+
+// legacyEnv holds what differs between the Observatorium instances of the environments.
+type legacyEnv struct {
+	// dashboardID and dashboardDataSource are the Grafana SLO dashboard of the environment and its data source.
+	dashboardID         string
+	dashboardDataSource string
+	// apiJob is the Prometheus job label value for the Observatorium API.
+	apiJob string
+	// metricsNS is the namespace for the metrics (Thanos) components of Observatorium instance.
+	// Usually the same namespace as Observatorium API, but can be different in some
+	// cases like Telemeter due to historical reasons.
+	metricsNS string
+	// upNS is the namespace for the observatorium/up job in Observatorium instance, that allows
+	// generating synthetic data for Query SLOs. Usually the same namespace as other
+	// components, but can be different in some cases like Telemeter due to historical
+	// reasons.
+	upNS string
+}
+
+// legacyEnvs can be deprecated once we unify our environments.
+var legacyEnvs = map[rhobsInstanceEnv]legacyEnv{
+	telemeterProduction: {
+		dashboardID:         "f9fa7677fb4a2669f123f9a0f2234b47",
+		dashboardDataSource: "telemeter-prod-01-prometheus",
+		apiJob:              "observatorium-observatorium-api",
+		metricsNS:           "observatorium-metrics-production",
+		upNS:                "observatorium-production",
+	},
+	telemeterStaging: {
+		dashboardID:         "080e53f245a15445bdf777ae0e66945d",
+		dashboardDataSource: "app-sre-stage-01-prometheus",
+		apiJob:              "observatorium-observatorium-api",
+		metricsNS:           "observatorium-metrics-stage",
+		upNS:                "observatorium-stage",
+	},
+	mstProduction: {
+		dashboardID:         "283e7002d85c08126681241df2fdb22b",
+		dashboardDataSource: "telemeter-prod-01-prometheus",
+		apiJob:              "observatorium-observatorium-mst-api",
+		metricsNS:           "observatorium-mst-production",
+		upNS:                "observatorium-mst-production",
+	},
+	mstStage: {
+		dashboardID:         "92520ea4d6976f30d1618164e186ef9b",
+		dashboardDataSource: "app-sre-stage-01-prometheus",
+		apiJob:              "observatorium-observatorium-mst-api",
+		metricsNS:           "observatorium-mst-stage",
+		upNS:                "observatorium-mst-stage",
+	},
+}
+
+// legacyInstance returns the instance of the environment, running the Observatorium components if observatorium
+// is set, and the given telemeter servers.
+func legacyInstance(envName rhobsInstanceEnv, observatorium bool, servers ...Component) Instance {
+	env := legacyEnvs[envName]
+	i := Instance{
+		Name:               string(envName),
+		Service:            "observatorium-api",
+		Signals:            []cfgobservatorium.Resource{cfgobservatorium.MetricsResource},
+		Components:         map[Component]string{},
+		AvailabilityTarget: "99",
+		Labels:             map[string]string{"instance": string(envName)},
+		Dashboard:          grafanaLink(env.dashboardID, string(envName), env.dashboardDataSource, "{{$labels.namespace}}"),
+		RunbookURL:         runbookBaseURL,
+		Legacy:             true,
+	}
+	for _, server := range servers {
+		i.Components[server] = ""
+	}
+	if observatorium {
+		i.Components[ComponentGateway] = fmt.Sprintf("job=%q", env.apiJob)
+		i.Components[ComponentGatewayRules] = fmt.Sprintf("job=%q", env.apiJob)
+		i.Components[ComponentRuleQuery] = `job="observatorium-ruler-query"`
+		i.Components[ComponentRuleSyncer] = fmt.Sprintf(`container="thanos-rule-syncer", namespace=%q`, env.metricsNS)
+		i.Components[ComponentRuler] = fmt.Sprintf(`container="thanos-rule", namespace=%q`, env.metricsNS)
+		i.Components[ComponentAlertmanager] = fmt.Sprintf(`service="observatorium-alertmanager", namespace=%q`, env.metricsNS)
+		i.Components[ComponentUp] = fmt.Sprintf("namespace=%q", env.upNS)
+	}
+	return i
+}
+
+var (
+	promRuleTypeMeta = metav1.TypeMeta{
+		APIVersion: monitoring.GroupName + "/" + monitoringv1.Version,
+		Kind:       monitoringv1.PrometheusRuleKind,
+	}
+
+	// Needed appSRE labels for prom-operator PromethuesRule file.
+	ruleFileLabels = map[string]string{
+		"prometheus": "app-sre",
+		"role":       "alert-rules",
+	}
+)
+
+// appInterfacePrometheusRule allows adding schema field to the generated YAML.
+type appInterfacePrometheusRule struct {
+	Schema string `json:"$schema"`
+	monitoringv1.PrometheusRule
+}
+
+// GenSLO is the function responsible for tying together Pyrra Objectives of the environments deployed before the
+// registered clusters and converting them into SLO+Rule files.
+func GenSLO(genPyrra, genRules *mimic.Generator) {
+	envSLOs(legacyInstance(rhelemeterProduction, false, ComponentRhelemeterServer), "rhobs-slos-rhelemeter-production", genPyrra, genRules)
+	envSLOs(legacyInstance(rhelemeterStaging, false, ComponentRhelemeterServer), "rhobs-slos-rhelemeter-stage", genPyrra, genRules)
+	envSLOs(legacyInstance(telemeterProduction, true, ComponentTelemeterServer), "rhobs-slos-telemeter-production", genPyrra, genRules)
+	envSLOs(legacyInstance(telemeterStaging, true, ComponentTelemeterServer), "rhobs-slos-telemeter-stage", genPyrra, genRules)
+	envSLOs(legacyInstance(mstProduction, true), "rhobs-slos-mst-production", genPyrra, genRules)
+	envSLOs(legacyInstance(mstStage, true), "rhobs-slos-mst-stage", genPyrra, genRules)
+}
+
+// envSLOs generates the resultant config for a particular rhobsInstanceEnv.
+// We do not want to page on SLO alerts of these environments, their rules are all non-critical.
+// Ticket: https://issues.redhat.com/browse/RHOBS-781
+func envSLOs(i Instance, ruleFilename string, genPyrra, genRules *mimic.Generator) {
+	objs := i.Objectives()
+	AddObjectives(genPyrra, i, objs)
+
+	groups, err := RuleGroups(objs, true)
+	mimic.PanicOnErr(err)
+
+	// We add "" to encoding as first arg, so that we get a YAML doc directive
+	// at the start of the file as per app-interface format.
+	genRules.Add(ruleFilename+".prometheusrules.yaml", encoding.GhodssYAML("", appInterfacePrometheusRule{
+		Schema: "/openshift/prometheus-rule-1.yml",
+		PrometheusRule: monitoringv1.PrometheusRule{
+			TypeMeta: promRuleTypeMeta,
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   ruleFilename,
+				Labels: ruleFileLabels,
+			},
+			Spec: monitoringv1.PrometheusRuleSpec{
+				Groups: groups,
+			},
+		},
+	}))
+}
+
+// AddObjectives adds a file per Pyrra objective of the instance to the generator.
+func AddObjectives(gen *mimic.Generator, i Instance, objs []pyrrav1alpha1.ServiceLevelObjective) {
+	for _, obj := range objs {
+		gen.Add(i.Name+"-"+obj.ObjectMeta.Name+".yaml", encoding.GhodssYAML(obj))
+	}
+}
@@ -0,0 +1,320 @@
+// Package slo declares the RHOBS SLOs once per signal and component, and instantiates them for every RHOBS
+// deployment as Pyrra objectives and the Prometheus rules generated from them.
+package slo
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"strings"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	pyrrav1alpha1 "github.com/pyrra-dev/pyrra/kubernetes/api/v1alpha1"
+	"github.com/pyrra-dev/pyrra/slo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	cfgobservatorium "github.com/rhobs/configuration/configuration/observatorium"
+)
+
+const (
+	globalSLOWindowDuration       = "28d" // Window over which all RHOBS SLOs are calculated.
+	globalSLOLatencyTargetPercent = "90"  // The Latency Target percentage for RHOBS latency SLOs.
+	genericSLOLatencySeconds      = "5"   // Latency request duration to measure percentile target (this is diff for query SLOs).
+
+	runbookBaseURL = "https://github.com/rhobs/configuration/blob/main/docs/sop/observatorium.md"
+)
+
+var (
+	// Reusable k8s type metas.
+	pyrraTypeMeta = metav1.TypeMeta{
+		Kind:       "ServiceLevelObjective",
+		APIVersion: pyrrav1alpha1.GroupVersion.Version,
+	}
+)
+
+// sloType indicates the type of a particular SLO in rhobsSLO shorthand.
+type sloType string
+
+const (
+	// Pyrra Latency SLO, calculated as percentile ratio of successful requests
+	// in a latency bucket by total successful requests. For example, p90 of
+	// # of http requests with 2xx response code, under 5s / # of http requests with 2xx.
+	sloTypeLatency sloType = "latency"
+
+	// Pyrra Availablity SLO, calculated as the inverse percentage ratio of errors by total
+	// requests. For example, (1 - # of http requests with 5xx response code / # of http requests) * 100.
+	sloTypeAvailability sloType = "availability"
+
+	// Pyrra Bool Gauge SLO, calculated as the percentage of samples of a gauge that are 1.
+	// For example, the ratio of successful probes over all probes. The successOrErrorsExpr is the gauge.
+	sloTypeBoolGauge sloType = "bool_gauge"
+)
+
+// Component is a RHOBS component measured by SLOs.
+type Component string
+
+const (
+	// ComponentGateway is the Observatorium API serving the signals.
+	ComponentGateway Component = "gateway"
+	// ComponentGatewayRules is the rules API of the Observatorium API.
+	ComponentGatewayRules Component = "gateway-rules"
+	// ComponentRuleQuery is the Thanos Query dedicated to rule evaluation.
+	ComponentRuleQuery Component = "rule-query"
+	// ComponentRuler is the Thanos Ruler sending alerts to Alertmanager.
+	ComponentRuler Component = "ruler"
+	// ComponentRuleSyncer reloads the Thanos Ruler with the tenant rules.
+	ComponentRuleSyncer Component = "rule-syncer"
+	// ComponentAlertmanager delivers the tenant alerts.
+	ComponentAlertmanager Component = "alertmanager"
+	// ComponentUp runs the observatorium/up synthetic queries the read latency SLOs are measured on.
+	ComponentUp Component = "up"
+	// ComponentTelemeterServer and ComponentRhelemeterServer are the telemeter servers receiving from the clusters.
+	ComponentTelemeterServer  Component = "telemeter-server"
+	ComponentRhelemeterServer Component = "rhelemeter-server"
+	// ComponentLokiDistributor and ComponentLokiQueryFrontend are the LokiStack components behind the logs API.
+	ComponentLokiDistributor   Component = "loki-distributor"
+	ComponentLokiQueryFrontend Component = "loki-query-frontend"
+	// ComponentSyntheticsAPI manages the probes.
+	ComponentSyntheticsAPI Component = "synthetics-api"
+	// ComponentProber is the blackbox exporter running the probes the synthetics API manages.
+	ComponentProber Component = "prober"
+)
+
+// selector is replaced in the expressions of an SLO by the label matchers selecting its component in the instance.
+const selector = "__selector__"
+
+// rhobsSLO is a shorthand struct to declare an SLO of a component.
+type rhobsSLO struct {
+	name      string
+	signal    cfgobservatorium.Resource
+	component Component
+	// service is the service label of the SLO, the instance's service when empty.
+	service     string
+	labels      map[string]string
+	description string
+	// summary is the message of the SLO alerts, the description when empty.
+	summary string
+	// metricService is set when the SLO metrics carry a service label of their own, such as the Alertmanager's.
+	// Legacy instances label these objectives with a plain service label, which Pyrra does not propagate.
+	metricService       bool
+	successOrErrorsExpr string
+	totalExpr           string
+	alertName           string
+	sloType             sloType
+}
+
+// Instance is a deployment of RHOBS the SLOs are instantiated for.
+type Instance struct {
+	// Name prefixes the files of the Pyrra objectives of the instance.
+	Name string
+	// Service is the service label of the instance's SLOs.
+	Service string
+	// Signals are the signals served by the instance.
+	Signals []cfgobservatorium.Resource
+	// Components are the label matchers selecting each component the instance runs.
+	// SLOs of the components left out are not instantiated.
+	Components map[Component]string
+	// AvailabilityTarget is the target percentage of the availability SLOs.
+	AvailabilityTarget string
+	// Labels are added to every SLO of the instance's service.
+	Labels map[string]string
+	// Dashboard is the Grafana dashboard linked by the SLO alerts.
+	Dashboard string
+	// RunbookURL is the document linked by the SLO alerts, at the section named after each alert.
+	RunbookURL string
+	// Legacy generates the objectives as the environments deployed before the registered clusters always had them:
+	// the alerts carry the description as their only message, and metricService SLOs keep a plain service label.
+	Legacy bool
+}
+
+// Objectives returns the Pyrra objectives of every signal served by the instance.
+func (i Instance) Objectives() []pyrrav1alpha1.ServiceLevelObjective {
+	objectives := []pyrrav1alpha1.ServiceLevelObjective{}
+	for _, signal := range i.Signals {
+		objectives = append(objectives, i.ObservatoriumSLOs(signal)...)
+	}
+	return objectives
+}
+
+// ObservatoriumSLOs returns the Pyrra objectives of the signal, for the components the instance runs.
+//
+// This set of SLOs are driven by the RHOBS Service Level Objectives document
+// https://docs.google.com/document/d/1wJjcpgg-r8rlnOtRiqWGv0zwr1MB6WwkQED1XDWXVQs/edit
+func (i Instance) ObservatoriumSLOs(signal cfgobservatorium.Resource) []pyrrav1alpha1.ServiceLevelObjective {
+	objectives := []pyrrav1alpha1.ServiceLevelObjective{}
+	for _, s := range rhobsSLOs {
+		sel, ok := i.Components[s.component]
+		if s.signal != signal || !ok {
+			continue
+		}
+		objectives = append(objectives, i.objective(s, sel))
+	}
+	return objectives
+}
+
+// objective returns the Pyrra objective of the SLO, its component selected by sel.
+func (i Instance) objective(s rhobsSLO, sel string) pyrrav1alpha1.ServiceLevelObjective {
+	labels := map[string]string{}
+	if s.service == "" {
+		maps.Copy(labels, i.Labels)
+	}
+	maps.Copy(labels, s.labels)
+
+	annotations := map[string]string{
+		slo.PropagationLabelsPrefix + "dashboard": i.Dashboard,
+		slo.PropagationLabelsPrefix + "runbook":   fmt.Sprintf("%s#%s", i.RunbookURL, s.alertName),
+	}
+	if i.Legacy {
+		annotations[slo.PropagationLabelsPrefix+"message"] = s.description
+	} else {
+		annotations[slo.PropagationLabelsPrefix+"description"] = s.description
+		annotations[slo.PropagationLabelsPrefix+"summary"] = cmp.Or(s.summary, s.description)
+	}
+
+	if i.Legacy && s.metricService {
+		labels["service"] = cmp.Or(s.service, i.Service)
+	} else {
+		labels[slo.PropagationLabelsPrefix+"service"] = cmp.Or(s.service, i.Service)
+	}
+
+	objective := pyrrav1alpha1.ServiceLevelObjective{
+		TypeMeta: pyrraTypeMeta,
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        s.name,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		Spec: pyrrav1alpha1.ServiceLevelObjectiveSpec{
+			Description: s.description,
+			Window:      globalSLOWindowDuration,
+			Alerting: pyrrav1alpha1.Alerting{
+				Name: s.alertName,
+			},
+		},
+	}
+
+	successOrErrors := pyrrav1alpha1.Query{Metric: strings.ReplaceAll(s.successOrErrorsExpr, selector, sel)}
+	total := pyrrav1alpha1.Query{Metric: strings.ReplaceAll(s.totalExpr, selector, sel)}
+	switch s.sloType {
+	case sloTypeAvailability:
+		objective.Spec.Target = i.AvailabilityTarget
+		objective.Spec.ServiceLevelIndicator = pyrrav1alpha1.ServiceLevelIndicator{
+			Ratio: &pyrrav1alpha1.RatioIndicator{
+				Errors: successOrErrors,
+				Total:  total,
+			},
+		}
+	case sloTypeBoolGauge:
+		objective.Spec.Target = i.AvailabilityTarget
+		objective.Spec.ServiceLevelIndicator = pyrrav1alpha1.ServiceLevelIndicator{
+			BoolGauge: &pyrrav1alpha1.BoolGaugeIndicator{
+				Query: successOrErrors,
+			},
+		}
+	default:
+		objective.Spec.Target = globalSLOLatencyTargetPercent
+		objective.Spec.ServiceLevelIndicator = pyrrav1alpha1.ServiceLevelIndicator{
+			Latency: &pyrrav1alpha1.LatencyIndicator{
+				Success: successOrErrors,
+				Total:   total,
+			},
+		}
+	}
+	return objective
+}
+
+// RuleGroups returns the recording and alerting rules of the objectives, with the AppSRE customizations.
+// Non-critical rules lower the severity of every alert, so that they do not page.
+//
+// Adapted from https://github.com/pyrra-dev/pyrra/blob/v0.5.3/kubernetes/controllers/servicelevelobjective.go#L207
+// Ideally, this can be done via pyrra generate command somehow. Upstream PR: https://github.com/pyrra-dev/pyrra/pull/620
+// However even with CLI we might need to generate in specific format, and group together SLO rules in different ways.
+func RuleGroups(objs []pyrrav1alpha1.ServiceLevelObjective, nonCritical bool) ([]monitoringv1.RuleGroup, error) {
+	grp := []monitoringv1.RuleGroup{}
+	for _, obj := range objs {
+		objective, err := obj.Internal()
+		if err != nil {
+			return nil, fmt.Errorf("objective %s: %w", obj.Name, err)
+		}
+
+		increases, err := objective.IncreaseRules()
+		if err != nil {
+			return nil, fmt.Errorf("objective %s: %w", obj.Name, err)
+		}
+		burnrates, err := objective.Burnrates()
+		if err != nil {
+			return nil, fmt.Errorf("objective %s: %w", obj.Name, err)
+		}
+		generic, err := objective.GenericRules()
+		if err != nil {
+			return nil, fmt.Errorf("objective %s: %w", obj.Name, err)
+		}
+
+		groups := []monitoringv1.RuleGroup{increases, burnrates, generic}
+		for i := range groups {
+			for j := range groups[i].Rules {
+				customizeRule(&groups[i].Rules[j], cmp.Or(obj.Labels[slo.PropagationLabelsPrefix+"service"], obj.Labels["service"]), nonCritical)
+			}
+		}
+		grp = append(grp, groups...)
+	}
+	return grp, nil
+}
+
+// customizeRule applies the AppSRE customizations to a rule of an SLO of the service.
+func customizeRule(rule *monitoringv1.Rule, service string, nonCritical bool) {
+	if rule.Alert != "" {
+		// Prune certain alert labels.
+		delete(rule.Labels, "le")
+		delete(rule.Labels, "client")
+		delete(rule.Labels, "container")
+
+		// Alerts are routed on the service of the SLO, not on the service label of the measured metrics,
+		// such as the Alertmanager's.
+		if _, ok := rule.Labels["service"]; ok {
+			rule.Labels["service"] = service
+		}
+	}
+
+	// Make long/short labels more descriptive.
+	if v, ok := rule.Labels["long"]; ok {
+		rule.Labels["long_burnrate_window"] = v
+		delete(rule.Labels, "long")
+	}
+	if v, ok := rule.Labels["short"]; ok {
+		rule.Labels["short_burnrate_window"] = v
+		delete(rule.Labels, "short")
+	}
+
+	// We do not want to send noise to app-sre Slack when the SLOMetricAbsent alert
+	// fires, as the metrics we use for the SLOs are sometimes unitialized for a long time.
+	// For example, some SLOS on API endpoints with low traffic sometimes trigger this.
+	if rule.Alert == "SLOMetricAbsent" {
+		rule.Labels["severity"] = "medium"
+	} else if v, ok := rule.Labels["severity"]; ok && nonCritical {
+		switch v {
+		case "critical":
+			rule.Labels["severity"] = "high"
+		case "warning":
+			rule.Labels["severity"] = "medium"
+		}
+	}
+
+	// Usually summary + description is the best practice, but this is so that we are compatible with app-interface schema.
+	// To be removed once https://issues.redhat.com/browse/APPSRE-7834 is closed
+	if summary := rule.Annotations["summary"]; summary != "" {
+		rule.Annotations["message"] = summary
+		delete(rule.Annotations, "summary")
+	}
+}
+
+// grafanaLink returns the link to an AppSRE Grafana SLO dashboard.
+func grafanaLink(id, slug, datasource, namespace string) string {
+	return fmt.Sprintf(
+		"https://grafana.app-sre.devshift.net/d/%s/%s-slos?orgId=1&refresh=10s&var-datasource=%s&var-namespace=%s&var-job=All&var-pod=All&var-interval=5m",
+		id,
+		slug,
+		datasource,
+		namespace,
+	)
+}
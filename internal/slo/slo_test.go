@@ -0,0 +1,237 @@
+package slo
+
+import (
+	"testing"
+	"time"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	pyrrav1alpha1 "github.com/pyrra-dev/pyrra/kubernetes/api/v1alpha1"
+
+	cfgobservatorium "github.com/rhobs/configuration/configuration/observatorium"
+	"github.com/rhobs/configuration/internal/ruletest"
+)
+
+// testInstance serves every signal through the gateway, the Loki distributor and the prober.
+var testInstance = Instance{
+	Name:    "test",
+	Service: "rhobs.regional",
+	Signals: []cfgobservatorium.Resource{cfgobservatorium.MetricsResource, cfgobservatorium.LogsResource, cfgobservatorium.ProbesResource},
+	Components: map[Component]string{
+		ComponentGateway:         `job="rhobs-gateway", namespace="rhobs"`,
+		ComponentLokiDistributor: `job="distributor", namespace="rhobs"`,
+		ComponentProber:          `job=~"probe/rhobs/.+"`,
+	},
+	AvailabilityTarget: "99.9",
+	RunbookURL:         runbookBaseURL,
+}
+
+// objectiveRule returns the rules of the named objective of the test instance.
+func objectiveRule(t *testing.T, name string, nonCritical bool) *monitoringv1.PrometheusRule {
+	t.Helper()
+
+	for _, obj := range testInstance.Objectives() {
+		if obj.Name != name {
+			continue
+		}
+		groups, err := RuleGroups([]pyrrav1alpha1.ServiceLevelObjective{obj}, nonCritical)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &monitoringv1.PrometheusRule{Spec: monitoringv1.PrometheusRuleSpec{Groups: groups}}
+	}
+	t.Fatalf("no objective %s", name)
+	return nil
+}
+
+func TestWriteAvailabilitySLO(t *testing.T) {
+	writes := func(errors string) []ruletest.Series {
+		return []ruletest.Series{
+			{Series: `http_requests_total{job="rhobs-gateway", namespace="rhobs", handler="receive", group="metricsv1", code="200"}`, Values: "0+10x30"},
+			{Series: `http_requests_total{job="rhobs-gateway", namespace="rhobs", handler="receive", group="metricsv1", code="500"}`, Values: errors},
+		}
+	}
+	burning := func(severity string) ruletest.Alert {
+		return ruletest.Alert{Labels: map[string]string{
+			"exhaustion":            "2d",
+			"group":                 "metricsv1",
+			"handler":               "receive",
+			"job":                   "rhobs-gateway",
+			"long_burnrate_window":  "1h",
+			"namespace":             "rhobs",
+			"service":               "rhobs.regional",
+			"severity":              severity,
+			"short_burnrate_window": "5m",
+			"slo":                   "api-metrics-write-availability-slo",
+		}}
+	}
+
+	ruletest.Run(t, objectiveRule(t, "api-metrics-write-availability-slo", false),
+		ruletest.Test{
+			Name:  "no failing writes",
+			Input: writes("0x30"),
+			Alerts: []ruletest.AlertTest{
+				{At: 10 * time.Minute, Name: "APIMetricsWriteAvailabilityErrorBudgetBurning"},
+				{At: 10 * time.Minute, Name: "SLOMetricAbsent"},
+			},
+		},
+		ruletest.Test{
+			Name:  "half of the writes failing",
+			Input: writes("0+10x30"),
+			Exprs: []ruletest.ExprTest{
+				{
+					At:   10 * time.Minute,
+					Expr: `http_requests:burnrate5m`,
+					Expected: []ruletest.Sample{
+						{
+							Labels: map[string]string{
+								"__name__":  "http_requests:burnrate5m",
+								"group":     "metricsv1",
+								"handler":   "receive",
+								"job":       "rhobs-gateway",
+								"namespace": "rhobs",
+								"service":   "rhobs.regional",
+								"slo":       "api-metrics-write-availability-slo",
+							},
+							Value: 0.5,
+						},
+					},
+				},
+			},
+			Alerts: []ruletest.AlertTest{
+				// The fastest burn rate alert is pending for 2m.
+				{At: time.Minute, Name: "APIMetricsWriteAvailabilityErrorBudgetBurning"},
+				{At: 10 * time.Minute, Name: "APIMetricsWriteAvailabilityErrorBudgetBurning", Firing: []ruletest.Alert{burning("critical")}},
+			},
+		},
+		ruletest.Test{
+			Name: "no writes",
+			Alerts: []ruletest.AlertTest{
+				{
+					At:   5 * time.Minute,
+					Name: "SLOMetricAbsent",
+					Firing: []ruletest.Alert{
+						{Labels: map[string]string{"group": "metricsv1", "handler": "receive", "job": "rhobs-gateway", "namespace": "rhobs", "service": "rhobs.regional", "severity": "medium", "slo": "api-metrics-write-availability-slo"}},
+					},
+				},
+			},
+		},
+	)
+
+	ruletest.Run(t, objectiveRule(t, "api-metrics-write-availability-slo", true),
+		ruletest.Test{
+			Name:  "non-critical half of the writes failing",
+			Input: writes("0+10x30"),
+			Alerts: []ruletest.AlertTest{
+				{At: 10 * time.Minute, Name: "APIMetricsWriteAvailabilityErrorBudgetBurning", Firing: []ruletest.Alert{burning("high")}},
+			},
+		},
+	)
+}
+
+func TestLokiDistributorPushAvailabilitySLO(t *testing.T) {
+	ruletest.Run(t, objectiveRule(t, "loki-distributor-push-availability-slo", false),
+		ruletest.Test{
+			Name: "half of the pushes failing",
+			Input: []ruletest.Series{
+				{Series: `loki_request_duration_seconds_count{job="distributor", namespace="rhobs", route="loki_api_v1_push", status_code="204"}`, Values: "0+10x30"},
+				{Series: `loki_request_duration_seconds_count{job="distributor", namespace="rhobs", route="loki_api_v1_push", status_code="500"}`, Values: "0+10x30"},
+				// Other routes are not part of the SLO.
+				{Series: `loki_request_duration_seconds_count{job="distributor", namespace="rhobs", route="ready", status_code="200"}`, Values: "0+100x30"},
+			},
+			Exprs: []ruletest.ExprTest{
+				{
+					At:   10 * time.Minute,
+					Expr: `loki_request_duration_seconds:burnrate5m`,
+					Expected: []ruletest.Sample{
+						{
+							Labels: map[string]string{
+								"__name__":  "loki_request_duration_seconds:burnrate5m",
+								"job":       "distributor",
+								"namespace": "rhobs",
+								"route":     "loki_api_v1_push",
+								"service":   "rhobs.regional",
+								"slo":       "loki-distributor-push-availability-slo",
+							},
+							Value: 0.5,
+						},
+					},
+				},
+			},
+			Alerts: []ruletest.AlertTest{
+				{
+					At:   10 * time.Minute,
+					Name: "LokiDistributorPushAvailabilityErrorBudgetBurning",
+					Firing: []ruletest.Alert{
+						{
+							Labels: map[string]string{
+								"exhaustion":            "2d",
+								"job":                   "distributor",
+								"long_burnrate_window":  "1h",
+								"namespace":             "rhobs",
+								"route":                 "loki_api_v1_push",
+								"service":               "rhobs.regional",
+								"severity":              "critical",
+								"short_burnrate_window": "5m",
+								"slo":                   "loki-distributor-push-availability-slo",
+							},
+							Annotations: map[string]string{
+								"dashboard":   "",
+								"description": "Loki distributor push route is burning too much error budget to guarantee availability SLOs.",
+								"message":     "Loki distributor push route is burning too much error budget to guarantee availability SLOs.",
+								"runbook":     runbookBaseURL + "#LokiDistributorPushAvailabilityErrorBudgetBurning",
+							},
+						},
+					},
+				},
+			},
+		},
+	)
+}
+
+func TestSyntheticsProbesSuccessSLO(t *testing.T) {
+	ruletest.Run(t, objectiveRule(t, "synthetics-probes-success-slo", false),
+		ruletest.Test{
+			Name: "succeeding probes",
+			Input: []ruletest.Series{
+				{Series: `probe_success{job="probe/rhobs/console", instance="https://console.example.com"}`, Values: "1x30"},
+			},
+			Alerts: []ruletest.AlertTest{
+				{At: 10 * time.Minute, Name: "SyntheticsProbesSuccessErrorBudgetBurning"},
+				{At: 10 * time.Minute, Name: "SLOMetricAbsent"},
+			},
+		},
+		ruletest.Test{
+			Name: "failing probe",
+			Input: []ruletest.Series{
+				{Series: `probe_success{job="probe/rhobs/console", instance="https://console.example.com"}`, Values: "0x30"},
+				// Probes out of the synthetics API are not part of the SLO.
+				{Series: `probe_success{job="blackbox", instance="https://other.example.com"}`, Values: "1x30"},
+			},
+			Exprs: []ruletest.ExprTest{
+				{
+					At:   10 * time.Minute,
+					Expr: `probe_success:burnrate5m`,
+					Expected: []ruletest.Sample{
+						{Labels: map[string]string{"__name__": "probe_success:burnrate5m", "service": "rhobs.regional", "slo": "synthetics-probes-success-slo"}, Value: 1},
+					},
+				},
+			},
+			Alerts: []ruletest.AlertTest{
+				{
+					At:   10 * time.Minute,
+					Name: "SyntheticsProbesSuccessErrorBudgetBurning",
+					Firing: []ruletest.Alert{
+						{Labels: map[string]string{
+							"exhaustion":            "2d",
+							"long_burnrate_window":  "1h",
+							"service":               "rhobs.regional",
+							"severity":              "critical",
+							"short_burnrate_window": "5m",
+							"slo":                   "synthetics-probes-success-slo",
+						}},
+					},
+				},
+			},
+		},
+	)
+}
@@ -5,17 +5,15 @@ import (
 	"log"
 	"maps"
 	"os"
+	"slices"
 	"time"
 
 	"github.com/bwplotka/mimic"
-	"github.com/bwplotka/mimic/encoding"
 	kitlog "github.com/go-kit/log"
 	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/alertmanager"
 	kghelpers "github.com/observatorium/observatorium/configuration_go/kubegen/helpers"
-	"github.com/observatorium/observatorium/configuration_go/kubegen/openshift"
 	"github.com/observatorium/observatorium/configuration_go/kubegen/workload"
 	routev1 "github.com/openshift/api/route/v1"
-	templatev1 "github.com/openshift/api/template/v1"
 	monv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	"github.com/rhobs/configuration/clusters"
 	appsv1 "k8s.io/api/apps/v1"
@@ -52,85 +50,47 @@ func (b Build) Alertmanager(config clusters.ClusterConfig) {
 		return
 	}
 
-	gen := b.generator(config, "alertmanager")
-
-	// TODO: @moadz Extract Alertmanager options to an envTemplate in template.go
-	k8s := alertmanagerKubernetes(alertManagerOptions(), manifestOptions{
-		namespace: config.Namespace,
-		image:     defaultAlertManagerImage,
-		imageTag:  defaultAlertManagerImageTag,
-		resourceRequirements: resourceRequirements{
-			cpuRequest:    defaultAlertmanagerCPURequest,
-			cpuLimit:      defaultAlertmanagerCPULimit,
-			memoryRequest: defaultAlertmanagerMemoryRequest,
-			memoryLimit:   defaultAlertmanagerMemoryLimit,
-		},
-	})
-	buildAlertmanager(k8s.Objects(), config.Namespace, gen)
+	buildAlertmanager(config.Namespace, b.generator(config, "alertmanager"))
 }
 
 // Alertmanager Generates the Alertmanager configuration for the stage environment.
 func (s Stage) Alertmanager() {
-	gen := s.generator(alertManagerName)
-
-	const (
-		alertManagerImageTag = defaultAlertManagerImageTag
-
-		cpuRequest = defaultAlertmanagerCPURequest
-		cpuLimit   = defaultAlertmanagerCPULimit
-		memRequest = defaultAlertmanagerMemoryRequest
-		memLimit   = defaultAlertmanagerMemoryLimit
-	)
-
-	k8s := alertmanagerKubernetes(alertManagerOptions(), manifestOptions{
-		namespace: s.namespace(),
-		image:     defaultAlertManagerImage,
-		imageTag:  alertManagerImageTag,
-		resourceRequirements: resourceRequirements{
-			cpuRequest:    cpuRequest,
-			cpuLimit:      cpuLimit,
-			memoryRequest: memRequest,
-			memoryLimit:   memLimit,
-		},
-	})
-	buildAlertmanager(k8s.Objects(), s.namespace(), gen)
+	buildAlertmanager(s.namespace(), s.generator(alertManagerName))
 }
 
 // Alertmanager Generates the Alertmanager configuration for the production environment.
 func (p Production) Alertmanager() {
-	gen := p.generator(alertManagerName)
-
-	const (
-		alertManagerImageTag = defaultAlertManagerImageTag
+	buildAlertmanager(p.namespace(), p.generator(alertManagerName))
+}
 
-		cpuRequest = defaultAlertmanagerCPURequest
-		cpuLimit   = defaultAlertmanagerCPULimit
-		memRequest = defaultAlertmanagerMemoryRequest
-		memLimit   = defaultAlertmanagerMemoryLimit
-	)
+// buildAlertmanager generates the Alertmanager template, and the template of its ServiceMonitor
+func buildAlertmanager(namespace string, generator *mimic.Generator) {
+	manifests, sm := alertmanagerObjects(namespace, false)
+	newTemplateRenderer(alertManagerName, alertmanagerTemplate).render(generator, manifests)
+	generator.Add(serviceMonitorTemplate, postProcessServiceMonitor(sm, namespace))
+	generator.Generate()
+}
 
+// alertmanagerObjects returns the Alertmanager resources of every output, and its ServiceMonitor.
+// lokiRuler names the API port of the headless service for the Loki ruler to discover the replicas.
+// TODO: @moadz Extract Alertmanager options to an envTemplate in template.go
+func alertmanagerObjects(namespace string, lokiRuler bool) ([]runtime.Object, *monv1.ServiceMonitor) {
 	k8s := alertmanagerKubernetes(alertManagerOptions(), manifestOptions{
-		namespace: p.namespace(),
+		namespace: namespace,
 		image:     defaultAlertManagerImage,
-		imageTag:  alertManagerImageTag,
+		imageTag:  defaultAlertManagerImageTag,
 		resourceRequirements: resourceRequirements{
-			cpuRequest:    cpuRequest,
-			cpuLimit:      cpuLimit,
-			memoryRequest: memRequest,
-			memoryLimit:   memLimit,
+			cpuRequest:    defaultAlertmanagerCPURequest,
+			cpuLimit:      defaultAlertmanagerCPULimit,
+			memoryRequest: defaultAlertmanagerMemoryRequest,
+			memoryLimit:   defaultAlertmanagerMemoryLimit,
 		},
 	})
-	buildAlertmanager(k8s.Objects(), p.namespace(), gen)
-}
 
-func buildAlertmanager(manifests []runtime.Object, namespace string, generator *mimic.Generator) {
 	var sm *monv1.ServiceMonitor
+	manifests := k8s.Objects()
 	sm, manifests = getAndRemoveObject[*monv1.ServiceMonitor](manifests, "")
-	smEnc := postProcessServiceMonitor(sm, namespace)
-	enc := alertmanagerPostProcess(manifests, namespace)
-	generator.Add(alertmanagerTemplate, enc)
-	generator.Add(serviceMonitorTemplate, smEnc)
-	generator.Generate()
+	return alertmanagerPostProcess(manifests, namespace, lokiRuler), sm
 }
 
 func alertManagerOptions() *alertmanager.AlertManagerOptions {
@@ -164,107 +124,15 @@ func alertmanagerKubernetes(opts *alertmanager.AlertManagerOptions, options mani
 	return alertmanSts
 }
 
-func alertmanagerPostProcess(manifests []runtime.Object, namespace string) encoding.Encoder {
-	service := kghelpers.GetObject[*corev1.Service](manifests, alertManagerName)
-	service.ObjectMeta.Annotations[servingCertSecretNameAnnotation] = alertmanagerTLSSecret
-	service.Spec.Ports = append(service.Spec.Ports, corev1.ServicePort{
-		Name:       "https",
-		Port:       8443,
-		TargetPort: intstr.FromInt32(8443),
-	})
-	// Add annotations for openshift oauth so that the route to access the query ui works
-	serviceAccount := kghelpers.GetObject[*corev1.ServiceAccount](manifests, "")
-	if serviceAccount.Annotations == nil {
-		serviceAccount.Annotations = map[string]string{}
-	}
-	serviceAccount.Annotations[serviceRedirectAnnotation] = fmt.Sprintf(`{"kind":"OAuthRedirectReference","apiVersion":"v1","reference":{"kind":"Route","name":"%s"}}`, alertManagerName)
-
-	// Add route for oauth-proxy
-	manifests = append(manifests, &routev1.Route{
-		TypeMeta: metav1.TypeMeta{
-			Kind:       "Route",
-			APIVersion: routev1.SchemeGroupVersion.String(),
-		},
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      alertManagerName,
-			Namespace: namespace,
-			Labels:    maps.Clone(kghelpers.GetObject[*appsv1.StatefulSet](manifests, "").ObjectMeta.Labels),
-			Annotations: map[string]string{
-				"cert-manager.io/issuer-kind": "ClusterIssuer",
-				"cert-manager.io/issuer-name": "letsencrypt-prod-http",
-			},
-		},
-		Spec: routev1.RouteSpec{
-			Port: &routev1.RoutePort{
-				TargetPort: intstr.FromString("https"),
-			},
-			TLS: &routev1.TLSConfig{
-				Termination:                   routev1.TLSTerminationReencrypt,
-				InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyRedirect,
-			},
-			To: routev1.RouteTargetReference{
-				Kind: "Service",
-				Name: alertManagerName,
-			},
-		},
-	})
-
-	return encoding.GhodssYAML(
-		openshift.WrapInTemplate(
-			manifests,
-			metav1.ObjectMeta{Name: alertManagerName},
-			[]templatev1.Parameter{
-				{
-					Name:     "OAUTH_PROXY_COOKIE_SECRET",
-					Generate: "expression",
-					From:     `[a-zA-Z0-9]{40}`,
-				},
-			},
-		))
-}
-
 // generateAlertmanagerBundleFromTemplate generates individual alertmanager component resources for bundle deployment
-// This function reuses the existing template-based alertmanager generation but outputs individual files instead
 func generateAlertmanagerBundleFromTemplate(config clusters.ClusterConfig) error {
-	ns := config.Namespace
-
 	// Create bundle generator for individual resource files
 	bundleGen := &mimic.Generator{}
 	bundleGen = bundleGen.With("resources", "clusters", string(config.Environment), string(config.Name), "alertmanager", "bundle")
 	bundleGen.Logger = kitlog.NewLogfmtLogger(kitlog.NewSyncWriter(os.Stdout))
 
-	// Generate alertmanager objects using existing template logic
-	k8s := alertmanagerKubernetes(alertManagerOptions(), manifestOptions{
-		namespace: ns,
-		image:     defaultAlertManagerImage,
-		imageTag:  defaultAlertManagerImageTag,
-		resourceRequirements: resourceRequirements{
-			cpuRequest:    defaultAlertmanagerCPURequest,
-			cpuLimit:      defaultAlertmanagerCPULimit,
-			memoryRequest: defaultAlertmanagerMemoryRequest,
-			memoryLimit:   defaultAlertmanagerMemoryLimit,
-		},
-	})
-
-	manifests := k8s.Objects()
-
-	// Extract and handle ServiceMonitor separately
-	var sm *monv1.ServiceMonitor
-	sm, manifests = getAndRemoveObject[*monv1.ServiceMonitor](manifests, "")
-
-	// Apply the same post-processing that the template version uses
-	// This adds the Route and modifies the ServiceAccount with OAuth annotations
-	processedManifests := alertmanagerPostProcessForBundle(manifests, ns)
-
-	// Generate individual resource files for each alertmanager component
-	for i, obj := range processedManifests {
-		resourceKind := getResourceKind(obj)
-		resourceName := getKubernetesResourceName(obj)
-		filename := fmt.Sprintf("%02d-%s-%s.yaml", i+1, resourceName, resourceKind)
-		bundleGen.Add(filename, encoding.GhodssYAML(obj))
-	}
-
-	// Generate the bundle files
+	manifests, sm := alertmanagerObjects(config.Namespace, slices.Contains(config.BuildSteps, clusters.StepLokiTenantRules))
+	numberedBundle.render(bundleGen, manifests)
 	bundleGen.Generate()
 
 	// Add ServiceMonitor to monitoring bundle if it exists
@@ -276,9 +144,8 @@ func generateAlertmanagerBundleFromTemplate(config clusters.ClusterConfig) error
 	return nil
 }
 
-// alertmanagerPostProcessForBundle applies the same post-processing as alertmanagerPostProcess
-// but returns the processed manifests directly for bundle generation instead of wrapping in template
-func alertmanagerPostProcessForBundle(manifests []runtime.Object, namespace string) []runtime.Object {
+// alertmanagerPostProcess exposes the Alertmanager UI behind the oauth-proxy with a re-encrypting Route
+func alertmanagerPostProcess(manifests []runtime.Object, namespace string, lokiRuler bool) []runtime.Object {
 	service := kghelpers.GetObject[*corev1.Service](manifests, alertManagerName)
 	service.ObjectMeta.Annotations[servingCertSecretNameAnnotation] = alertmanagerTLSSecret
 	service.Spec.Ports = append(service.Spec.Ports, corev1.ServicePort{
@@ -287,6 +154,16 @@ func alertmanagerPostProcessForBundle(manifests []runtime.Object, namespace stri
 		TargetPort: intstr.FromInt32(8443),
 	})
 
+	// Name the API port on the headless service, the Loki ruler discovers the replicas through its SRV records
+	if lokiRuler {
+		clusterService := kghelpers.GetObject[*corev1.Service](manifests, alertManagerName+"-cluster")
+		clusterService.Spec.Ports = append(clusterService.Spec.Ports, corev1.ServicePort{
+			Name:       "http",
+			Port:       9093,
+			TargetPort: intstr.FromInt32(9093),
+		})
+	}
+
 	// Add annotations for openshift oauth so that the route to access the query ui works
 	serviceAccount := kghelpers.GetObject[*corev1.ServiceAccount](manifests, "")
 	if serviceAccount.Annotations == nil {
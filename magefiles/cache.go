@@ -2,13 +2,22 @@ package main
 
 import (
 	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
 
 	"github.com/rhobs/configuration/clusters"
 
 	"github.com/bwplotka/mimic"
 	"github.com/bwplotka/mimic/encoding"
 	"github.com/observatorium/observatorium/configuration_go/kubegen/openshift"
+	templatev1 "github.com/openshift/api/template/v1"
 	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/thanos-community/thanos-operator/api/v1alpha1"
+	"github.com/thanos-io/thanos/pkg/cacheutil"
+	"github.com/thanos-io/thanos/pkg/queryfrontend"
+	storecache "github.com/thanos-io/thanos/pkg/store/cache"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -371,9 +380,241 @@ func (b Build) Cache(config clusters.ClusterConfig) {
 	}
 	caches := []*memcachedConfig{
 		gatewayCache(clusters.ProductionMaps, ns),
-		indexCache(clusters.ProductionMaps, ns),
-		bucketCache(clusters.ProductionMaps, ns),
-		queryRangeCache(clusters.ProductionMaps, ns),
 	}
+	caches = append(caches, thanosMemcachedCaches(config, clusters.ProductionMaps)...)
 	cache(gen, clusters.ProductionMaps, caches)
 }
+
+// thanosCacheRoles are the cache roles of the Thanos components, in the order their objects are generated
+var thanosCacheRoles = []clusters.CacheRole{
+	clusters.CacheRoleIndex,
+	clusters.CacheRoleBucket,
+	clusters.CacheRoleQueryRange,
+}
+
+// thanosCacheSecretName is the name of the secret holding the Thanos cache configuration of the role on the backend
+func thanosCacheSecretName(role clusters.CacheRole, backend clusters.CacheBackend) string {
+	return fmt.Sprintf("thanos-%s-cache-%s", role, backend)
+}
+
+// thanosMemcachedCaches returns the in-cluster memcached of the Thanos cache roles running on memcached
+func thanosMemcachedCaches(config clusters.ClusterConfig, m clusters.TemplateMaps) []*memcachedConfig {
+	var caches []*memcachedConfig
+	for _, role := range thanosCacheRoles {
+		if config.Cache(role).Backend != clusters.CacheBackendMemcached {
+			continue
+		}
+		switch role {
+		case clusters.CacheRoleIndex:
+			caches = append(caches, indexCache(m, config.Namespace))
+		case clusters.CacheRoleBucket:
+			caches = append(caches, bucketCache(m, config.Namespace))
+		case clusters.CacheRoleQueryRange:
+			caches = append(caches, queryRangeCache(m, config.Namespace))
+		}
+	}
+	return caches
+}
+
+// thanosCacheSecret renders the Thanos cache configuration of the role in a secret, returning the template parameters
+// it references. In-cluster memcached is discovered through its headless service and needs no parameters.
+// Query range results expire after the results cache TTL of the query frontend policy of the cluster.
+func thanosCacheSecret(config clusters.ClusterConfig, role clusters.CacheRole) (*corev1.Secret, []templatev1.Parameter, error) {
+	cache := config.Cache(role)
+	expiration, err := queryRangeCacheExpiration(config.Templates)
+	if err != nil {
+		return nil, nil, err
+	}
+	var cacheConfig any
+	var params []templatev1.Parameter
+	switch cache.Backend {
+	case clusters.CacheBackendMemcached:
+		cacheConfig = memcachedCacheConfig(role, config.Namespace, expiration)
+	case clusters.CacheBackendRedis:
+		cacheConfig, params = redisCacheConfig(role, cache, expiration)
+	default:
+		panic(fmt.Sprintf("unknown cache backend %q", cache.Backend))
+	}
+
+	b, err := yaml.Marshal(cacheConfig)
+	if err != nil {
+		panic(err)
+	}
+
+	name := thanosCacheSecretName(role, cache.Backend)
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: config.Namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/name": name,
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		StringData: map[string]string{
+			"thanos.yaml": string(b),
+		},
+	}, params, nil
+}
+
+func memcachedCacheConfig(role clusters.CacheRole, namespace string, expiration time.Duration) any {
+	switch role {
+	case clusters.CacheRoleIndex:
+		return storecache.IndexCacheConfig{
+			Type: storecache.MEMCACHED,
+			Config: cacheutil.MemcachedClientConfig{
+				Addresses: []string{
+					"dnssrv+_client._tcp.thanos-index-cache." + namespace + ".svc",
+				},
+				DNSProviderUpdateInterval: 10 * time.Second,
+				MaxAsyncBufferSize:        2500000,
+				MaxAsyncConcurrency:       1000,
+				MaxGetMultiBatchSize:      100000,
+				MaxGetMultiConcurrency:    1000,
+				MaxIdleConnections:        2500,
+				// TODO(saswatamcode): Figure out a way to pass units for gen.
+				// MaxItemSize:               model.Bytes(5 * 1024 * 1024),
+				Timeout: 2 * time.Second,
+			},
+		}
+	case clusters.CacheRoleBucket:
+		return cachingBucketConfig(storecache.MemcachedBucketCacheProvider, cacheutil.MemcachedClientConfig{
+			Addresses: []string{
+				"dnssrv+_client._tcp.thanos-bucket-cache." + namespace + ".svc",
+			},
+			DNSProviderUpdateInterval: 10 * time.Second,
+			MaxAsyncBufferSize:        25000,
+			MaxAsyncConcurrency:       50,
+			MaxGetMultiBatchSize:      100,
+			MaxGetMultiConcurrency:    1000,
+			MaxIdleConnections:        1100,
+			// TODO(saswatamcode): Figure out a way to pass units for gen.
+			// MaxItemSize:               model.Bytes(1 * 1024 * 1024),
+			Timeout: 2 * time.Second,
+		})
+	case clusters.CacheRoleQueryRange:
+		return queryfrontend.CacheProviderConfig{
+			Type: queryfrontend.MEMCACHED,
+			Config: queryfrontend.MemcachedResponseCacheConfig{
+				Memcached: cacheutil.MemcachedClientConfig{
+					Addresses: []string{
+						"dnssrv+_client._tcp.thanos-query-range-cache." + namespace + ".svc",
+					},
+					DNSProviderUpdateInterval: 30 * time.Second,
+					MaxAsyncBufferSize:        1000000,
+					MaxAsyncConcurrency:       100,
+					MaxGetMultiBatchSize:      500,
+					MaxGetMultiConcurrency:    100,
+					MaxIdleConnections:        500,
+					// TODO(saswatamcode): Figure out a way to pass units for gen.
+					// MaxItemSize:               model.Bytes(100 * 1024 * 1024),
+					Timeout: 5 * time.Second,
+				},
+				Expiration: expiration,
+			},
+		}
+	default:
+		panic(fmt.Sprintf("cache role %q has no Thanos cache configuration", role))
+	}
+}
+
+// redisCacheConfig points the role at an external Redis over TLS. Its parameters are prefixed with the role,
+// e.g. INDEX_CACHE_AUTH_TOKEN, as for the Redis caches of the stage environment.
+func redisCacheConfig(role clusters.CacheRole, cache clusters.Cache, expiration time.Duration) (any, []templatev1.Parameter) {
+	p := &templateParams{prefix: strings.ToUpper(strings.ReplaceAll(string(role), "-", "_")) + "_CACHE_"}
+	client := cacheutil.DefaultRedisClientConfig
+	client.Addr = cache.Address
+	if client.Addr == "" {
+		client.Addr = p.param("ADDR") + ":" + p.param("PORT")
+	}
+	client.Password = p.param("AUTH_TOKEN")
+	client.DB = 0
+	client.TLSEnabled = true
+
+	switch role {
+	case clusters.CacheRoleIndex:
+		return storecache.IndexCacheConfig{
+			Type:   storecache.REDIS,
+			Config: client,
+		}, p.params
+	case clusters.CacheRoleBucket:
+		return cachingBucketConfig(storecache.RedisBucketCacheProvider, client), p.params
+	case clusters.CacheRoleQueryRange:
+		return queryfrontend.CacheProviderConfig{
+			Type: queryfrontend.REDIS,
+			Config: queryfrontend.RedisResponseCacheConfig{
+				Redis:      client,
+				Expiration: expiration,
+			},
+		}, p.params
+	default:
+		panic(fmt.Sprintf("cache role %q has no Thanos cache configuration", role))
+	}
+}
+
+func cachingBucketConfig(provider storecache.BucketCacheProvider, backend any) storecache.CachingWithBackendConfig {
+	return storecache.CachingWithBackendConfig{
+		Type:                      provider,
+		BackendConfig:             backend,
+		ChunkSubrangeSize:         16000,
+		ChunkObjectAttrsTTL:       24 * time.Hour,
+		ChunkSubrangeTTL:          24 * time.Hour,
+		MaxChunksGetRangeRequests: 3,
+		// MetafileMaxSize:           model.Bytes(1 * 1024 * 1024),
+		MetafileExistsTTL:      2 * time.Hour,
+		MetafileDoesntExistTTL: 15 * time.Minute,
+		MetafileContentTTL:     24 * time.Hour,
+	}
+}
+
+// thanosCacheReferences points the Thanos custom resources at the cache secrets of the backends selected for the cluster
+func thanosCacheReferences(config clusters.ClusterConfig, objs []runtime.Object) []runtime.Object {
+	ref := func(cacheConfig *v1alpha1.CacheConfig, role clusters.CacheRole) {
+		if cacheConfig == nil || cacheConfig.ExternalCacheConfig == nil {
+			return
+		}
+		cacheConfig.ExternalCacheConfig.Name = thanosCacheSecretName(role, config.Cache(role).Backend)
+	}
+	for _, obj := range objs {
+		switch o := obj.(type) {
+		case *v1alpha1.ThanosStore:
+			ref(o.Spec.IndexCacheConfig, clusters.CacheRoleIndex)
+			ref(o.Spec.CachingBucketConfig, clusters.CacheRoleBucket)
+		case *v1alpha1.ThanosQuery:
+			if o.Spec.QueryFrontend != nil {
+				ref(o.Spec.QueryFrontend.QueryRangeResponseCacheConfig, clusters.CacheRoleQueryRange)
+			}
+		}
+	}
+	return objs
+}
+
+// addCacheSecretTemplate adds the secrets of the Thanos cache roles running on an external backend to gen as an
+// OpenShift template. It returns false if every role runs on in-cluster memcached.
+func addCacheSecretTemplate(gen *mimic.Generator, config clusters.ClusterConfig) (bool, error) {
+	var secrets []runtime.Object
+	var params []templatev1.Parameter
+	for _, role := range thanosCacheRoles {
+		cache := config.Cache(role)
+		if cache.Backend == clusters.CacheBackendMemcached {
+			continue
+		}
+		secret, p, err := thanosCacheSecret(config, role)
+		if err != nil {
+			return false, err
+		}
+		secrets = append(secrets, secret)
+		params = append(params, p...)
+	}
+	if len(secrets) == 0 {
+		return false, nil
+	}
+	gen.Add("thanos-cache-secret-template.yaml", encoding.GhodssYAML(
+		openshift.WrapInTemplate(secrets, metav1.ObjectMeta{Name: "thanos-cache-secret"}, params),
+	))
+	return true, nil
+}
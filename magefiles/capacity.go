@@ -0,0 +1,358 @@
+package main
+
+import (
+	"fmt"
+	"go/format"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	lokiv1 "github.com/grafana/loki/operator/api/loki/v1"
+	"github.com/magefile/mage/mg"
+	"github.com/prometheus/common/model"
+	"github.com/rhobs/configuration/clusters"
+	"github.com/thanos-community/thanos-operator/api/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+type (
+	Plan mg.Namespace
+)
+
+// Sizing model. The figures are rules of thumb from running Thanos and Loki in RHOBS, meant as a starting
+// point for a new cluster and as a floor for an existing one rather than as exact requirements.
+const (
+	gib = 1 << 30
+
+	// receiveReplicationFactor matches the replication factor of the receive router.
+	receiveReplicationFactor = 3
+	// ingesterBytesPerSeries covers the head series, postings and the headroom needed to replay the WAL.
+	ingesterBytesPerSeries = 8 * 1024
+	// ingesterMaxSeries bounds the active series of an ingester so that it restarts in a reasonable time.
+	ingesterMaxSeries      = 2_000_000
+	ingesterSamplesPerCore = 100_000
+	// ingesterRetention matches the default TSDB retention of the hashrings, plus the WAL not yet in blocks.
+	ingesterRetention = 26 * time.Hour
+
+	routerSamplesPerReplica = 250_000
+	routerSamplesPerCore    = 100_000
+	// routerSamplesPerGiB accounts for the requests buffered while they are forwarded to the ingesters.
+	routerSamplesPerGiB = 50_000
+	minRouterReplicas   = 3
+
+	// bytesPerSample is the size of a sample in the blocks, including the index.
+	bytesPerSample = 2
+	// storeBytesPerShard is the amount of blocks a store shard serves before its index headers get too large.
+	storeBytesPerShard = 10 << 40
+	// storeIndexHeaderRatio and storeMemoryRatio relate the blocks of a shard to its disk and memory.
+	storeIndexHeaderRatio = 0.02
+	storeMemoryRatio      = 0.001
+
+	// compactorTenantSkew assumes the largest tenant is this many times larger than the average one.
+	// The compactor needs room for twice the 2w blocks of that tenant.
+	compactorTenantSkew = 3
+	compactorBlockRange = 14 * 24 * time.Hour
+
+	// lokiReplicationFactor is the replication factor of the Loki operator for the 1x sizes.
+	lokiReplicationFactor    = 2
+	lokiIngesterMBPerSecond  = 5
+	lokiRouterMBPerSecond    = 10
+	minLokiIngesterReplicas  = lokiReplicationFactor + 1
+	minLokiRouterReplicas    = 3
+	minComponentStorageBytes = 10 * gib
+	minCompactorStorageBytes = 50 * gib
+)
+
+// capacityInputs is the expected load of a cluster
+type capacityInputs struct {
+	ActiveSeries     int64
+	SamplesPerSecond int64
+	Tenants          int
+	LogsMBPerSecond  float64
+	// Retention is the long-term metrics retention served by the stores
+	Retention model.Duration
+}
+
+// capacityPlan is the sizing derived from capacityInputs, keyed by template component
+type capacityPlan struct {
+	Replicas    map[string]int32
+	Resources   map[string]corev1.ResourceRequirements
+	StorageSize map[string]v1alpha1.StorageSize
+	StoreShards int32
+	// Loki replicas are only planned when logs are ingested.
+	LokiRouterReplicas int32
+	LokiIngestReplicas int32
+}
+
+// lokiPresetReplicas are the distributor and ingester replicas of the sizing presets of the Loki operator, which
+// the components run with unless the cluster sets their replicas.
+var lokiPresetReplicas = map[lokiv1.LokiStackSizeType]struct{ router, ingest int32 }{
+	lokiv1.SizeOneXDemo:       {1, 1},
+	lokiv1.SizeOneXPico:       {2, 3},
+	lokiv1.SizeOneXExtraSmall: {2, 2},
+	lokiv1.SizeOneXSmall:      {2, 2},
+	lokiv1.SizeOneXMedium:     {2, 3},
+}
+
+// capacityComponents are the planned components, in the order they are printed, with their Go identifier in the
+// clusters package.
+var capacityComponents = []struct {
+	key   string
+	ident string
+}{
+	{clusters.ReceiveRouter, "ReceiveRouter"},
+	{clusters.ReceiveIngestorDefault, "ReceiveIngestorDefault"},
+	{clusters.StoreDefault, "StoreDefault"},
+	{clusters.CompactDefault, "CompactDefault"},
+}
+
+// Capacity suggests Thanos and Loki sizing for a cluster from its expected load, printed as a template override to
+// paste into the cluster definition, and warns where the cluster's current settings fall below it.
+// A tenants count of 0 uses the tenants of the cluster's gateway. The retention is the long-term metrics retention.
+// Example: mage plan:capacity rhobsp01ue1 5000000 500000 0 10 365d
+func (Plan) Capacity(cluster string, activeSeries, samplesPerSecond, tenants, logsMBPerSecond int, retention string) error {
+	config, err := clusters.GetClusterByName(clusters.ClusterName(cluster))
+	if err != nil {
+		return err
+	}
+	r, err := model.ParseDuration(retention)
+	if err != nil {
+		return fmt.Errorf("invalid retention: %w", err)
+	}
+	if tenants == 0 && config.GatewayConfig != nil {
+		tenants = len(config.GatewayConfig.Tenants().Tenants)
+	}
+	if activeSeries < 0 || samplesPerSecond < 0 || tenants < 0 || logsMBPerSecond < 0 {
+		return fmt.Errorf("load inputs cannot be negative")
+	}
+
+	in := capacityInputs{
+		ActiveSeries:     int64(activeSeries),
+		SamplesPerSecond: int64(samplesPerSecond),
+		Tenants:          tenants,
+		LogsMBPerSecond:  float64(logsMBPerSecond),
+		Retention:        r,
+	}
+	plan := planCapacity(in)
+
+	src, err := plan.override()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "// Capacity plan for %s: %d active series, %d samples/s, %d tenants, %g MB/s of logs, %s retention\n",
+		config.Name, in.ActiveSeries, in.SamplesPerSecond, in.Tenants, in.LogsMBPerSecond, in.Retention)
+	fmt.Fprintln(os.Stdout, src)
+
+	for _, w := range capacityWarnings(*config, plan) {
+		fmt.Fprintf(os.Stderr, "WARNING: %s: %s\n", config.Name, w)
+	}
+	return nil
+}
+
+func planCapacity(in capacityInputs) capacityPlan {
+	plan := capacityPlan{
+		Replicas:    map[string]int32{},
+		Resources:   map[string]corev1.ResourceRequirements{},
+		StorageSize: map[string]v1alpha1.StorageSize{},
+	}
+
+	routers := max(minRouterReplicas, ceilDiv(float64(in.SamplesPerSecond), routerSamplesPerReplica))
+	routerSamples := float64(in.SamplesPerSecond) / float64(routers)
+	plan.Replicas[clusters.ReceiveRouter] = routers
+	plan.Resources[clusters.ReceiveRouter] = requests(
+		max(1, ceilDiv(routerSamples, routerSamplesPerCore)),
+		gib+routerSamples/routerSamplesPerGiB*gib,
+	)
+
+	replicatedSeries := float64(in.ActiveSeries * receiveReplicationFactor)
+	ingesters := max(receiveReplicationFactor, ceilDiv(replicatedSeries, ingesterMaxSeries))
+	ingesterSamples := float64(in.SamplesPerSecond*receiveReplicationFactor) / float64(ingesters)
+	plan.Replicas[clusters.ReceiveIngestorDefault] = ingesters
+	plan.Resources[clusters.ReceiveIngestorDefault] = requests(
+		max(1, ceilDiv(ingesterSamples, ingesterSamplesPerCore)),
+		gib+replicatedSeries/float64(ingesters)*ingesterBytesPerSeries,
+	)
+	plan.StorageSize[clusters.ReceiveIngestorDefault] = storageSize(
+		ingesterSamples*ingesterRetention.Seconds()*bytesPerSample, minComponentStorageBytes)
+
+	blockBytes := float64(in.SamplesPerSecond) * time.Duration(in.Retention).Seconds() * bytesPerSample
+	plan.StoreShards = max(1, ceilDiv(blockBytes, storeBytesPerShard))
+	shardBytes := blockBytes / float64(plan.StoreShards)
+	plan.Resources[clusters.StoreDefault] = requests(1, gib+shardBytes*storeMemoryRatio)
+	plan.StorageSize[clusters.StoreDefault] = storageSize(shardBytes*storeIndexHeaderRatio, minComponentStorageBytes)
+
+	tenants := max(1, in.Tenants)
+	largestTenantBlockBytes := float64(in.SamplesPerSecond) * compactorBlockRange.Seconds() * bytesPerSample / float64(tenants) * float64(min(tenants, compactorTenantSkew))
+	plan.StorageSize[clusters.CompactDefault] = storageSize(2*largestTenantBlockBytes, minCompactorStorageBytes)
+
+	if in.LogsMBPerSecond > 0 {
+		plan.LokiRouterReplicas = max(minLokiRouterReplicas, ceilDiv(in.LogsMBPerSecond, lokiRouterMBPerSecond))
+		plan.LokiIngestReplicas = max(minLokiIngesterReplicas, ceilDiv(in.LogsMBPerSecond*lokiReplicationFactor, lokiIngesterMBPerSecond))
+	}
+	return plan
+}
+
+// override renders the plan as the arguments of TemplateMaps.Override in the clusters package
+func (p capacityPlan) override() (string, error) {
+	var b strings.Builder
+	b.WriteString("DefaultBaseTemplate().Override(\n")
+
+	b.WriteString("Replicas{\n")
+	for _, c := range capacityComponents {
+		if v, ok := p.Replicas[c.key]; ok {
+			fmt.Fprintf(&b, "%s: %d,\n", c.ident, v)
+		}
+	}
+	b.WriteString("},\n")
+
+	b.WriteString("Resources{\n")
+	for _, c := range capacityComponents {
+		r, ok := p.Resources[c.key]
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(&b, "%s: {\nRequests: corev1.ResourceList{\n", c.ident)
+		fmt.Fprintf(&b, "corev1.ResourceCPU: resource.MustParse(%q),\n", r.Requests.Cpu().String())
+		fmt.Fprintf(&b, "corev1.ResourceMemory: resource.MustParse(%q),\n", r.Requests.Memory().String())
+		b.WriteString("},\n},\n")
+	}
+	b.WriteString("},\n")
+
+	b.WriteString("StorageSizes{\n")
+	for _, c := range capacityComponents {
+		if v, ok := p.StorageSize[c.key]; ok {
+			fmt.Fprintf(&b, "%s: %q,\n", c.ident, v)
+		}
+	}
+	b.WriteString("},\n")
+
+	if p.LokiIngestReplicas > 0 {
+		b.WriteString("LokiOverridesMap{\nLokiConfig: {\n")
+		fmt.Fprintf(&b, "Router: LokiComponentSpec{Replicas: %d},\n", p.LokiRouterReplicas)
+		fmt.Fprintf(&b, "Ingest: LokiComponentSpec{Replicas: %d},\n", p.LokiIngestReplicas)
+		b.WriteString("},\n},\n")
+	}
+	b.WriteString(")\n")
+
+	if p.StoreShards > 1 {
+		fmt.Fprintf(&b, "// STORE_DEFAULT needs %d shards: set them through the StoreTiers of STORE_DEFAULT.\n", p.StoreShards)
+	}
+
+	src, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return "", fmt.Errorf("failed to format template override: %w", err)
+	}
+	return string(src), nil
+}
+
+// capacityWarnings lists the current settings of the cluster falling below the plan
+func capacityWarnings(config clusters.ClusterConfig, p capacityPlan) []string {
+	t := config.Templates
+	var warnings []string
+	below := func(key, what string, current, planned fmt.Stringer) {
+		warnings = append(warnings, fmt.Sprintf("%s %s is %s, the model suggests %s", key, what, current, planned))
+	}
+
+	for _, c := range capacityComponents {
+		planned, ok := p.Replicas[c.key]
+		if !ok {
+			continue
+		}
+		current := t.Replicas[c.key]
+		if c.key == clusters.ReceiveIngestorDefault && len(config.Hashrings) > 0 {
+			current = 0
+			for _, h := range config.Hashrings {
+				current += cmpOr(h.Replicas, t.Replicas[c.key])
+			}
+		}
+		if current < planned {
+			below(c.key, "replicas", count(current), count(planned))
+		}
+	}
+
+	for _, c := range capacityComponents {
+		planned, ok := p.Resources[c.key]
+		if !ok {
+			continue
+		}
+		current := t.ResourceRequirements[c.key]
+		for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			cur, want := current.Requests[name], planned.Requests[name]
+			if cur.Cmp(want) < 0 {
+				below(c.key, string(name)+" request", &cur, &want)
+			}
+		}
+	}
+
+	for _, c := range capacityComponents {
+		planned, ok := p.StorageSize[c.key]
+		if !ok {
+			continue
+		}
+		want := resource.MustParse(string(planned))
+		cur, err := resource.ParseQuantity(string(t.StorageSize[c.key]))
+		if err != nil || cur.Cmp(want) < 0 {
+			below(c.key, "storage size", &cur, &want)
+		}
+	}
+
+	shards := int32(1)
+	if tiers := t.StoreTiers[clusters.StoreDefault]; len(tiers) > 0 {
+		// Tiers partition the blocks, so their shards add up.
+		shards = 0
+		for _, tier := range tiers {
+			shards += max(1, tier.Shards)
+		}
+	}
+	if shards < p.StoreShards {
+		below(clusters.StoreDefault, "shards", count(shards), count(p.StoreShards))
+	}
+
+	if p.LokiIngestReplicas > 0 {
+		loki := t.LokiOverrides[clusters.LokiConfig]
+		preset := lokiPresetReplicas[loki.Size]
+		if router := cmpOr(loki.Router.Replicas, preset.router); router < p.LokiRouterReplicas {
+			below(clusters.LokiConfig, "router replicas", count(router), count(p.LokiRouterReplicas))
+		}
+		if ingest := cmpOr(loki.Ingest.Replicas, preset.ingest); ingest < p.LokiIngestReplicas {
+			below(clusters.LokiConfig, "ingester replicas", count(ingest), count(p.LokiIngestReplicas))
+		}
+	}
+	return warnings
+}
+
+type count int32
+
+func (c count) String() string {
+	return fmt.Sprint(int32(c))
+}
+
+func cmpOr(v, fallback int32) int32 {
+	if v != 0 {
+		return v
+	}
+	return fallback
+}
+
+func ceilDiv(a, b float64) int32 {
+	return int32(math.Ceil(a / b))
+}
+
+// requests returns CPU and memory requests, rounding the memory up to GiB.
+func requests(cpu int32, memoryBytes float64) corev1.ResourceRequirements {
+	return corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    *resource.NewQuantity(int64(cpu), resource.DecimalSI),
+			corev1.ResourceMemory: *resource.NewQuantity(int64(math.Ceil(memoryBytes/gib))*gib, resource.BinarySI),
+		},
+	}
+}
+
+// storageSize rounds the bytes up to GiB, with a floor of minBytes.
+func storageSize(bytes, minBytes float64) v1alpha1.StorageSize {
+	q := resource.NewQuantity(int64(math.Ceil(max(bytes, minBytes)/gib))*gib, resource.BinarySI)
+	return v1alpha1.StorageSize(q.String())
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"maps"
+	"testing"
+	"time"
+
+	lokiv1 "github.com/grafana/loki/operator/api/loki/v1"
+	"github.com/prometheus/common/model"
+	"github.com/rhobs/configuration/clusters"
+	"github.com/thanos-community/thanos-operator/api/v1alpha1"
+)
+
+func TestPlanCapacity(t *testing.T) {
+	type requests struct{ cpu, memory string }
+
+	for _, tc := range []struct {
+		name        string
+		in          capacityInputs
+		replicas    map[string]int32
+		requests    map[string]requests
+		storageSize map[string]v1alpha1.StorageSize
+		storeShards int32
+		lokiRouter  int32
+		lokiIngest  int32
+	}{
+		{
+			name: "no load keeps the minimums",
+			in:   capacityInputs{},
+			replicas: map[string]int32{
+				clusters.ReceiveRouter:          minRouterReplicas,
+				clusters.ReceiveIngestorDefault: receiveReplicationFactor,
+			},
+			requests: map[string]requests{
+				clusters.ReceiveRouter:          {"1", "1Gi"},
+				clusters.ReceiveIngestorDefault: {"1", "1Gi"},
+				clusters.StoreDefault:           {"1", "1Gi"},
+			},
+			storageSize: map[string]v1alpha1.StorageSize{
+				clusters.ReceiveIngestorDefault: "10Gi",
+				clusters.StoreDefault:           "10Gi",
+				clusters.CompactDefault:         "50Gi",
+			},
+			storeShards: 1,
+		},
+		{
+			name: "large cluster",
+			in: capacityInputs{
+				ActiveSeries:     5_000_000,
+				SamplesPerSecond: 500_000,
+				Tenants:          10,
+				LogsMBPerSecond:  10,
+				Retention:        model.Duration(365 * 24 * time.Hour),
+			},
+			replicas: map[string]int32{
+				clusters.ReceiveRouter:          3,
+				clusters.ReceiveIngestorDefault: 8,
+			},
+			requests: map[string]requests{
+				clusters.ReceiveRouter:          {"2", "5Gi"},
+				clusters.ReceiveIngestorDefault: {"2", "16Gi"},
+				clusters.StoreDefault:           {"1", "11Gi"},
+			},
+			storageSize: map[string]v1alpha1.StorageSize{
+				clusters.ReceiveIngestorDefault: "33Gi",
+				clusters.StoreDefault:           "196Gi",
+				clusters.CompactDefault:         "676Gi",
+			},
+			storeShards: 3,
+			lokiRouter:  3,
+			lokiIngest:  4,
+		},
+		{
+			name: "a single tenant takes the whole compaction",
+			in: capacityInputs{
+				SamplesPerSecond: 1_000_000,
+				Tenants:          1,
+			},
+			replicas: map[string]int32{
+				clusters.ReceiveRouter:          4,
+				clusters.ReceiveIngestorDefault: receiveReplicationFactor,
+			},
+			requests: map[string]requests{
+				clusters.ReceiveRouter:          {"3", "6Gi"},
+				clusters.ReceiveIngestorDefault: {"10", "1Gi"},
+				clusters.StoreDefault:           {"1", "1Gi"},
+			},
+			storageSize: map[string]v1alpha1.StorageSize{
+				clusters.ReceiveIngestorDefault: "175Gi",
+				clusters.StoreDefault:           "10Gi",
+				clusters.CompactDefault:         "4507Gi",
+			},
+			storeShards: 1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			plan := planCapacity(tc.in)
+
+			if !maps.Equal(plan.Replicas, tc.replicas) {
+				t.Errorf("replicas: got %v, want %v", plan.Replicas, tc.replicas)
+			}
+			got := map[string]requests{}
+			for key, r := range plan.Resources {
+				got[key] = requests{r.Requests.Cpu().String(), r.Requests.Memory().String()}
+			}
+			if !maps.Equal(got, tc.requests) {
+				t.Errorf("requests: got %v, want %v", got, tc.requests)
+			}
+			if !maps.Equal(plan.StorageSize, tc.storageSize) {
+				t.Errorf("storage sizes: got %v, want %v", plan.StorageSize, tc.storageSize)
+			}
+			if plan.StoreShards != tc.storeShards {
+				t.Errorf("store shards: got %d, want %d", plan.StoreShards, tc.storeShards)
+			}
+			if plan.LokiRouterReplicas != tc.lokiRouter || plan.LokiIngestReplicas != tc.lokiIngest {
+				t.Errorf("loki replicas: got router %d and ingest %d, want %d and %d",
+					plan.LokiRouterReplicas, plan.LokiIngestReplicas, tc.lokiRouter, tc.lokiIngest)
+			}
+		})
+	}
+}
+
+func TestCapacityWarningsLokiPreset(t *testing.T) {
+	plan := capacityPlan{LokiRouterReplicas: 2, LokiIngestReplicas: 3}
+
+	for _, tc := range []struct {
+		name     string
+		loki     clusters.LokiOverrides
+		warnings int
+	}{
+		{
+			name: "unset replicas run with the preset",
+			loki: clusters.LokiOverrides{Size: lokiv1.SizeOneXMedium},
+		},
+		{
+			name:     "the preset falls below the plan",
+			loki:     clusters.LokiOverrides{Size: lokiv1.SizeOneXSmall},
+			warnings: 1,
+		},
+		{
+			name: "set replicas override the preset",
+			loki: clusters.LokiOverrides{
+				Size:   lokiv1.SizeOneXDemo,
+				Router: clusters.LokiComponentSpec{Replicas: 2},
+				Ingest: clusters.LokiComponentSpec{Replicas: 3},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config := clusters.ClusterConfig{Templates: clusters.TemplateMaps{
+				LokiOverrides: clusters.ParamMap[clusters.LokiOverrides]{clusters.LokiConfig: tc.loki},
+			}}
+			if warnings := capacityWarnings(config, plan); len(warnings) != tc.warnings {
+				t.Errorf("got warnings %q, want %d", warnings, tc.warnings)
+			}
+		})
+	}
+}
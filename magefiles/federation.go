@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/bwplotka/mimic"
+	"github.com/bwplotka/mimic/encoding"
+	kghelpers "github.com/observatorium/observatorium/configuration_go/kubegen/helpers"
+	"github.com/observatorium/observatorium/configuration_go/kubegen/openshift"
+	routev1 "github.com/openshift/api/route/v1"
+	templatev1 "github.com/openshift/api/template/v1"
+	"github.com/rhobs/configuration/clusters"
+	"github.com/thanos-community/thanos-operator/api/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	federationGlobalQuery     = "global"
+	federationClientTLSSecret = "thanos-federation-client-tls"
+	federationServerTLSSecret = "thanos-federation-server-tls"
+	federationTLSVolume       = "federation-tls"
+	federationTLSMountPath    = "/etc/thanos/federation"
+	federationStoreAPIRoute   = "thanos-query-grpc"
+	// federatedClusterLabel is never set on a Service, so that the remote queries do not pick up any local StoreAPI.
+	federatedClusterLabel = "rhobs.observatorium.io/federated-cluster"
+)
+
+// federationObjects wires the query federation of a cluster into its Thanos objects.
+// A cluster federated by others serves the StoreAPI of its query over mTLS through a passthrough route.
+// A cluster federating others gets a query per remote, carrying the remote's timeout and partial response settings,
+// and a global query spanning the local stores and every remote. The ruler is pinned to the local query so that
+// rules keep evaluating against local data only.
+func federationObjects(config clusters.ClusterConfig, objs []runtime.Object) ([]runtime.Object, error) {
+	endpoints, err := clusters.FederationEndpoints(config)
+	if err != nil {
+		return nil, err
+	}
+	exposed := config.StoreAPIHost != "" && len(clusters.FederatingClusters(config.Name)) > 0
+	if len(endpoints) == 0 && !exposed {
+		return objs, nil
+	}
+
+	var query *v1alpha1.ThanosQuery
+	var ruler *v1alpha1.ThanosRuler
+	for _, obj := range objs {
+		switch o := obj.(type) {
+		case *v1alpha1.ThanosQuery:
+			query = o
+		case *v1alpha1.ThanosRuler:
+			ruler = o
+		}
+	}
+	if query == nil {
+		return nil, fmt.Errorf("cluster %s has no ThanosQuery to federate", config.Name)
+	}
+
+	if exposed {
+		query.Spec.Additional.Args = append(query.Spec.Additional.Args,
+			fmt.Sprintf("--grpc-server-tls-cert=%s/tls.crt", federationTLSMountPath),
+			fmt.Sprintf("--grpc-server-tls-key=%s/tls.key", federationTLSMountPath),
+			fmt.Sprintf("--grpc-server-tls-client-ca=%s/ca.crt", federationTLSMountPath),
+		)
+		mountFederationTLS(&query.Spec.Additional, federationServerTLSSecret)
+		objs = append(objs, federationRoute(config.Namespace, config.StoreAPIHost, query.Name))
+	}
+
+	if len(endpoints) == 0 {
+		return objs, nil
+	}
+
+	if ruler != nil && ruler.Spec.QueryLabelSelector != nil {
+		ruler.Spec.QueryLabelSelector.MatchLabels["app.kubernetes.io/instance"] = queryServiceName(query.Name)
+	}
+
+	global := federatedQueryBase(query, federationGlobalQuery)
+	global.Spec.Additional.Args = append(global.Spec.Additional.Args, "--no-query.partial-response")
+	for _, ep := range endpoints {
+		remote := federatedQueryBase(query, "federated-"+string(ep.Name))
+		remote.Spec.StoreLabelSelector = &metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				"operator.thanos.io/store-api": "true",
+				federatedClusterLabel:          string(ep.Name),
+			},
+		}
+		remote.Spec.Additional.Args = append(remote.Spec.Additional.Args,
+			fmt.Sprintf("--endpoint-strict=%s:443", ep.Host),
+			"--grpc-client-tls-secure",
+			fmt.Sprintf("--grpc-client-tls-cert=%s/tls.crt", federationTLSMountPath),
+			fmt.Sprintf("--grpc-client-tls-key=%s/tls.key", federationTLSMountPath),
+			fmt.Sprintf("--grpc-client-tls-ca=%s/ca.crt", federationTLSMountPath),
+			fmt.Sprintf("--grpc-client-server-name=%s", ep.Host),
+		)
+		if ep.ResponseTimeout != "" {
+			remote.Spec.Additional.Args = append(remote.Spec.Additional.Args, fmt.Sprintf("--store.response-timeout=%s", ep.ResponseTimeout))
+		}
+		if ep.PartialResponse {
+			remote.Spec.Additional.Args = append(remote.Spec.Additional.Args, "--query.partial-response")
+		} else {
+			remote.Spec.Additional.Args = append(remote.Spec.Additional.Args, "--no-query.partial-response")
+		}
+		mountFederationTLS(&remote.Spec.Additional, federationClientTLSSecret)
+		objs = append(objs, remote)
+
+		global.Spec.Additional.Args = append(global.Spec.Additional.Args,
+			fmt.Sprintf("--endpoint=dnssrv+_grpc._tcp.%s.%s.svc", queryServiceName(remote.Name), config.Namespace))
+	}
+	objs = append(objs, global)
+	return objs, nil
+}
+
+// federatedQueryBase derives a query of the federation from the local query, without its frontend and oauth proxy.
+func federatedQueryBase(query *v1alpha1.ThanosQuery, name string) *v1alpha1.ThanosQuery {
+	q := query.DeepCopy()
+	q.Name = name
+	q.Annotations = nil
+	q.Spec.QueryFrontend = nil
+	q.Spec.Additional = v1alpha1.Additional{}
+	return q
+}
+
+func mountFederationTLS(additional *v1alpha1.Additional, secret string) {
+	additional.Volumes = append(additional.Volumes, kghelpers.NewPodVolumeFromSecret(federationTLSVolume, secret))
+	additional.VolumeMounts = append(additional.VolumeMounts, corev1.VolumeMount{
+		Name:      federationTLSVolume,
+		MountPath: federationTLSMountPath,
+		ReadOnly:  true,
+	})
+}
+
+// queryServiceName is the name of the Service the operator creates for a ThanosQuery.
+func queryServiceName(query string) string {
+	return "thanos-query-" + query
+}
+
+func federationRoute(namespace, host, query string) *routev1.Route {
+	return &routev1.Route{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "route.openshift.io/v1",
+			Kind:       "Route",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      federationStoreAPIRoute,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/part-of": "thanos",
+			},
+		},
+		Spec: routev1.RouteSpec{
+			Host: host,
+			To: routev1.RouteTargetReference{
+				Kind:   "Service",
+				Name:   queryServiceName(query),
+				Weight: ptr.To(int32(100)),
+			},
+			Port: &routev1.RoutePort{
+				TargetPort: intstr.FromString("grpc"),
+			},
+			// The query terminates TLS itself to verify the client certificates of the federating clusters.
+			TLS: &routev1.TLSConfig{
+				Termination:                   routev1.TLSTerminationPassthrough,
+				InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyNone,
+			},
+		},
+	}
+}
+
+// federationSecrets returns the mTLS secrets of the query federation of a cluster and the parameters they reference.
+func federationSecrets(config clusters.ClusterConfig) ([]runtime.Object, []templatev1.Parameter) {
+	var objs []runtime.Object
+	var params []templatev1.Parameter
+	if config.StoreAPIHost != "" && len(clusters.FederatingClusters(config.Name)) > 0 {
+		objs = append(objs, federationTLSSecret(config.Namespace, federationServerTLSSecret, "FEDERATION_SERVER"))
+		params = append(params, federationTLSParams("FEDERATION_SERVER", "serving the StoreAPI to federating clusters")...)
+	}
+	if config.QueryFederation != nil {
+		objs = append(objs, federationTLSSecret(config.Namespace, federationClientTLSSecret, "FEDERATION_CLIENT"))
+		params = append(params, federationTLSParams("FEDERATION_CLIENT", "querying the StoreAPI of federated clusters")...)
+	}
+	return objs, params
+}
+
+func federationTLSSecret(namespace, name, paramPrefix string) *corev1.Secret {
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/part-of": "thanos",
+			},
+		},
+		StringData: map[string]string{
+			"tls.crt": fmt.Sprintf("${%s_TLS_CRT}", paramPrefix),
+			"tls.key": fmt.Sprintf("${%s_TLS_KEY}", paramPrefix),
+			"ca.crt":  fmt.Sprintf("${%s_TLS_CA}", paramPrefix),
+		},
+	}
+}
+
+func federationTLSParams(prefix, usage string) []templatev1.Parameter {
+	return []templatev1.Parameter{
+		{Name: prefix + "_TLS_CRT", Description: "Certificate for " + usage, Required: true},
+		{Name: prefix + "_TLS_KEY", Description: "Private key for " + usage, Required: true},
+		{Name: prefix + "_TLS_CA", Description: "CA bundle for " + usage, Required: true},
+	}
+}
+
+// addFederationSecretTemplate adds the federation secrets of a cluster to gen as an OpenShift template.
+// It returns false if the cluster is not part of a query federation.
+func addFederationSecretTemplate(gen *mimic.Generator, config clusters.ClusterConfig) bool {
+	secrets, params := federationSecrets(config)
+	if len(secrets) == 0 {
+		return false
+	}
+	gen.Add("thanos-federation-secret-template.yaml", encoding.GhodssYAML(
+		openshift.WrapInTemplate(secrets, metav1.ObjectMeta{Name: "thanos-federation-secret"}, params),
+	))
+	return true
+}
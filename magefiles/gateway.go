@@ -7,11 +7,9 @@ import (
 	"time"
 
 	"github.com/bwplotka/mimic"
-	"github.com/bwplotka/mimic/encoding"
 	"github.com/ghodss/yaml"
 	"github.com/go-kit/log"
 	observatoriumapi "github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/observatorium/api"
-	"github.com/observatorium/observatorium/configuration_go/kubegen/openshift"
 	routev1 "github.com/openshift/api/route/v1"
 	templatev1 "github.com/openshift/api/template/v1"
 	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
@@ -19,10 +17,13 @@ import (
 	cfgobservatorium "github.com/rhobs/configuration/configuration/observatorium"
 
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
 )
 
 const (
@@ -30,7 +31,6 @@ const (
 	gatewayTemplate = "observatorium-api-template.yaml"
 
 	observatoriumAPI     = "OBSERVATORIUM_API"
-	syntheticsAPI        = "SYNTHETICS_API"
 	opaAMS               = "OPA_AMS"
 	apiCache             = "API_CACHE"
 	componentOPAAMS      = "opa-ams"
@@ -42,85 +42,98 @@ const (
 
 	logsQfeService    = "observatorium-lokistack-query-frontend-http"
 	logsRouterService = "observatorium-lokistack-distributor-http"
+
+	tracesQfeService         = "tempo-" + tempoStackName + "-query-frontend"
+	tracesDistributorService = "tempo-" + tempoStackName + "-distributor"
 )
 
 func (b Build) Gateway(config clusters.ClusterConfig) error {
 	fn := func() *mimic.Generator {
 		return b.generator(config, gatewayName)
 	}
-	// For rhobss01ue1 and rhobsi01uw2 clusters, generate gateway bundle with individual resources
-	if isMigratedCluster(config) {
-		return generateGatewayBundle(config)
-	}
 	return gateway(config, fn)
 }
 
+// gateway generates the gateway of a registered cluster or a legacy environment, every gateway build goes through it.
 func gateway(config clusters.ClusterConfig, fn builderBuilderGenFunc) error {
-	ns := config.Namespace
-	rbac, err := json.Marshal(config.GatewayConfig.RBAC())
-	if err != nil {
-		return fmt.Errorf("failed to marshal RBAC configuration: %w", err)
+	// Stage and Production build their config outside the cluster registry, which validates the registered ones
+	if err := config.GatewayConfig.Validate(); err != nil {
+		return fmt.Errorf("invalid gateway config: %w", err)
 	}
-	rbacYAML, err := yaml.JSONToYAML(rbac)
-	if err != nil {
-		return fmt.Errorf("failed to convert RBAC configuration to YAML: %w", err)
+	if err := checkRBACExpiry(time.Now(), config); err != nil {
+		return err
 	}
 
-	deployment := gatewayDeployment(config.Templates, ns, config.GatewayConfig)
+	// For rhobss01ue1 and rhobsi01uw2 clusters, generate gateway bundle with individual resources
+	if isMigratedCluster(config) {
+		return generateGatewayBundle(config)
+	}
 
-	objs := []runtime.Object{
-		gatewayRBAC(config.Templates, ns, string(rbacYAML)),
-		deployment,
-		createGatewayService(config.Templates, ns, config.GatewayConfig),
-		createTenantSecret(config, ns),
-		createGatewayServiceAccount(config.Templates, ns),
+	// The deploy passes the AMS organization IDs to every OPA-AMS gateway, with or without the opa-ams sidecar.
+	var deployParams []templatev1.Parameter
+	if config.GatewayConfig.Authorizer().Type == clusters.AuthorizerOPAAMS {
+		deployParams = gatewayAMSParams
+	}
+	r := newTemplateRenderer(gatewayName, gatewayTemplate, deployParams...)
+	objs, err := gatewayObjects(config, r)
+	if err != nil {
+		return err
 	}
 
-	template := openshift.WrapInTemplate(objs, metav1.ObjectMeta{
-		Name: gatewayName,
-	}, gatewayTemplateParams)
-	enc := encoding.GhodssYAML(template)
 	gen := fn()
-	gen.Add(gatewayTemplate, enc)
+	r.render(gen, append(objs, createTenantSecret(config, config.Namespace, r)))
 	gen.Generate()
 
-	sms := []runtime.Object{
-		gatewayServiceMonitor(clusters.StageMaps, ns, config.GatewayConfig),
-	}
-
-	template = openshift.WrapInTemplate(sms, metav1.ObjectMeta{
-		Name: gatewayName + "-service-monitor",
-	}, nil)
+	sm := newTemplateRenderer(gatewayName+"-service-monitor", "service-monitor-"+gatewayTemplate)
 	gen = fn()
-	gen.Add("service-monitor-"+gatewayTemplate, encoding.GhodssYAML(template))
+	sm.render(gen, []runtime.Object{gatewayServiceMonitor(config.Templates, config.Namespace, config.GatewayConfig)})
 	gen.Generate()
 
 	return nil
 }
 
-// generateGatewayBundle generates individual gateway and cache resource files
-func generateGatewayBundle(config clusters.ClusterConfig) error {
+// gatewayObjects returns the gateway resources of every output. The tenant secret is left out,
+// as its client credentials are template parameters in every output.
+func gatewayObjects(config clusters.ClusterConfig, r renderer) ([]runtime.Object, error) {
 	ns := config.Namespace
 	rbac, err := json.Marshal(config.GatewayConfig.RBAC())
 	if err != nil {
-		return fmt.Errorf("failed to marshal RBAC configuration: %w", err)
+		return nil, fmt.Errorf("failed to marshal RBAC configuration: %w", err)
 	}
 	rbacYAML, err := yaml.JSONToYAML(rbac)
 	if err != nil {
-		return fmt.Errorf("failed to convert RBAC configuration to YAML: %w", err)
+		return nil, fmt.Errorf("failed to convert RBAC configuration to YAML: %w", err)
 	}
 
-	deployment := gatewayDeployment(config.Templates, ns, config.GatewayConfig)
-	// Ensure metadata.name is rhobs-gateway
-	deployment.ObjectMeta.Name = gatewayName
-
-	// Gateway resources
-	gatewayObjs := []runtime.Object{
+	objs := []runtime.Object{
 		gatewayRBAC(config.Templates, ns, string(rbacYAML)),
-		deployment,
+		gatewayDeployment(config.Templates, ns, config.GatewayConfig, r),
 		createGatewayService(config.Templates, ns, config.GatewayConfig),
 		createGatewayServiceAccount(config.Templates, ns),
 	}
+	if config.GatewayConfig.Authorizer().Type == clusters.AuthorizerExternalOPA {
+		objs = append(objs, gatewayOPABundle(config.Templates, ns, string(rbac)))
+	}
+	objs = append(objs, gatewayDisruptionObjects(config.Templates, ns, config.GatewayConfig)...)
+	if host := config.GatewayConfig.CustomRoute(); host != "" {
+		objs = append(objs, createGatewayRoute(ns, host))
+	}
+	return objs, nil
+}
+
+// generateGatewayBundle generates individual gateway and cache resource files
+func generateGatewayBundle(config clusters.ClusterConfig) error {
+	ns := config.Namespace
+	// Generate individual gateway resource files with proxy- prefix
+	proxyBundle := bundleRenderer{
+		filename: func(_ int, obj runtime.Object) string {
+			return fmt.Sprintf("proxy-%s-%s.yaml", getKubernetesResourceName(obj), getResourceKind(obj))
+		},
+	}
+	gatewayObjs, err := gatewayObjects(config, proxyBundle)
+	if err != nil {
+		return err
+	}
 
 	// Gateway cache resources
 	cacheConfig := gatewayCache(config.Templates, ns)
@@ -130,34 +143,20 @@ func generateGatewayBundle(config clusters.ClusterConfig) error {
 		createCacheHeadlessService(cacheConfig),
 	}
 
-	// Secret as template
-	secret := createTenantSecret(config, ns)
-
 	// Create bundle generator for individual resource files
 	bundleGen := &mimic.Generator{}
 	bundleGen = bundleGen.With(templatePath, templateClustersPath, string(config.Environment), string(config.Name), "gateway", "bundle")
 	bundleGen.Logger = log.NewLogfmtLogger(log.NewSyncWriter(os.Stdout))
 
-	// Generate individual gateway resource files with proxy- prefix
-	for _, obj := range gatewayObjs {
-		filename := fmt.Sprintf("proxy-%s-%s.yaml", gatewayName, getResourceKind(obj))
-		processedObj := gatewayPostProcessForBundle(obj, ns)
-		bundleGen.Add(filename, encoding.GhodssYAML(processedObj))
-	}
-
-	// Generate custom route if configured
-	if config.GatewayConfig.CustomRoute() != "" {
-		route := createGatewayRoute(ns, config.GatewayConfig.CustomRoute())
-		filename := fmt.Sprintf("proxy-%s-Route.yaml", gatewayName)
-		bundleGen.Add(filename, encoding.GhodssYAML(route))
-	}
+	proxyBundle.render(bundleGen, gatewayObjs)
 
 	// Generate individual cache resource files with cache- prefix
-	for _, obj := range cacheObjs {
-		filename := fmt.Sprintf("cache-%s-%s.yaml", cacheConfig.Name, getResourceKind(obj))
-		// For now, just use the object as-is since we're focusing on gateway bundle structure
-		bundleGen.Add(filename, encoding.GhodssYAML(obj))
+	cacheBundle := bundleRenderer{
+		filename: func(_ int, obj runtime.Object) string {
+			return fmt.Sprintf("cache-%s-%s.yaml", cacheConfig.Name, getResourceKind(obj))
+		},
 	}
+	cacheBundle.render(bundleGen, cacheObjs)
 
 	// Create templates generator for secret wrapped in OpenShift template
 	templatesGen := &mimic.Generator{}
@@ -165,11 +164,8 @@ func generateGatewayBundle(config clusters.ClusterConfig) error {
 	templatesGen.Logger = log.NewLogfmtLogger(log.NewSyncWriter(os.Stdout))
 
 	// Wrap secret in OpenShift template with parameters
-	secretObjs := []runtime.Object{secret}
-	secretTemplate := openshift.WrapInTemplate(secretObjs, metav1.ObjectMeta{
-		Name: "gateway-secret",
-	}, gatewayTemplateParams)
-	templatesGen.Add("gateway-secret-template.yaml", encoding.GhodssYAML(secretTemplate))
+	secretTemplate := newTemplateRenderer("gateway-secret", "gateway-secret-template.yaml")
+	secretTemplate.render(templatesGen, []runtime.Object{createTenantSecret(config, ns, secretTemplate)})
 
 	// Generate all bundles
 	bundleGen.Generate()
@@ -196,7 +192,7 @@ func (s Stage) Gateway() error {
 		GatewayConfig: clusters.NewGatewayConfig(
 			clusters.WithMetricsEnabled(),
 			clusters.WithRBAC(*cfgobservatorium.GenerateRBAC()),
-			clusters.WithAMS("https://api.stage.openshift.com"),
+			clusters.WithAMS("https://api.stage.openshift.com", clusters.DefaultAMSMappings()...),
 			clusters.WithTenants(stageGatewayTenants()),
 		),
 	}
@@ -214,7 +210,7 @@ func (p Production) Gateway() error {
 		GatewayConfig: clusters.NewGatewayConfig(
 			clusters.WithMetricsEnabled(),
 			clusters.WithRBAC(*cfgobservatorium.GenerateRBAC()),
-			clusters.WithAMS("https://api.openshift.com"),
+			clusters.WithAMS("https://api.openshift.com", clusters.DefaultAMSMappings()...),
 			clusters.WithTenants(prodGatewayTenants()),
 		),
 	}
@@ -237,14 +233,14 @@ func gatewayLabels(m clusters.TemplateMaps) (labels map[string]string, selectorL
 	return metaLabels, selectorLabels
 }
 
-func gatewayDeployment(m clusters.TemplateMaps, namespace string, conf *clusters.GatewayConfig) *appsv1.Deployment {
+func gatewayDeployment(m clusters.TemplateMaps, namespace string, conf *clusters.GatewayConfig, r renderer) *appsv1.Deployment {
 	containers := []corev1.Container{
 		createObservatoriumAPIContainer(m, namespace, conf),
 	}
 
-	if conf.AMSURL() != "" {
+	if authorizer := conf.Authorizer(); authorizer.Type == clusters.AuthorizerOPAAMS {
 		if _, ok := m.Images[opaAMS]; ok {
-			containers = append(containers, createOPAAMSContainer(m, namespace, conf.AMSURL()))
+			containers = append(containers, createOPAAMSContainer(m, namespace, authorizer, r))
 		}
 	}
 
@@ -255,7 +251,29 @@ func gatewayDeployment(m clusters.TemplateMaps, namespace string, conf *clusters
 	}
 
 	metaLabels, selectorLabels := gatewayLabels(m)
-	replicas := m.Replicas[observatoriumAPI]
+
+	// The HPA owns the replica count when autoscaling is enabled.
+	var replicas *int32
+	if _, ok := m.Autoscaling[observatoriumAPI]; !ok {
+		replicas = ptr.To(m.Replicas[observatoriumAPI])
+	}
+
+	var topologySpread []corev1.TopologySpreadConstraint
+	for _, spread := range conf.TopologySpread() {
+		whenUnsatisfiable := corev1.ScheduleAnyway
+		if spread.HardConstraint {
+			whenUnsatisfiable = corev1.DoNotSchedule
+		}
+		topologySpread = append(topologySpread, corev1.TopologySpreadConstraint{
+			MaxSkew:           spread.MaxSkew,
+			TopologyKey:       spread.TopologyKey,
+			WhenUnsatisfiable: whenUnsatisfiable,
+			LabelSelector: &metav1.LabelSelector{
+				MatchLabels: selectorLabels,
+			},
+		})
+	}
+
 	return &appsv1.Deployment{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "Deployment",
@@ -267,7 +285,7 @@ func gatewayDeployment(m clusters.TemplateMaps, namespace string, conf *clusters
 			Labels:    metaLabels,
 		},
 		Spec: appsv1.DeploymentSpec{
-			Replicas: &replicas,
+			Replicas: replicas,
 			Selector: &metav1.LabelSelector{
 				MatchLabels: selectorLabels,
 			},
@@ -304,7 +322,8 @@ func gatewayDeployment(m clusters.TemplateMaps, namespace string, conf *clusters
 							},
 						},
 					},
-					Containers: containers,
+					Containers:                containers,
+					TopologySpreadConstraints: topologySpread,
 					Affinity: &corev1.Affinity{
 						PodAntiAffinity: &corev1.PodAntiAffinity{
 							PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
@@ -332,6 +351,103 @@ func gatewayDeployment(m clusters.TemplateMaps, namespace string, conf *clusters
 	}
 }
 
+// gatewayDisruptionObjects returns the HorizontalPodAutoscaler and PodDisruptionBudget of the gateway, when configured.
+func gatewayDisruptionObjects(m clusters.TemplateMaps, namespace string, conf *clusters.GatewayConfig) []runtime.Object {
+	var objs []runtime.Object
+	if autoscaling, ok := m.Autoscaling[observatoriumAPI]; ok {
+		objs = append(objs, createGatewayHPA(m, namespace, autoscaling))
+	}
+	if budget := conf.DisruptionBudget(); budget != nil {
+		objs = append(objs, createGatewayPDB(m, namespace, budget))
+	}
+	return objs
+}
+
+func createGatewayHPA(m clusters.TemplateMaps, namespace string, autoscaling clusters.AutoscalingSpec) *autoscalingv2.HorizontalPodAutoscaler {
+	labels, _ := gatewayLabels(m)
+
+	var metrics []autoscalingv2.MetricSpec
+	resourceTargets := []struct {
+		name        corev1.ResourceName
+		utilization int32
+	}{
+		{corev1.ResourceCPU, autoscaling.TargetCPUUtilization},
+		{corev1.ResourceMemory, autoscaling.TargetMemoryUtilization},
+	}
+	for _, target := range resourceTargets {
+		if target.utilization == 0 {
+			continue
+		}
+		metrics = append(metrics, autoscalingv2.MetricSpec{
+			Type: autoscalingv2.ResourceMetricSourceType,
+			Resource: &autoscalingv2.ResourceMetricSource{
+				Name: target.name,
+				Target: autoscalingv2.MetricTarget{
+					Type:               autoscalingv2.UtilizationMetricType,
+					AverageUtilization: ptr.To(target.utilization),
+				},
+			},
+		})
+	}
+	for _, custom := range autoscaling.CustomMetrics {
+		metrics = append(metrics, autoscalingv2.MetricSpec{
+			Type: autoscalingv2.PodsMetricSourceType,
+			Pods: &autoscalingv2.PodsMetricSource{
+				Metric: autoscalingv2.MetricIdentifier{
+					Name: custom.Name,
+				},
+				Target: autoscalingv2.MetricTarget{
+					Type:         autoscalingv2.AverageValueMetricType,
+					AverageValue: ptr.To(custom.AverageValue),
+				},
+			},
+		})
+	}
+
+	return &autoscalingv2.HorizontalPodAutoscaler{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "HorizontalPodAutoscaler",
+			APIVersion: "autoscaling/v2",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      gatewayName,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       gatewayName,
+			},
+			MinReplicas: ptr.To(autoscaling.MinReplicas),
+			MaxReplicas: autoscaling.MaxReplicas,
+			Metrics:     metrics,
+		},
+	}
+}
+
+func createGatewayPDB(m clusters.TemplateMaps, namespace string, budget *clusters.DisruptionBudget) *policyv1.PodDisruptionBudget {
+	labels, selectorLabels := gatewayLabels(m)
+	return &policyv1.PodDisruptionBudget{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "PodDisruptionBudget",
+			APIVersion: "policy/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      gatewayName,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MaxUnavailable: ptr.To(intstr.FromInt32(budget.MaxUnavailable)),
+			Selector: &metav1.LabelSelector{
+				MatchLabels: selectorLabels,
+			},
+		},
+	}
+}
+
 func createObservatoriumAPIContainer(m clusters.TemplateMaps, namespace string, conf *clusters.GatewayConfig) corev1.Container {
 	logLevel := clusters.TemplateFn(clusters.ObservatoriumAPI, m.LogLevels)
 	args := []string{
@@ -361,6 +477,14 @@ func createObservatoriumAPIContainer(m clusters.TemplateMaps, namespace string,
 		)
 	}
 
+	if conf.TracesEnabled() {
+		args = append(args,
+			fmt.Sprintf("--traces.write.endpoint=%s.%s.svc.cluster.local:4317", tracesDistributorService, namespace),
+			fmt.Sprintf("--traces.read.endpoint=http://%s.%s.svc.cluster.local:16686", tracesQfeService, namespace),
+			fmt.Sprintf("--traces.tempo.endpoint=http://%s.%s.svc.cluster.local:3200", tracesQfeService, namespace),
+		)
+	}
+
 	if conf.SyntheticsEnabled() {
 		args = append(args,
 			fmt.Sprintf("--probes.endpoint=http://synthetics-api.%s.svc.cluster.local:8080", namespace),
@@ -416,28 +540,30 @@ func createObservatoriumAPIContainer(m clusters.TemplateMaps, namespace string,
 	}
 }
 
-func createOPAAMSContainer(m clusters.TemplateMaps, namespace, amsURL string) corev1.Container {
+func createOPAAMSContainer(m clusters.TemplateMaps, namespace string, authorizer clusters.Authorizer, r renderer) corev1.Container {
+	args := []string{
+		"--web.listen=127.0.0.1:8082",
+		"--web.internal.listen=0.0.0.0:8083",
+		"--web.healthchecks.url=http://127.0.0.1:8082",
+		"--log.level=warn",
+		fmt.Sprintf("--ams.url=%s", authorizer.AMSURL),
+		"--resource-type-prefix=observatorium",
+		"--oidc.client-id=$(CLIENT_ID)",
+		"--oidc.client-secret=$(CLIENT_SECRET)",
+		"--oidc.issuer-url=$(ISSUER_URL)",
+		"--opa.package=observatorium",
+		fmt.Sprintf("--memcached=%s.%s.svc.cluster.local:11211", gatewayCacheName, namespace),
+		"--memcached.expire=300",
+	}
+	for _, mapping := range authorizer.AMSMappings {
+		args = append(args, fmt.Sprintf("--ams.mappings=%s=%s", mapping.Group, amsOrganizationID(r, mapping.OrganizationID)))
+	}
+	args = append(args, "--internal.tracing.endpoint=localhost:6831")
+
 	return corev1.Container{
 		Name:  componentOPAAMS,
 		Image: clusters.TemplateFn(clusters.OpaAMS, m.Images),
-		Args: []string{
-			"--web.listen=127.0.0.1:8082",
-			"--web.internal.listen=0.0.0.0:8083",
-			"--web.healthchecks.url=http://127.0.0.1:8082",
-			"--log.level=warn",
-			fmt.Sprintf("--ams.url=%s", amsURL),
-			"--resource-type-prefix=observatorium",
-			"--oidc.client-id=$(CLIENT_ID)",
-			"--oidc.client-secret=$(CLIENT_SECRET)",
-			"--oidc.issuer-url=$(ISSUER_URL)",
-			"--opa.package=observatorium",
-			fmt.Sprintf("--memcached=%s.%s.svc.cluster.local:11211", gatewayCacheName, namespace),
-			"--memcached.expire=300",
-			"--ams.mappings=osd=${OSD_ORGANIZATION_ID}",
-			"--ams.mappings=osd=${SD_OPS_ORGANIZATION_ID}",
-			"--ams.mappings=cnvqe={CNVQE_ORGANIZATION_ID}",
-			"--internal.tracing.endpoint=localhost:6831",
-		},
+		Args:  args,
 		Env: []corev1.EnvVar{
 			{
 				Name: "ISSUER_URL",
@@ -597,7 +723,7 @@ func createGatewayService(m clusters.TemplateMaps, namespace string, conf *clust
 		},
 	}
 
-	if conf.AMSURL() != "" {
+	if conf.Authorizer().Type == clusters.AuthorizerOPAAMS {
 		amsPorts := []corev1.ServicePort{
 			{
 				Name:       "opa-ams-api",
@@ -722,7 +848,7 @@ func gatewayRBAC(m clusters.TemplateMaps, namespace, contents string) *corev1.Co
 	}
 }
 
-func createTenantSecret(config clusters.ClusterConfig, namespace string) *corev1.Secret {
+func createTenantSecret(config clusters.ClusterConfig, namespace string, r renderer) *corev1.Secret {
 	labels, _ := gatewayLabels(config.Templates)
 
 	return &corev1.Secret{
@@ -739,10 +865,84 @@ func createTenantSecret(config clusters.ClusterConfig, namespace string) *corev1
 			},
 		},
 		StringData: map[string]string{
-			"client-id":     "${CLIENT_ID}",
-			"client-secret": "${CLIENT_SECRET}",
+			"client-id":     r.param(templatev1.Parameter{Name: "CLIENT_ID", Description: "Client ID for OIDC"}),
+			"client-secret": r.param(templatev1.Parameter{Name: "CLIENT_SECRET", Description: "Client secret for OIDC"}),
 			"issuer-url":    "https://sso.redhat.com/auth/realms/redhat-external",
-			"tenants.yaml":  config.GatewayConfig.Tenants().String(),
+			"tenants.yaml":  gatewayTenants(config.GatewayConfig).String(),
+		},
+	}
+}
+
+// gatewayTenants returns the tenants of the gateway, pointing every tenant without its own OPA
+// configuration at the external OPA endpoint when that authorizer is selected.
+func gatewayTenants(conf *clusters.GatewayConfig) observatoriumapi.Tenants {
+	tenants := conf.Tenants()
+	authorizer := conf.Authorizer()
+	if authorizer.Type != clusters.AuthorizerExternalOPA {
+		return tenants
+	}
+
+	result := observatoriumapi.Tenants{Tenants: make([]observatoriumapi.Tenant, 0, len(tenants.Tenants))}
+	for _, tenant := range tenants.Tenants {
+		if tenant.OPA == nil {
+			tenant.OPA = &observatoriumapi.TenantOPA{URL: authorizer.OPAURL}
+		}
+		result.Tenants = append(result.Tenants, tenant)
+	}
+	return result
+}
+
+// gatewayOPARego evaluates the observatorium-api authorization input against the roles and role bindings
+// shipped as data.json next to it, mirroring the semantics of the static rbac.yaml authorizer.
+const gatewayOPARego = `package observatorium
+
+import rego.v1
+
+default allow := false
+
+allow if {
+	some binding in data.roleBindings
+	subject_matches(binding)
+	some role in data.roles
+	role.name in binding.roles
+	input.resource in role.resources
+	input.permission in role.permissions
+	input.tenant in role.tenants
+}
+
+subject_matches(binding) if {
+	some subject in binding.subjects
+	subject.kind == "user"
+	subject.name == input.subject
+}
+
+subject_matches(binding) if {
+	some subject in binding.subjects
+	subject.kind == "group"
+	subject.name in input.groups
+}
+`
+
+// gatewayOPABundle returns the Rego bundle served by the external OPA authorizer.
+// The data.json key holds the gateway RBAC so that both authorizers share a single source of truth.
+func gatewayOPABundle(m clusters.TemplateMaps, namespace, rbacJSON string) *corev1.ConfigMap {
+	labels, _ := gatewayLabels(m)
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      gatewayName + "-opa-bundle",
+			Namespace: namespace,
+			Labels:    labels,
+			Annotations: map[string]string{
+				"qontract.recycle": "true",
+			},
+		},
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: "v1",
+		},
+		Data: map[string]string{
+			"observatorium.rego": gatewayOPARego,
+			"data.json":          rbacJSON,
 		},
 	}
 }
@@ -1094,7 +1294,7 @@ func prodGatewayTenants() observatoriumapi.Tenants {
 	}
 }
 
-var gatewayTemplateParams = []templatev1.Parameter{
+var gatewayAMSParams = []templatev1.Parameter{
 	{
 		Name:        "OSD_ORGANIZATION_ID",
 		Description: "Organization ID for OSD",
@@ -1107,20 +1307,17 @@ var gatewayTemplateParams = []templatev1.Parameter{
 		Name:        "CNVQE_ORGANIZATION_ID",
 		Description: "Organization ID for CNVQE",
 	},
-	{
-		Name:        "CLIENT_ID",
-		Description: "Client ID for OIDC",
-	},
-	{
-		Name:        "CLIENT_SECRET",
-		Description: "Client secret for OIDC",
-	},
 }
 
-// gatewayPostProcessForBundle processes gateway resources for bundle generation
-func gatewayPostProcessForBundle(obj runtime.Object, namespace string) runtime.Object {
-	// For now, just return the object as-is since we're focusing on gateway bundle structure
-	return obj
+// amsOrganizationID returns the organization ID of an AMS mapping, declaring it as a parameter
+// when it stands for one of the gatewayAMSParams.
+func amsOrganizationID(r renderer, id string) string {
+	for _, p := range gatewayAMSParams {
+		if id == "${"+p.Name+"}" {
+			return r.param(p)
+		}
+	}
+	return id
 }
 
 func gatewayServiceMonitor(m clusters.TemplateMaps, matchNS string, conf *clusters.GatewayConfig) *monitoringv1.ServiceMonitor {
@@ -1139,7 +1336,7 @@ func gatewayServiceMonitor(m clusters.TemplateMaps, matchNS string, conf *cluste
 		},
 	}
 
-	if conf.AMSURL() != "" {
+	if conf.Authorizer().Type == clusters.AuthorizerOPAAMS {
 		endpoints = append(endpoints, monitoringv1.Endpoint{
 			Port:     "opa-ams-metrics",
 			Interval: "30s",
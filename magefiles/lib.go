@@ -2,8 +2,6 @@ package main
 
 import (
 	"fmt"
-	"net/http"
-	"sort"
 	"strings"
 
 	"github.com/bwplotka/mimic/encoding"
@@ -11,17 +9,14 @@ import (
 	"github.com/observatorium/observatorium/configuration_go/kubegen/openshift"
 	"github.com/observatorium/observatorium/configuration_go/kubegen/workload"
 	routev1 "github.com/openshift/api/route/v1"
-	templatev1 "github.com/openshift/api/template/v1"
 	monv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	"github.com/rhobs/configuration/clusters"
 	appsv1 "k8s.io/api/apps/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 
 	corev1 "k8s.io/api/core/v1"
-	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/util/yaml"
 	"k8s.io/utils/ptr"
 )
 
@@ -72,7 +67,7 @@ type manifestOptions struct {
 }
 
 // makeOauthProxy creates a container for the oauth-proxy sidecar.
-// It contains a template parameter OAUTH_PROXY_COOKIE_SECRET that must be added to the template parameters.
+// It reads its cookie secret from the oauth-cookie secret.
 func makeOauthProxy(upstreamPort int32, namespace, serviceAccount, tlsSecret string) *workload.Container {
 	const (
 		name     = "oauth-proxy"
@@ -257,13 +252,6 @@ func postProcessServiceMonitor(serviceMonitor *monv1.ServiceMonitor, namespaceSe
 	return encoding.GhodssYAML(template)
 }
 
-func sortTemplateParams(params []templatev1.Parameter) []templatev1.Parameter {
-	sort.Slice(params, func(i, j int) bool {
-		return params[i].Name < params[j].Name
-	})
-	return params
-}
-
 func createServiceAccount(name, namespace string, labels map[string]string) *corev1.ServiceAccount {
 	return &corev1.ServiceAccount{
 		TypeMeta: metav1.TypeMeta{
@@ -290,48 +278,6 @@ func deepCopyMap(m map[string]string) map[string]string {
 	return result
 }
 
-func getCustomResourceDefinition(url string) (*v1.CustomResourceDefinition, error) {
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to fetch %s: %s", url, resp.Status)
-	}
-
-	var obj v1.CustomResourceDefinition
-	decoder := yaml.NewYAMLOrJSONDecoder(resp.Body, 100000)
-	err = decoder.Decode(&obj)
-	if err != nil {
-		return nil, fmt.Errorf("failed to decode %s: %w", url, err)
-	}
-
-	return &obj, nil
-}
-
-func getClusterRole(url string) (*rbacv1.ClusterRole, error) {
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to fetch %s: %s", url, resp.Status)
-	}
-
-	var obj rbacv1.ClusterRole
-	decoder := yaml.NewYAMLOrJSONDecoder(resp.Body, 100000)
-	err = decoder.Decode(&obj)
-	if err != nil {
-		return nil, fmt.Errorf("failed to decode %s: %w", url, err)
-	}
-
-	return &obj, nil
-}
-
 // getResourceKind returns the Kind field from a Kubernetes object
 func getResourceKind(obj runtime.Object) string {
 	switch obj.(type) {
@@ -2,11 +2,9 @@ package main
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/bwplotka/mimic"
 	"github.com/bwplotka/mimic/encoding"
-	"github.com/go-kit/log"
 	"github.com/observatorium/observatorium/configuration_go/kubegen/openshift"
 	templatev1 "github.com/openshift/api/template/v1"
 	"github.com/rhobs/configuration/clusters"
@@ -38,7 +36,8 @@ const (
 )
 
 // LokiOperatorCRDS Generates the CRDs for the Loki operator.
-// This is synced from the ref lokiRef at https://gitlab.cee.redhat.com/openshift-logging/konflux-log-storage
+// They are read from the upstream store, vendored by mage sync:operator from the ref lokiOperatorCRDRef
+// at https://gitlab.cee.redhat.com/openshift-logging/konflux-log-storage
 func (b Build) LokiOperatorCRDS(config clusters.ClusterConfig) error {
 	// For rhobss01ue1 and rhobsi01uw2 clusters, CRDs are handled in logs bundle
 	if isMigratedCluster(config) {
@@ -49,28 +48,11 @@ func (b Build) LokiOperatorCRDS(config clusters.ClusterConfig) error {
 }
 
 func lokiCRD(gen *mimic.Generator, templates clusters.TemplateMaps) error {
-	const (
-		projectconfigs = "config.grafana.com_projectconfigs.yaml"
-		alertingrules  = "loki.grafana.com_alertingrules.yaml"
-		lokistacks     = "loki.grafana.com_lokistacks.yaml"
-		recordingrules = "loki.grafana.com_recordingrules.yaml"
-		rulerconfigs   = "loki.grafana.com_rulerconfigs.yaml"
-		base           = "https://raw.githubusercontent.com/openshift/loki/" + lokiOperatorCRDRef + "/operator/config/crd/bases/"
-	)
-
-	var objs []runtime.Object
-	for _, component := range []string{projectconfigs, alertingrules, lokistacks, recordingrules, rulerconfigs} {
-		crd, err := getCustomResourceDefinition(base + component)
-		if err != nil {
-			return err
-		}
-
-		objs = append(objs, crd)
+	objs, err := upstreamCRDObjects(lokiOperatorUpstream(lokiOperatorCRDRef), lokiOperatorCRDs)
+	if err != nil {
+		return err
 	}
 
-	logger := log.NewLogfmtLogger(log.NewSyncWriter(os.Stdout))
-	_ = logger.Log("msg", "successfully fetched CRD objects", "count", len(objs))
-
 	gen.Add("crds.yaml", encoding.GhodssYAML(
 		openshift.WrapInTemplate(
 			objs,
@@ -2,15 +2,14 @@ package main
 
 import (
 	"fmt"
-	"log"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/bwplotka/mimic"
 	"github.com/bwplotka/mimic/encoding"
 	kitlog "github.com/go-kit/log"
 	lokiv1 "github.com/grafana/loki/operator/api/loki/v1"
-	"github.com/observatorium/observatorium/configuration_go/kubegen/openshift"
 	templatev1 "github.com/openshift/api/template/v1"
 	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	"github.com/rhobs/configuration/clusters"
@@ -19,129 +18,58 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
-func (b Build) DefaultLokiStack(config clusters.ClusterConfig) {
+func (b Build) DefaultLokiStack(config clusters.ClusterConfig) error {
 	// For rhobss01ue1 and rhobsi01uw2 clusters, generate logs bundle with individual resources
 	if isMigratedCluster(config) {
 		if err := generateLogsBundle(config); err != nil {
-			log.Printf("Error generating logs bundle: %v", err)
+			return fmt.Errorf("failed to generate logs bundle: %w", err)
 		}
-		return
+		return nil
 	}
 
-	gen := b.generator(config, "loki-operator-default-cr")
-	objs := []runtime.Object{
-		NewLokiStack(config.Namespace, config.Templates),
+	r := newTemplateRenderer("loki-rhobs", "loki-operator-default-cr.yaml")
+	stack, err := lokiStack(config, r)
+	if err != nil {
+		return err
 	}
 
-	gen.Add("loki-operator-default-cr.yaml", encoding.GhodssYAML(
-		openshift.WrapInTemplate(
-			objs,
-			metav1.ObjectMeta{Name: "loki-rhobs"},
-			[]templatev1.Parameter{
-				{
-					Name:  "LOKI_SIZE",
-					Value: "1x.extra-small",
-				},
-				{
-					Name:  "LOKI_STORAGE_SECRET_NAME",
-					Value: "loki-default-bucket",
-				},
-				{
-					Name:  "LOKI_STORAGE_CLASS",
-					Value: "gp3-csi",
-				},
-			},
-		),
-	))
-
+	gen := b.generator(config, "loki-operator-default-cr")
+	r.render(gen, []runtime.Object{stack})
 	gen.Generate()
+	return nil
 }
 
-func NewLokiStack(namespace string, overrides clusters.TemplateMaps) *lokiv1.LokiStack {
-	return &lokiv1.LokiStack{
-		TypeMeta: metav1.TypeMeta{
-			APIVersion: "loki.grafana.com/v1",
-			Kind:       "LokiStack",
-		},
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      "observatorium-lokistack",
-			Namespace: namespace,
-		},
-		Spec: lokiv1.LokiStackSpec{
-			Limits: &lokiv1.LimitsSpec{
-				Global: &lokiv1.LimitsTemplateSpec{
-					IngestionLimits: &lokiv1.IngestionLimitSpec{
-						IngestionRate:           overrides.LokiOverrides[clusters.LokiConfig].IngestionRateLimitMB,
-						IngestionBurstSize:      overrides.LokiOverrides[clusters.LokiConfig].IngestionBurstSizeMB,
-						MaxLineSize:             overrides.LokiOverrides[clusters.LokiConfig].MaxLineSize,
-						PerStreamRateLimit:      overrides.LokiOverrides[clusters.LokiConfig].PerStreamRateLimitMB,
-						PerStreamRateLimitBurst: overrides.LokiOverrides[clusters.LokiConfig].PerStreamBurstSizeMB,
-					},
-					QueryLimits: &lokiv1.QueryLimitSpec{
-						QueryTimeout: overrides.LokiOverrides[clusters.LokiConfig].QueryTimeout,
-					},
-					OTLP: &lokiv1.OTLPSpec{
-						StreamLabels: &lokiv1.OTLPStreamLabelSpec{
-							ResourceAttributes: []lokiv1.OTLPAttributeReference{
-								{
-									Name: "k8s.namespace.name",
-								},
-								{
-									Name: "openshift.label.cluster_name",
-								},
-								{
-									Name: "openshift.log.source",
-								},
-								{
-									Name: "openshift.log.type",
-								},
-							},
-						},
-					},
-				},
-			},
-			ManagementState: lokiv1.ManagementStateManaged,
-			Size:            "${LOKI_SIZE}",
-			Storage: lokiv1.ObjectStorageSpec{
-				Schemas: []lokiv1.ObjectStorageSchema{
-					{
-						EffectiveDate: "2025-06-06",
-						Version:       lokiv1.ObjectStorageSchemaV13,
-					},
-				},
-				Secret: lokiv1.ObjectStorageSecretSpec{
-					Name: "${LOKI_STORAGE_SECRET_NAME}",
-					Type: "s3",
-				},
-			},
-			StorageClassName: "${LOKI_STORAGE_CLASS}",
-			Template: &lokiv1.LokiTemplateSpec{
-				Distributor: &lokiv1.LokiComponentSpec{
-					Replicas: overrides.LokiOverrides[clusters.LokiConfig].Router.Replicas,
-				},
-				Ingester: &lokiv1.LokiComponentSpec{
-					Replicas: overrides.LokiOverrides[clusters.LokiConfig].Ingest.Replicas,
-				},
-				Querier: &lokiv1.LokiComponentSpec{
-					Replicas: overrides.LokiOverrides[clusters.LokiConfig].Query.Replicas,
-				},
-				QueryFrontend: &lokiv1.LokiComponentSpec{
-					Replicas: overrides.LokiOverrides[clusters.LokiConfig].QueryFrontend.Replicas,
-				},
-			},
-		},
+// lokiStackName is the name of the LokiStack of every cluster.
+const lokiStackName = "observatorium-lokistack"
+
+// lokiStack returns the cluster's LokiStack with its tenant limits, schemas and rules.
+func lokiStack(config clusters.ClusterConfig, r renderer) (*lokiv1.LokiStack, error) {
+	tenants, err := lokiTenantLimits(config)
+	if err != nil {
+		return nil, err
 	}
+	generated, err := generatedLokiSchemas(config)
+	if err != nil {
+		return nil, err
+	}
+	schemas, err := lokiStackSchemas(config, generated, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	stack := NewLokiStack(config.Namespace, config.Templates, tenants, schemas, r)
+	lokiStackRules(config, stack)
+	return stack, nil
 }
 
-// NewBundleLokiStack creates a LokiStack with concrete values for bundle deployment (no template parameters)
-func NewBundleLokiStack(namespace string, overrides clusters.TemplateMaps) *lokiv1.LokiStack {
+// NewLokiStack returns the LokiStack of every output, the renderer deciding how the storage secret is referenced.
+func NewLokiStack(namespace string, overrides clusters.TemplateMaps, tenants map[string]lokiv1.PerTenantLimitsTemplateSpec, schemas []lokiv1.ObjectStorageSchema, r renderer) *lokiv1.LokiStack {
 	return &lokiv1.LokiStack{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "loki.grafana.com/v1",
 			Kind:       "LokiStack",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "observatorium-lokistack",
+			Name:      lokiStackName,
 			Namespace: namespace,
 		},
 		Spec: lokiv1.LokiStackSpec{
@@ -155,61 +83,147 @@ func NewBundleLokiStack(namespace string, overrides clusters.TemplateMaps) *loki
 						PerStreamRateLimitBurst: overrides.LokiOverrides[clusters.LokiConfig].PerStreamBurstSizeMB,
 					},
 					QueryLimits: &lokiv1.QueryLimitSpec{
-						QueryTimeout: overrides.LokiOverrides[clusters.LokiConfig].QueryTimeout,
-					},
-					OTLP: &lokiv1.OTLPSpec{
-						StreamLabels: &lokiv1.OTLPStreamLabelSpec{
-							ResourceAttributes: []lokiv1.OTLPAttributeReference{
-								{
-									Name: "k8s.namespace.name",
-								},
-								{
-									Name: "openshift.label.cluster_name",
-								},
-								{
-									Name: "openshift.log.source",
-								},
-								{
-									Name: "openshift.log.type",
-								},
-							},
-						},
+						QueryTimeout:            overrides.LokiOverrides[clusters.LokiConfig].QueryTimeout,
+						MaxQuerySeries:          overrides.LokiOverrides[clusters.LokiConfig].MaxQuerySeries,
+						MaxChunksPerQuery:       overrides.LokiOverrides[clusters.LokiConfig].MaxChunksPerQuery,
+						MaxEntriesLimitPerQuery: overrides.LokiOverrides[clusters.LokiConfig].MaxEntriesLimitPerQuery,
 					},
+					OTLP:      lokiOTLPSpec(overrides.LokiOverrides[clusters.LokiConfig].OTLP),
+					Retention: lokiRetention(overrides.LokiOverrides[clusters.LokiConfig].Retention),
 				},
+				Tenants: tenants,
 			},
 			ManagementState: lokiv1.ManagementStateManaged,
-			Size:            "1x.extra-small",
+			Size:            overrides.LokiOverrides[clusters.LokiConfig].Size,
 			Storage: lokiv1.ObjectStorageSpec{
-				Schemas: []lokiv1.ObjectStorageSchema{
-					{
-						EffectiveDate: "2025-06-06",
-						Version:       lokiv1.ObjectStorageSchemaV13,
-					},
-				},
+				Schemas: schemas,
 				Secret: lokiv1.ObjectStorageSecretSpec{
-					Name: "loki-default-bucket",
-					Type: "s3",
-				},
-			},
-			StorageClassName: "gp3-csi", // Concrete value instead of ${LOKI_STORAGE_CLASS}
-			Template: &lokiv1.LokiTemplateSpec{
-				Distributor: &lokiv1.LokiComponentSpec{
-					Replicas: overrides.LokiOverrides[clusters.LokiConfig].Router.Replicas,
-				},
-				Ingester: &lokiv1.LokiComponentSpec{
-					Replicas: overrides.LokiOverrides[clusters.LokiConfig].Ingest.Replicas,
-				},
-				Querier: &lokiv1.LokiComponentSpec{
-					Replicas: overrides.LokiOverrides[clusters.LokiConfig].Query.Replicas,
-				},
-				QueryFrontend: &lokiv1.LokiComponentSpec{
-					Replicas: overrides.LokiOverrides[clusters.LokiConfig].QueryFrontend.Replicas,
+					Name: r.param(templatev1.Parameter{Name: "LOKI_STORAGE_SECRET_NAME", Value: clusters.TemplateFn(clusters.LokiBucket, overrides.ObjectStorageBucket).Name}),
+					Type: lokiStorageSecretType(overrides),
 				},
 			},
+			StorageClassName: overrides.LokiOverrides[clusters.LokiConfig].StorageClass,
+			Template:         lokiTemplateSpec(overrides.LokiOverrides[clusters.LokiConfig]),
 		},
 	}
 }
 
+// lokiTenantLimits returns the per-tenant limits of the LokiStack.
+// Tenants are keyed by their ID, as that is the org ID the gateway forwards to Loki.
+func lokiTenantLimits(config clusters.ClusterConfig) (map[string]lokiv1.PerTenantLimitsTemplateSpec, error) {
+	overrides := config.Templates.LokiOverrides[clusters.LokiConfig].Tenants
+	if len(overrides) == 0 {
+		return nil, nil
+	}
+	if config.GatewayConfig == nil {
+		return nil, fmt.Errorf("loki limits are keyed by gateway tenant but cluster %s has no gateway", config.Name)
+	}
+
+	tenants := make(map[string]lokiv1.PerTenantLimitsTemplateSpec, len(overrides))
+	for name, limits := range overrides {
+		id, ok := config.GatewayConfig.TenantID(name)
+		if !ok {
+			return nil, fmt.Errorf("loki limits reference tenant %q, which is not registered in the gateway", name)
+		}
+		spec := lokiv1.PerTenantLimitsTemplateSpec{
+			OTLP:      lokiOTLPSpec(limits.OTLP),
+			Retention: lokiRetention(limits.Retention),
+		}
+		ingestion := lokiv1.IngestionLimitSpec{
+			IngestionRate:           limits.IngestionRateLimitMB,
+			IngestionBurstSize:      limits.IngestionBurstSizeMB,
+			MaxLineSize:             limits.MaxLineSize,
+			PerStreamRateLimit:      limits.PerStreamRateLimitMB,
+			PerStreamRateLimitBurst: limits.PerStreamBurstSizeMB,
+		}
+		if ingestion != (lokiv1.IngestionLimitSpec{}) {
+			spec.IngestionLimits = &ingestion
+		}
+		queries := lokiv1.QueryLimitSpec{
+			QueryTimeout:            limits.QueryTimeout,
+			MaxQuerySeries:          limits.MaxQuerySeries,
+			MaxChunksPerQuery:       limits.MaxChunksPerQuery,
+			MaxEntriesLimitPerQuery: limits.MaxEntriesLimitPerQuery,
+		}
+		if queries != (lokiv1.QueryLimitSpec{}) {
+			spec.QueryLimits = &lokiv1.PerTenantQueryLimitSpec{QueryLimitSpec: queries}
+		}
+		tenants[id] = spec
+	}
+	return tenants, nil
+}
+
+// lokiTemplateSpec sizes and places the components of the LokiStack.
+// Components without settings are left out for the operator to size them from the preset.
+func lokiTemplateSpec(overrides clusters.LokiOverrides) *lokiv1.LokiTemplateSpec {
+	return &lokiv1.LokiTemplateSpec{
+		UseRequestsAsLimits: overrides.UseRequestsAsLimits,
+		Compactor:           lokiComponentSpec(overrides.Compactor),
+		Distributor:         lokiComponentSpec(overrides.Router),
+		Ingester:            lokiComponentSpec(overrides.Ingest),
+		Querier:             lokiComponentSpec(overrides.Query),
+		QueryFrontend:       lokiComponentSpec(overrides.QueryFrontend),
+		Gateway:             lokiComponentSpec(overrides.Gateway),
+		IndexGateway:        lokiComponentSpec(overrides.IndexGateway),
+		Ruler:               lokiComponentSpec(overrides.Ruler),
+	}
+}
+
+func lokiComponentSpec(c clusters.LokiComponentSpec) *lokiv1.LokiComponentSpec {
+	if c.Replicas == 0 && len(c.NodeSelector) == 0 && len(c.Tolerations) == 0 && c.PodAntiAffinity == nil {
+		return nil
+	}
+	return &lokiv1.LokiComponentSpec{
+		Replicas:        c.Replicas,
+		NodeSelector:    c.NodeSelector,
+		Tolerations:     c.Tolerations,
+		PodAntiAffinity: c.PodAntiAffinity,
+	}
+}
+
+// lokiRetention returns nil without retention, for the operator to leave it disabled
+func lokiRetention(r *clusters.LokiRetention) *lokiv1.RetentionLimitSpec {
+	if r == nil {
+		return nil
+	}
+	spec := &lokiv1.RetentionLimitSpec{Days: r.Days}
+	for _, stream := range r.Streams {
+		spec.Streams = append(spec.Streams, &lokiv1.RetentionStreamSpec{
+			Days:     stream.Days,
+			Priority: stream.Priority,
+			Selector: stream.Selector,
+		})
+	}
+	return spec
+}
+
+// lokiOTLPSpec returns nil without a mapping, for the operator to apply its defaults.
+func lokiOTLPSpec(o *clusters.LokiOTLP) *lokiv1.OTLPSpec {
+	if o == nil {
+		return nil
+	}
+	spec := &lokiv1.OTLPSpec{}
+	if len(o.StreamLabels) > 0 {
+		spec.StreamLabels = &lokiv1.OTLPStreamLabelSpec{ResourceAttributes: lokiOTLPAttributes(o.StreamLabels)}
+	}
+	if len(o.DropResourceAttributes) > 0 || len(o.DropScopeAttributes) > 0 || len(o.DropLogAttributes) > 0 {
+		spec.Drop = &lokiv1.OTLPMetadataSpec{
+			ResourceAttributes: lokiOTLPAttributes(o.DropResourceAttributes),
+			ScopeAttributes:    lokiOTLPAttributes(o.DropScopeAttributes),
+			LogAttributes:      lokiOTLPAttributes(o.DropLogAttributes),
+		}
+	}
+	return spec
+}
+
+func lokiOTLPAttributes(attrs []clusters.LokiOTLPAttribute) []lokiv1.OTLPAttributeReference {
+	var refs []lokiv1.OTLPAttributeReference
+	for _, attr := range attrs {
+		refs = append(refs, lokiv1.OTLPAttributeReference{Name: attr.Name, Regex: attr.Regex})
+	}
+	return refs
+}
+
 // generateLogsBundle generates individual Loki component resources for bundle deployment
 func generateLogsBundle(config clusters.ClusterConfig) error {
 	ns := config.Namespace
@@ -220,14 +234,12 @@ func generateLogsBundle(config clusters.ClusterConfig) error {
 	bundleGen.Logger = kitlog.NewLogfmtLogger(kitlog.NewSyncWriter(os.Stdout))
 
 	// 1. CRDs (prefix: 01-*)
-	crdObjs := getLokiCRDObjects()
-	crdNames := []string{"projectconfigs", "alertingrules", "lokistacks", "recordingrules", "rulerconfigs"}
+	crdObjs, err := upstreamCRDObjects(lokiOperatorUpstream(lokiOperatorCRDRef), lokiOperatorCRDs)
+	if err != nil {
+		return fmt.Errorf("failed to read CRDs: %w", err)
+	}
 	for i, crd := range crdObjs {
-		crdName := "unknown"
-		if i < len(crdNames) {
-			crdName = crdNames[i]
-		}
-		filename := fmt.Sprintf("01-crd-%s.yaml", crdName)
+		filename := fmt.Sprintf("01-crd-%s.yaml", lokiOperatorCRDs[i].name)
 		bundleGen.Add(filename, encoding.GhodssYAML(crd))
 	}
 
@@ -241,21 +253,29 @@ func generateLogsBundle(config clusters.ClusterConfig) error {
 	}
 
 	// 3. LOKISTACK RESOURCES (prefix: 03-*)
-	lokiStackObjs := make([]runtime.Object, 0, 1)
-	lokiStackObjs = append(lokiStackObjs, NewBundleLokiStack(ns, config.Templates))
-
-	for _, obj := range lokiStackObjs {
-		resourceKind := getResourceKind(obj)
-		resourceName := getLokiResourceName(obj)
-		// Clean up names and remove redundant prefixes
-		resourceName = strings.TrimPrefix(resourceName, "observatorium-")
-		filename := fmt.Sprintf("03-%s-%s.yaml", resourceName, resourceKind)
-		bundleGen.Add(filename, encoding.GhodssYAML(obj))
+	r := bundleRenderer{
+		filename: func(_ int, obj runtime.Object) string {
+			// Clean up names and remove redundant prefixes
+			resourceName := strings.TrimPrefix(getLokiResourceName(obj), "observatorium-")
+			return fmt.Sprintf("03-%s-%s.yaml", resourceName, getResourceKind(obj))
+		},
+	}
+	stack, err := lokiStack(config, r)
+	if err != nil {
+		return err
 	}
+	r.render(bundleGen, []runtime.Object{stack})
 
 	// Generate the bundle files
 	bundleGen.Generate()
 
+	// The storage secret references its credentials, it is templated next to the bundle
+	templatesGen := &mimic.Generator{}
+	templatesGen = templatesGen.With("resources", "clusters", string(config.Environment), string(config.Name), "logs", "templates")
+	templatesGen.Logger = kitlog.NewLogfmtLogger(kitlog.NewSyncWriter(os.Stdout))
+	addLokiObjectStoreSecretTemplate(templatesGen, config)
+	templatesGen.Generate()
+
 	// Add consolidated ServiceMonitors to monitoring bundle
 	monBundle := GetMonitoringBundle(config)
 	lokiServiceMonitors := createConsolidatedLokiServiceMonitors(ns)
@@ -269,29 +289,6 @@ func generateLogsBundle(config clusters.ClusterConfig) error {
 	return nil
 }
 
-// getLokiCRDObjects retrieves Loki operator CRDs
-func getLokiCRDObjects() []runtime.Object {
-	const (
-		projectconfigs = "config.grafana.com_projectconfigs.yaml"
-		alertingrules  = "loki.grafana.com_alertingrules.yaml"
-		lokistacks     = "loki.grafana.com_lokistacks.yaml"
-		recordingrules = "loki.grafana.com_recordingrules.yaml"
-		rulerconfigs   = "loki.grafana.com_rulerconfigs.yaml"
-		base           = "https://raw.githubusercontent.com/openshift/loki/" + lokiOperatorCRDRef + "/operator/config/crd/bases/"
-	)
-
-	var objs []runtime.Object
-	for _, component := range []string{projectconfigs, alertingrules, lokistacks, recordingrules, rulerconfigs} {
-		crd, err := getCustomResourceDefinition(base + component)
-		if err != nil {
-			log.Printf("Error fetching CRD %s: %v", component, err)
-			continue
-		}
-		objs = append(objs, crd)
-	}
-	return objs
-}
-
 // getLokiResourceName extracts a meaningful name from a Loki Kubernetes object
 func getLokiResourceName(obj runtime.Object) string {
 	if obj == nil {
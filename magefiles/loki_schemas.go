@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	lokiv1 "github.com/grafana/loki/operator/api/loki/v1"
+	"github.com/magefile/mage/mg"
+	"github.com/rhobs/configuration/clusters"
+)
+
+type (
+	Loki mg.Namespace
+)
+
+// lokiSchemasBootstrapEnv set to true lets the build render the schemas of a cluster whose LokiStack was never
+// generated, for its first build. Otherwise the schemas could not be checked against the ones in effect.
+const lokiSchemasBootstrapEnv = "LOKI_SCHEMAS_BOOTSTRAP"
+
+// lokiSchemaLeadTime is how far ahead of the build a new schema must take effect, for the build to roll out
+// to stage and production before. An ingester still on the old schema past that date writes data Loki cannot read back.
+const lokiSchemaLeadTime = 7 * 24 * time.Hour
+
+// AddSchema appends a schema of the given version, such as v13, to the LokiStack schema history of the cluster.
+// It takes effect on the first day past the rollout lead time.
+func (Loki) AddSchema(cluster, version string) error {
+	config, err := clusters.GetClusterByName(clusters.ClusterName(cluster))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	schema := clusters.LokiSchema{
+		EffectiveDate: firstLokiSchemaDate(now).Format(lokiv1.StorageSchemaEffectiveDateFormat),
+		Version:       lokiv1.ObjectStorageSchemaVersion(version),
+	}
+	updated := *config
+	updated.LokiSchemas = append(slices.Clone(config.LokiSchemas), schema)
+	if err := updated.Validate(); err != nil {
+		return err
+	}
+	generated, err := generatedLokiSchemas(updated)
+	if err != nil {
+		return err
+	}
+	if _, err := lokiStackSchemas(updated, generated, now); err != nil {
+		return err
+	}
+
+	filename, err := appendLokiSchemaSource("clusters", updated.Name, schema)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "Added Loki schema %s effective from %s to %s, rebuild the cluster to render it\n",
+		schema.Version, schema.EffectiveDate, filename)
+	return nil
+}
+
+// firstLokiSchemaDate returns the first 00:00 UTC past the rollout lead time.
+func firstLokiSchemaDate(now time.Time) time.Time {
+	earliest := now.UTC().Add(lokiSchemaLeadTime)
+	date := time.Date(earliest.Year(), earliest.Month(), earliest.Day(), 0, 0, 0, 0, time.UTC)
+	if date.Before(earliest) {
+		date = date.AddDate(0, 0, 1)
+	}
+	return date
+}
+
+// lokiStackSchemas returns the schemas of the cluster's LokiStack after checking them against the ones last generated.
+// Schemas in effect at build time must be kept as generated, and schemas not generated yet must take effect past the
+// rollout lead time, unless nothing was generated for the cluster being bootstrapped.
+func lokiStackSchemas(config clusters.ClusterConfig, generated []lokiv1.ObjectStorageSchema, now time.Time) ([]lokiv1.ObjectStorageSchema, error) {
+	schemas := make([]lokiv1.ObjectStorageSchema, 0, len(config.LokiSchemas))
+	for _, schema := range config.LokiSchemas {
+		schemas = append(schemas, lokiv1.ObjectStorageSchema{
+			EffectiveDate: lokiv1.StorageSchemaEffectiveDate(schema.EffectiveDate),
+			Version:       schema.Version,
+		})
+	}
+
+	if generated == nil {
+		// Nothing was written with any schema on a cluster never generated.
+		return schemas, nil
+	}
+	for i, schema := range generated {
+		date, err := schema.EffectiveDate.UTCTime()
+		if err != nil {
+			return nil, fmt.Errorf("generated schema %s: %w", schema.EffectiveDate, err)
+		}
+		if date.After(now) {
+			continue
+		}
+		if i >= len(schemas) || schemas[i] != schema {
+			return nil, fmt.Errorf("cluster %s: schema %s %s is in effect and cannot be edited or removed",
+				config.Name, schema.Version, schema.EffectiveDate)
+		}
+	}
+
+	earliest := firstLokiSchemaDate(now)
+	for _, schema := range schemas {
+		if slices.Contains(generated, schema) {
+			continue
+		}
+		date, err := schema.EffectiveDate.UTCTime()
+		if err != nil {
+			return nil, fmt.Errorf("schema %s: %w", schema.EffectiveDate, err)
+		}
+		if date.Before(earliest) {
+			return nil, fmt.Errorf("cluster %s: new schema %s must take effect on %s or later, %d days ahead of the build",
+				config.Name, schema.EffectiveDate, earliest.Format(lokiv1.StorageSchemaEffectiveDateFormat), int(lokiSchemaLeadTime.Hours()/24))
+		}
+	}
+	return schemas, nil
+}
+
+// generatedLokiSchemas reads the schemas of the LokiStack last generated for the cluster. A cluster without one
+// fails the build, unless it is bootstrapped with lokiSchemasBootstrapEnv, nil is then returned.
+func generatedLokiSchemas(config clusters.ClusterConfig) ([]lokiv1.ObjectStorageSchema, error) {
+	dir := filepath.Join(templatePath, templateClustersPath, string(config.Environment), string(config.Name))
+	pattern := filepath.Join(dir, "loki-operator-default-cr", "*.yaml")
+	if isMigratedCluster(config) {
+		pattern = filepath.Join(dir, "logs", "bundle", "*.yaml")
+	}
+	schemas, found, err := findLokiStackSchemas(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the generated loki schemas: %w", err)
+	}
+	if found {
+		return schemas, nil
+	}
+	if os.Getenv(lokiSchemasBootstrapEnv) == "true" {
+		return nil, nil
+	}
+	return nil, fmt.Errorf("cluster %s: no generated LokiStack in %s to check the schemas against, set %s=true for the first build of the cluster",
+		config.Name, filepath.Dir(pattern), lokiSchemasBootstrapEnv)
+}
+
+// findLokiStackSchemas returns the schemas of the LokiStack found by kind in the files matching the pattern,
+// either as a manifest or as an object of a template.
+func findLokiStackSchemas(pattern string) ([]lokiv1.ObjectStorageSchema, bool, error) {
+	type object struct {
+		Kind string               `json:"kind"`
+		Spec lokiv1.LokiStackSpec `json:"spec"`
+	}
+
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, false, err
+		}
+		var manifest struct {
+			object
+			Objects []object `json:"objects"`
+		}
+		if err := yaml.Unmarshal(b, &manifest); err != nil {
+			return nil, false, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		for _, obj := range append([]object{manifest.object}, manifest.Objects...) {
+			if obj.Kind == "LokiStack" {
+				return obj.Spec.Storage.Schemas, true, nil
+			}
+		}
+	}
+	return nil, false, nil
+}
+
+// appendLokiSchemaSource appends the schema to the LokiSchemas of the cluster's registration in the Go files of dir,
+// the clusters package, and returns the file it edited.
+func appendLokiSchemaSource(dir string, name clusters.ClusterName, schema clusters.LokiSchema) (string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return "", err
+	}
+	for _, filename := range files {
+		src, err := os.ReadFile(filename)
+		if err != nil {
+			return "", err
+		}
+		fset := token.NewFileSet()
+		node, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse %s: %w", filename, err)
+		}
+
+		schemas := findLokiSchemasLiteral(node, name)
+		if schemas == nil {
+			continue
+		}
+
+		entry := fmt.Sprintf("{EffectiveDate: %q, Version: lokiv1.ObjectStorageSchema%s},\n",
+			schema.EffectiveDate, strings.ToUpper(string(schema.Version)))
+		end := fset.Position(schemas.Rbrace).Offset
+		if last := bytes.TrimSpace(src[:end]); len(last) > 0 && last[len(last)-1] != ',' && last[len(last)-1] != '{' {
+			entry = ",\n" + entry
+		}
+		out, err := format.Source(append(append(bytes.Clone(src[:end]), entry...), src[end:]...))
+		if err != nil {
+			return "", fmt.Errorf("failed to format %s: %w", filename, err)
+		}
+		return filename, os.WriteFile(filename, out, 0644)
+	}
+	return "", fmt.Errorf("no LokiSchemas found in the registration of cluster %s", name)
+}
+
+// findLokiSchemasLiteral returns the LokiSchemas of the ClusterConfig literal named after the cluster.
+// Names are string literals or constants declared in the same file.
+func findLokiSchemasLiteral(node *ast.File, name clusters.ClusterName) *ast.CompositeLit {
+	consts := map[string]string{}
+	for _, decl := range node.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			valueSpec := spec.(*ast.ValueSpec)
+			for i, ident := range valueSpec.Names {
+				if i >= len(valueSpec.Values) {
+					continue
+				}
+				if lit, ok := valueSpec.Values[i].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+					consts[ident.Name], _ = strconv.Unquote(lit.Value)
+				}
+			}
+		}
+	}
+
+	var schemas *ast.CompositeLit
+	ast.Inspect(node, func(n ast.Node) bool {
+		lit, ok := n.(*ast.CompositeLit)
+		if !ok {
+			return schemas == nil
+		}
+		if ident, ok := lit.Type.(*ast.Ident); !ok || ident.Name != "ClusterConfig" {
+			return true
+		}
+
+		var (
+			clusterName string
+			found       *ast.CompositeLit
+		)
+		for _, elt := range lit.Elts {
+			kv, ok := elt.(*ast.KeyValueExpr)
+			if !ok {
+				continue
+			}
+			key, ok := kv.Key.(*ast.Ident)
+			if !ok {
+				continue
+			}
+			switch key.Name {
+			case "Name":
+				switch v := kv.Value.(type) {
+				case *ast.Ident:
+					clusterName = consts[v.Name]
+				case *ast.BasicLit:
+					clusterName, _ = strconv.Unquote(v.Value)
+				}
+			case "LokiSchemas":
+				found, _ = kv.Value.(*ast.CompositeLit)
+			}
+		}
+		if clusterName == string(name) && found != nil {
+			schemas = found
+		}
+		return false
+	})
+	return schemas
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	lokiv1 "github.com/grafana/loki/operator/api/loki/v1"
+	"github.com/rhobs/configuration/clusters"
+)
+
+func TestFirstLokiSchemaDate(t *testing.T) {
+	for _, tc := range []struct {
+		now  string
+		want string
+	}{
+		{now: "2025-06-10T12:00:00Z", want: "2025-06-18"},
+		{now: "2025-06-10T00:00:00Z", want: "2025-06-17"},
+		{now: "2025-06-11T01:00:00+02:00", want: "2025-06-18"},
+	} {
+		t.Run(tc.now, func(t *testing.T) {
+			now, err := time.Parse(time.RFC3339, tc.now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := firstLokiSchemaDate(now).Format(lokiv1.StorageSchemaEffectiveDateFormat); got != tc.want {
+				t.Fatalf("got %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLokiStackSchemas(t *testing.T) {
+	now := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+	v12 := clusters.LokiSchema{EffectiveDate: "2024-01-01", Version: lokiv1.ObjectStorageSchemaV12}
+	v13 := clusters.LokiSchema{EffectiveDate: "2025-01-01", Version: lokiv1.ObjectStorageSchemaV13}
+	generated := []lokiv1.ObjectStorageSchema{
+		{EffectiveDate: "2024-01-01", Version: lokiv1.ObjectStorageSchemaV12},
+		{EffectiveDate: "2025-01-01", Version: lokiv1.ObjectStorageSchemaV13},
+	}
+
+	for _, tc := range []struct {
+		name      string
+		schemas   []clusters.LokiSchema
+		generated []lokiv1.ObjectStorageSchema
+		err       string
+	}{
+		{
+			name:      "unchanged",
+			schemas:   []clusters.LokiSchema{v12, v13},
+			generated: generated,
+		},
+		{
+			name:    "bootstrapped cluster",
+			schemas: []clusters.LokiSchema{{EffectiveDate: "2025-06-11", Version: lokiv1.ObjectStorageSchemaV13}},
+		},
+		{
+			name:      "edited past entry",
+			schemas:   []clusters.LokiSchema{v12, {EffectiveDate: "2025-01-01", Version: lokiv1.ObjectStorageSchemaV12}},
+			generated: generated,
+			err:       "schema v13 2025-01-01 is in effect and cannot be edited or removed",
+		},
+		{
+			name:      "removed past entry",
+			schemas:   []clusters.LokiSchema{v12},
+			generated: generated,
+			err:       "schema v13 2025-01-01 is in effect and cannot be edited or removed",
+		},
+		{
+			name:      "non-monotonic dates",
+			schemas:   []clusters.LokiSchema{v13, v12},
+			generated: generated,
+			err:       "schema v12 2024-01-01 is in effect and cannot be edited or removed",
+		},
+		{
+			name: "entry inside the lead time",
+			schemas: []clusters.LokiSchema{v12, v13,
+				{EffectiveDate: "2025-06-17", Version: lokiv1.ObjectStorageSchemaV13}},
+			generated: generated,
+			err:       "new schema 2025-06-17 must take effect on 2025-06-18 or later, 7 days ahead of the build",
+		},
+		{
+			name: "entry past the lead time",
+			schemas: []clusters.LokiSchema{v12, v13,
+				{EffectiveDate: "2025-06-18", Version: lokiv1.ObjectStorageSchemaV13}},
+			generated: generated,
+		},
+		{
+			name: "generated entry not in effect yet moved past the lead time",
+			schemas: []clusters.LokiSchema{v12, v13,
+				{EffectiveDate: "2025-07-02", Version: lokiv1.ObjectStorageSchemaV13}},
+			generated: append(slices.Clone(generated),
+				lokiv1.ObjectStorageSchema{EffectiveDate: "2025-07-01", Version: lokiv1.ObjectStorageSchemaV13}),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config := clusters.ClusterConfig{Name: "test", LokiSchemas: tc.schemas}
+			schemas, err := lokiStackSchemas(config, tc.generated, now)
+			if tc.err == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if len(schemas) != len(tc.schemas) {
+					t.Fatalf("got %d schemas, want %d", len(schemas), len(tc.schemas))
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("expected an error containing %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestFindLokiStackSchemas(t *testing.T) {
+	stack := `apiVersion: loki.grafana.com/v1
+kind: LokiStack
+metadata:
+  name: observatorium-lokistack
+spec:
+  storage:
+    schemas:
+    - effectiveDate: "2025-06-06"
+      version: v13
+`
+	template := `apiVersion: template.openshift.io/v1
+kind: Template
+objects:
+- ` + strings.ReplaceAll(strings.TrimSuffix(stack, "\n"), "\n", "\n  ") + "\n"
+	want := []lokiv1.ObjectStorageSchema{{EffectiveDate: "2025-06-06", Version: lokiv1.ObjectStorageSchemaV13}}
+
+	for _, tc := range []struct {
+		name  string
+		files map[string]string
+		found bool
+	}{
+		{
+			name: "bundle",
+			files: map[string]string{
+				"01-crd-lokistacks.yaml":      "apiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\n",
+				"04-renamed-LokiStack.yaml":   stack,
+				"05-tenant-rules-ruler.yaml":  "apiVersion: loki.grafana.com/v1\nkind: RulerConfig\n",
+				"02-operator-Deployment.yaml": "apiVersion: apps/v1\nkind: Deployment\n",
+			},
+			found: true,
+		},
+		{
+			name:  "template",
+			files: map[string]string{"loki-operator-default-cr.yaml": template},
+			found: true,
+		},
+		{
+			name:  "no LokiStack",
+			files: map[string]string{"01-crd-lokistacks.yaml": "apiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\n"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tc.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			schemas, found, err := findLokiStackSchemas(filepath.Join(dir, "*.yaml"))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if found != tc.found {
+				t.Fatalf("got found %t, want %t", found, tc.found)
+			}
+			if tc.found && !slices.Equal(schemas, want) {
+				t.Fatalf("got schemas %v, want %v", schemas, want)
+			}
+		})
+	}
+}
+
+func TestAppendLokiSchemaSource(t *testing.T) {
+	schema := clusters.LokiSchema{EffectiveDate: "2025-06-18", Version: lokiv1.ObjectStorageSchemaV13}
+
+	for _, tc := range []struct {
+		name    string
+		cluster clusters.ClusterName
+		src     string
+		want    string
+		err     string
+	}{
+		{
+			name:    "multi-line schemas of a cluster named by a constant",
+			cluster: "test",
+			src: `package clusters
+
+const clusterName ClusterName = "test"
+
+func init() {
+	RegisterCluster(ClusterConfig{
+		Name: "other",
+		LokiSchemas: []LokiSchema{
+			{EffectiveDate: "2024-01-01", Version: lokiv1.ObjectStorageSchemaV12},
+		},
+	})
+	RegisterCluster(ClusterConfig{
+		Name: clusterName,
+		LokiSchemas: []LokiSchema{
+			{EffectiveDate: "2024-01-01", Version: lokiv1.ObjectStorageSchemaV12},
+		},
+	})
+}
+`,
+			want: `package clusters
+
+const clusterName ClusterName = "test"
+
+func init() {
+	RegisterCluster(ClusterConfig{
+		Name: "other",
+		LokiSchemas: []LokiSchema{
+			{EffectiveDate: "2024-01-01", Version: lokiv1.ObjectStorageSchemaV12},
+		},
+	})
+	RegisterCluster(ClusterConfig{
+		Name: clusterName,
+		LokiSchemas: []LokiSchema{
+			{EffectiveDate: "2024-01-01", Version: lokiv1.ObjectStorageSchemaV12},
+			{EffectiveDate: "2025-06-18", Version: lokiv1.ObjectStorageSchemaV13},
+		},
+	})
+}
+`,
+		},
+		{
+			name:    "single-line schemas",
+			cluster: "test",
+			src: `package clusters
+
+func init() {
+	RegisterCluster(ClusterConfig{Name: "test", LokiSchemas: []LokiSchema{{EffectiveDate: "2024-01-01", Version: lokiv1.ObjectStorageSchemaV12}}})
+}
+`,
+			want: `package clusters
+
+func init() {
+	RegisterCluster(ClusterConfig{Name: "test", LokiSchemas: []LokiSchema{{EffectiveDate: "2024-01-01", Version: lokiv1.ObjectStorageSchemaV12},
+		{EffectiveDate: "2025-06-18", Version: lokiv1.ObjectStorageSchemaV13},
+	}})
+}
+`,
+		},
+		{
+			name:    "unregistered cluster",
+			cluster: "unregistered",
+			src: `package clusters
+
+func init() {
+	RegisterCluster(ClusterConfig{Name: "test", LokiSchemas: []LokiSchema{}})
+}
+`,
+			err: "no LokiSchemas found in the registration of cluster unregistered",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			filename := filepath.Join(dir, "cluster.go")
+			if err := os.WriteFile(filename, []byte(tc.src), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			edited, err := appendLokiSchemaSource(dir, tc.cluster, schema)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expected an error containing %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if edited != filename {
+				t.Fatalf("edited %s, want %s", edited, filename)
+			}
+			got, err := os.ReadFile(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tc.want {
+				t.Fatalf("unexpected source:\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+}
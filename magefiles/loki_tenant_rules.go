@@ -0,0 +1,347 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bwplotka/mimic"
+	"github.com/bwplotka/mimic/encoding"
+	"github.com/ghodss/yaml"
+	kitlog "github.com/go-kit/log"
+	lokiv1 "github.com/grafana/loki/operator/api/loki/v1"
+	"github.com/prometheus/common/model"
+	"github.com/rhobs/configuration/clusters"
+	"github.com/rhobs/configuration/internal/logql"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// lokiRulesPath holds a directory of LogQL rule files per tenant, named after the tenant in the gateway.
+	// Each file holds rule groups in the format of the Loki ruler.
+	lokiRulesPath = "resources/loki-tenant-rules"
+
+	// lokiRuleLabel selects the AlertingRules and RecordingRules the LokiStack ruler loads.
+	lokiRuleLabel = "rhobs.observatorium.io/loki-rule"
+
+	lokiRulerEvaluationInterval = "1m"
+	lokiRulerPollInterval       = "1m"
+	// lokiRuleGroupInterval is the interval of the rule groups without one, the default of the CRDs.
+	lokiRuleGroupInterval lokiv1.PrometheusDuration = "1m"
+)
+
+// lokiRuleFile is a LogQL rule file of a tenant.
+type lokiRuleFile struct {
+	Groups []lokiRuleGroup `json:"groups"`
+}
+
+type lokiRuleGroup struct {
+	Name     string                    `json:"name"`
+	Interval lokiv1.PrometheusDuration `json:"interval,omitempty"`
+	Limit    int32                     `json:"limit,omitempty"`
+	Rules    []lokiRule                `json:"rules"`
+}
+
+type lokiRule struct {
+	Alert       string                    `json:"alert,omitempty"`
+	Record      string                    `json:"record,omitempty"`
+	Expr        string                    `json:"expr"`
+	For         lokiv1.PrometheusDuration `json:"for,omitempty"`
+	Labels      map[string]string         `json:"labels,omitempty"`
+	Annotations map[string]string         `json:"annotations,omitempty"`
+}
+
+// LokiTenantRules generates the RulerConfig of the LokiStack of the cluster, and the AlertingRules and RecordingRules of
+// the tenants it hosts. A tenant is hosted if it is registered in the gateway of the cluster and has rule files
+// under resources/loki-tenant-rules.
+func (b Build) LokiTenantRules(config clusters.ClusterConfig) error {
+	rules, err := clusterLokiTenantRules(config)
+	if err != nil {
+		return err
+	}
+
+	gen := b.generator(config, "loki-tenant-rules")
+	prefix := ""
+	if isMigratedCluster(config) {
+		// The rules follow the LokiStack of the logs bundle, see generateLogsBundle
+		gen = &mimic.Generator{}
+		gen = gen.With(templatePath, templateClustersPath, string(config.Environment), string(config.Name), "logs", "bundle")
+		gen.Logger = kitlog.NewLogfmtLogger(kitlog.NewSyncWriter(os.Stdout))
+		prefix = "04-tenant-rules-"
+	}
+	gen.Add(prefix+lokiStackName+"-RulerConfig.yaml", encoding.GhodssYAML(newLokiRulerConfig(config.Namespace)))
+	for _, rule := range rules {
+		gen.Add(fmt.Sprintf("%s%s-%s.yaml", prefix, getKubernetesResourceName(rule), getResourceKind(rule)), encoding.GhodssYAML(rule))
+	}
+	gen.Generate()
+	return nil
+}
+
+// lokiStackRules enables the ruler of the LokiStack for the clusters with the loki-tenant-rules build step.
+// The ruler loads the rules of the LokiStack namespace only.
+func lokiStackRules(config clusters.ClusterConfig, stack *lokiv1.LokiStack) {
+	if !slices.Contains(config.BuildSteps, clusters.StepLokiTenantRules) {
+		return
+	}
+	stack.Spec.Rules = &lokiv1.RulesSpec{
+		Enabled: true,
+		Selector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{lokiRuleLabel: "true"},
+		},
+	}
+}
+
+// newLokiRulerConfig sends the alerts of the LokiStack ruler to every Alertmanager replica of the cluster,
+// discovered through the SRV records of the headless service like the Thanos ruler does.
+// Its name must be the name of the LokiStack.
+func newLokiRulerConfig(namespace string) *lokiv1.RulerConfig {
+	return &lokiv1.RulerConfig{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "loki.grafana.com/v1",
+			Kind:       "RulerConfig",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      lokiStackName,
+			Namespace: namespace,
+		},
+		Spec: lokiv1.RulerConfigSpec{
+			EvalutionInterval: lokiRulerEvaluationInterval,
+			PollInterval:      lokiRulerPollInterval,
+			AlertManagerSpec: &lokiv1.AlertManagerSpec{
+				EnableV2: true,
+				// Loki takes the SRV name in the host of the endpoint rather than a dnssrv+ scheme.
+				DiscoverySpec: &lokiv1.AlertManagerDiscoverySpec{EnableSRV: true},
+				Endpoints:     []string{fmt.Sprintf("http://_http._tcp.%s-cluster.%s.svc.cluster.local", alertManagerName, namespace)},
+			},
+		},
+	}
+}
+
+// clusterLokiTenantRules loads and validates the LogQL rules of every tenant hosted by the cluster.
+func clusterLokiTenantRules(config clusters.ClusterConfig) ([]runtime.Object, error) {
+	if config.GatewayConfig == nil {
+		return nil, nil
+	}
+
+	var rules []runtime.Object
+	for _, tenant := range config.GatewayConfig.Tenants().Tenants {
+		files, err := lokiTenantRuleFiles(tenant.Name)
+		if err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			continue
+		}
+		tenantRules, err := loadLokiTenantRules(files, config.Namespace, tenant.Name, tenant.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the LogQL rules of tenant %s: %w", tenant.Name, err)
+		}
+		rules = append(rules, tenantRules...)
+	}
+	return rules, nil
+}
+
+// lokiTenantRuleFiles returns the LogQL rule files of the tenant in lexical order.
+func lokiTenantRuleFiles(tenant string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(lokiRulesPath, tenant, "*.yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the LogQL rules of tenant %s: %w", tenant, err)
+	}
+	return files, nil
+}
+
+// loadLokiTenantRules turns every rule file of a tenant into an AlertingRule for its alerting groups and a RecordingRule
+// for its recording groups, both named after the tenant and the file. A group either alerts or records.
+func loadLokiTenantRules(files []string, namespace, tenant, tenantID string) ([]runtime.Object, error) {
+	var rules []runtime.Object
+	var errs []error
+	// The ruler of a tenant holds the groups of all its rules, their names cannot collide.
+	seen := make(map[string]string)
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		source, err := parseLokiRuleFile(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		if len(source.Groups) == 0 {
+			errs = append(errs, fmt.Errorf("%s: no rule groups", file))
+			continue
+		}
+
+		var alerting []*lokiv1.AlertingRuleGroup
+		var recording []*lokiv1.RecordingRuleGroup
+		for _, group := range source.Groups {
+			if other, ok := seen[group.Name]; ok {
+				errs = append(errs, fmt.Errorf("%s: rule group %s is already defined in %s", file, group.Name, other))
+				continue
+			}
+			seen[group.Name] = file
+
+			if err := validateLokiRuleGroup(group); err != nil {
+				errs = append(errs, fmt.Errorf("%s: rule group %s: %w", file, group.Name, err))
+				continue
+			}
+			if group.Rules[0].Alert != "" {
+				alerting = append(alerting, lokiAlertingRuleGroup(group))
+			} else {
+				recording = append(recording, lokiRecordingRuleGroup(group))
+			}
+		}
+
+		name := tenant + "-" + strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		if len(alerting) > 0 {
+			rules = append(rules, &lokiv1.AlertingRule{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "loki.grafana.com/v1", Kind: "AlertingRule"},
+				ObjectMeta: lokiRuleMeta(name, namespace),
+				Spec:       lokiv1.AlertingRuleSpec{TenantID: tenantID, Groups: alerting},
+			})
+		}
+		if len(recording) > 0 {
+			rules = append(rules, &lokiv1.RecordingRule{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "loki.grafana.com/v1", Kind: "RecordingRule"},
+				ObjectMeta: lokiRuleMeta(name, namespace),
+				Spec:       lokiv1.RecordingRuleSpec{TenantID: tenantID, Groups: recording},
+			})
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// parseLokiRuleFile rejects unknown fields, for a misspelled field not to silently drop a setting of a rule.
+func parseLokiRuleFile(content []byte) (lokiRuleFile, error) {
+	var source lokiRuleFile
+	b, err := yaml.YAMLToJSON(content)
+	if err != nil {
+		return source, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&source); err != nil {
+		return source, err
+	}
+	return source, nil
+}
+
+func lokiRuleMeta(name, namespace string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      name,
+		Namespace: namespace,
+		Labels: map[string]string{
+			lokiRuleLabel: "true",
+		},
+	}
+}
+
+// validateLokiRuleGroup checks that the group would be accepted by the ruler: names, durations and LogQL expressions.
+func validateLokiRuleGroup(group lokiRuleGroup) error {
+	if group.Name == "" {
+		return fmt.Errorf("rule group name cannot be empty")
+	}
+	if group.Interval != "" {
+		if _, err := model.ParseDuration(string(group.Interval)); err != nil {
+			return fmt.Errorf("invalid interval: %w", err)
+		}
+	}
+	if group.Limit < 0 {
+		return fmt.Errorf("limit cannot be negative")
+	}
+	if len(group.Rules) == 0 {
+		return fmt.Errorf("no rules")
+	}
+
+	alerting := group.Rules[0].Alert != ""
+	var errs []error
+	for i, rule := range group.Rules {
+		if (rule.Alert != "") != alerting {
+			errs = append(errs, fmt.Errorf("rule %d: a group cannot mix alerting and recording rules", i))
+			continue
+		}
+		if err := validateLokiRule(rule); err != nil {
+			errs = append(errs, fmt.Errorf("rule %d (%s%s): %w", i, rule.Alert, rule.Record, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func validateLokiRule(rule lokiRule) error {
+	switch {
+	case rule.Alert != "" && rule.Record != "":
+		return fmt.Errorf("a rule cannot both alert and record")
+	case rule.Record != "":
+		if !model.IsValidLegacyMetricName(rule.Record) {
+			return fmt.Errorf("invalid recording rule name")
+		}
+		if rule.For != "" || len(rule.Annotations) > 0 {
+			return fmt.Errorf("recording rules cannot have a for duration or annotations")
+		}
+	case rule.Alert != "":
+		if !model.LabelValue(rule.Alert).IsValid() {
+			return fmt.Errorf("invalid alert name")
+		}
+		if rule.For != "" {
+			if _, err := model.ParseDuration(string(rule.For)); err != nil {
+				return fmt.Errorf("invalid for duration: %w", err)
+			}
+		}
+	default:
+		return fmt.Errorf("a rule must either alert or record")
+	}
+
+	for name := range rule.Labels {
+		if !model.LabelName(name).IsValidLegacy() {
+			return fmt.Errorf("invalid label name %q", name)
+		}
+	}
+	if err := logql.ValidateMetricQuery(rule.Expr); err != nil {
+		return fmt.Errorf("invalid LogQL expression: %w", err)
+	}
+	return nil
+}
+
+func lokiAlertingRuleGroup(group lokiRuleGroup) *lokiv1.AlertingRuleGroup {
+	g := &lokiv1.AlertingRuleGroup{
+		Name:     group.Name,
+		Interval: cmp.Or(group.Interval, lokiRuleGroupInterval),
+		Limit:    group.Limit,
+	}
+	for _, rule := range group.Rules {
+		g.Rules = append(g.Rules, &lokiv1.AlertingRuleGroupSpec{
+			Alert:       rule.Alert,
+			Expr:        rule.Expr,
+			For:         rule.For,
+			Annotations: rule.Annotations,
+			Labels:      rule.Labels,
+		})
+	}
+	return g
+}
+
+func lokiRecordingRuleGroup(group lokiRuleGroup) *lokiv1.RecordingRuleGroup {
+	g := &lokiv1.RecordingRuleGroup{
+		Name:     group.Name,
+		Interval: cmp.Or(group.Interval, lokiRuleGroupInterval),
+		Limit:    group.Limit,
+	}
+	for _, rule := range group.Rules {
+		g.Rules = append(g.Rules, &lokiv1.RecordingRuleGroupSpec{
+			Record: rule.Record,
+			Expr:   rule.Expr,
+			Labels: rule.Labels,
+		})
+	}
+	return g
+}
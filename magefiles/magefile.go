@@ -35,8 +35,10 @@ var BuildStepFunctions = map[string]func(Build, clusters.ClusterConfig) error{
 		return b.ThanosOperator(cfg)
 	},
 	clusters.StepDefaultThanosStack: func(b Build, cfg clusters.ClusterConfig) error {
-		b.DefaultThanosStack(cfg)
-		return nil
+		return b.DefaultThanosStack(cfg)
+	},
+	clusters.StepTenantRules: func(b Build, cfg clusters.ClusterConfig) error {
+		return b.TenantRules(cfg)
 	},
 	clusters.StepLokiOperatorCRDS: func(b Build, cfg clusters.ClusterConfig) error {
 		return b.LokiOperatorCRDS(cfg)
@@ -46,8 +48,13 @@ var BuildStepFunctions = map[string]func(Build, clusters.ClusterConfig) error{
 		return nil
 	},
 	clusters.StepDefaultLokiStack: func(b Build, cfg clusters.ClusterConfig) error {
-		b.DefaultLokiStack(cfg)
-		return nil
+		return b.DefaultLokiStack(cfg)
+	},
+	clusters.StepLokiTenantRules: func(b Build, cfg clusters.ClusterConfig) error {
+		return b.LokiTenantRules(cfg)
+	},
+	clusters.StepDefaultTracesStack: func(b Build, cfg clusters.ClusterConfig) error {
+		return b.DefaultTracesStack(cfg)
 	},
 	clusters.StepServiceMonitors: func(b Build, cfg clusters.ClusterConfig) error {
 		b.ServiceMonitors(cfg)
@@ -58,8 +65,7 @@ var BuildStepFunctions = map[string]func(Build, clusters.ClusterConfig) error{
 		return nil
 	},
 	clusters.StepSecrets: func(b Build, cfg clusters.ClusterConfig) error {
-		b.Secrets(cfg)
-		return nil
+		return b.Secrets(cfg)
 	},
 	clusters.StepMemcached: func(b Build, cfg clusters.ClusterConfig) error {
 		b.Cache(cfg)
@@ -113,7 +119,10 @@ func (b Build) Clusters() error {
 	}
 
 	// Generate all monitoring bundles after all build steps complete
-	return GenerateAllMonitoringBundles()
+	if err := GenerateAllMonitoringBundles(); err != nil {
+		return err
+	}
+	return checkPlaceholders(clusterConfigs...)
 }
 
 // Cluster Builds manifests for a specific cluster
@@ -128,7 +137,10 @@ func (b Build) Cluster(clusterName string) error {
 	}
 
 	// Generate monitoring bundle after build steps complete
-	return GenerateAllMonitoringBundles()
+	if err := GenerateAllMonitoringBundles(); err != nil {
+		return err
+	}
+	return checkPlaceholders(*cluster)
 }
 
 // Environment Builds manifests for all clusters in a specific environment
@@ -150,7 +162,10 @@ func (b Build) Environment(environment string) error {
 	}
 
 	// Generate all monitoring bundles after all build steps complete
-	return GenerateAllMonitoringBundles()
+	if err := GenerateAllMonitoringBundles(); err != nil {
+		return err
+	}
+	return checkPlaceholders(clusterConfigs...)
 }
 
 // Steps Shows all available build steps
@@ -176,8 +191,10 @@ func (l List) Clusters() {
 }
 
 // Build Builds the manifests for the stage environment.
-func (Stage) Build() {
+func (Stage) Build() error {
 	mg.SerialDeps(Stage.Alertmanager, Stage.CRDS, Stage.Operator, Stage.Thanos, Stage.ServiceMonitors, Stage.Secrets)
+	return checkServicePlaceholders("staging", alertManagerName, crdTemplateDir, "operator", "rhobs-thanos-operator",
+		"servicemonitors", objStoreSecretsTemplateDir, cacheTemplatesDir)
 }
 
 func (Build) generator(config clusters.ClusterConfig, component string) *mimic.Generator {
@@ -229,13 +246,15 @@ func (Production) namespace() string {
 }
 
 // Build Builds the manifests for the production environment.
-func (Production) Build() {
+func (Production) Build() error {
 	mg.Deps(Production.Alertmanager)
+	return checkServicePlaceholders("production", alertManagerName)
 }
 
 // SyntheticsApi generates a single, environment-agnostic synthetics-api template
-func (Unified) SyntheticsApi() {
+func (Unified) SyntheticsApi() error {
 	generateUnifiedSyntheticsApi()
+	return checkServicePlaceholders("", syntheticsApiTemplate, "service-monitor-"+syntheticsApiTemplate)
 }
 
 // All generates all available unified templates
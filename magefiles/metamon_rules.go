@@ -25,6 +25,9 @@ const (
 	dashboardThanosOperator = "https://grafana.app-sre.devshift.net/d/3da9a026333052b2733299a69c302074/thanos-operator?orgId=1&refresh=10s&var-datasource={{$externalLabels.cluster}}-prometheus&var-namespace={{$labels.namespace}}&var-job=All&var-pod=All&var-interval=5m"
 )
 
+// rhobsNextServiceLabel is the service label value of the RHOBS regional deployments' rules.
+const rhobsNextServiceLabel = "rhobs.regional"
+
 // Runbook URLs
 const (
 	runbookBaseURL = "https://github.com/rhobs/configuration/blob/main/docs/sop/observatorium.md"
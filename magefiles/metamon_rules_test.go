@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rhobs/configuration/internal/ruletest"
+)
+
+func TestThanosPrometheusRule(t *testing.T) {
+	ruletest.Run(t, &ThanosPrometheusRule(false).PrometheusRule,
+		ruletest.Test{
+			Name: "compact down",
+			Input: []ruletest.Series{
+				{Series: `up{job="thanos-compact-rhobs", namespace="rhobs"}`, Values: "1x5 0x20"},
+			},
+			Alerts: []ruletest.AlertTest{
+				// The alert is pending for 5m once the compactor is down.
+				{At: 5 * time.Minute, Name: "ThanosCompactIsDown"},
+				{
+					At:   15 * time.Minute,
+					Name: "ThanosCompactIsDown",
+					Firing: []ruletest.Alert{
+						{Labels: map[string]string{"service": "rhobs.regional", "severity": "high"}},
+					},
+				},
+			},
+		},
+	)
+}
+
+func TestAlertmanagerPrometheusRule(t *testing.T) {
+	// The tests run without external labels, the cluster is left out of the dashboard link.
+	ruletest.Run(t, &AlertmanagerPrometheusRule(false).PrometheusRule,
+		ruletest.Test{
+			Name: "failed reload",
+			Input: []ruletest.Series{
+				{Series: `alertmanager_config_last_reload_successful{job="alertmanager", instance="alertmanager-0"}`, Values: "1x2 0x25"},
+			},
+			Alerts: []ruletest.AlertTest{
+				{At: 10 * time.Minute, Name: "AlertmanagerFailedReload"},
+				{
+					At:   20 * time.Minute,
+					Name: "AlertmanagerFailedReload",
+					Firing: []ruletest.Alert{
+						{
+							Labels: map[string]string{"instance": "alertmanager-0", "job": "alertmanager", "service": "rhobs.regional", "severity": "critical"},
+							Annotations: map[string]string{
+								"dashboard":   "https://grafana.app-sre.devshift.net/d/50b36e28785705570854022296f14821/alertmanager?orgId=1&refresh=10s&var-datasource=-prometheus&var-namespace=&var-job=All&var-pod=All&var-interval=5m",
+								"description": "Configuration has failed to load for alertmanager-0.",
+								"message":     "Reloading an Alertmanager configuration has failed.",
+								"runbook":     runbookBaseURL + "#alertmanagerfailedreload",
+							},
+						},
+					},
+				},
+			},
+		},
+	)
+}
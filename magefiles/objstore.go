@@ -0,0 +1,299 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/bwplotka/mimic"
+	"github.com/bwplotka/mimic/encoding"
+	lokiv1 "github.com/grafana/loki/operator/api/loki/v1"
+	"github.com/observatorium/observatorium/configuration_go/kubegen/openshift"
+	templatev1 "github.com/openshift/api/template/v1"
+	"github.com/rhobs/configuration/clusters"
+	"github.com/thanos-io/objstore/client"
+	"github.com/thanos-io/objstore/providers/azure"
+	"github.com/thanos-io/objstore/providers/filesystem"
+	"github.com/thanos-io/objstore/providers/gcs"
+	"github.com/thanos-io/objstore/providers/s3"
+	"github.com/thanos-io/objstore/providers/swift"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// thanosObjectStoreSecret renders the Thanos objstore configuration of the bucket's storage in the bucket's secret,
+// returning the template parameters it references.
+func thanosObjectStoreSecret(namespace string, bucket clusters.ObjectStorageBucket) (*corev1.Secret, []templatev1.Parameter) {
+	p := &templateParams{}
+	storage := bucket.Storage
+	var config client.BucketConfig
+	switch storage.Provider {
+	case clusters.ObjectStorageS3:
+		bucket := p.valueOr(storage.Bucket, "S3_BUCKET_NAME")
+		region := p.valueOr(storage.Region, "S3_BUCKET_REGION")
+		endpoint := p.valueOr(storage.Endpoint, "S3_BUCKET_ENDPOINT")
+		config = client.BucketConfig{
+			Type: client.S3,
+			Config: s3.Config{
+				Bucket:    bucket,
+				Region:    region,
+				Endpoint:  endpoint,
+				AccessKey: p.param("ACCESS_KEY_ID"),
+				SecretKey: p.param("SECRET_ACCESS_KEY"),
+			},
+		}
+	case clusters.ObjectStorageGCS:
+		config = client.BucketConfig{
+			Type: client.GCS,
+			Config: gcs.Config{
+				Bucket:         p.valueOr(storage.Bucket, "GCS_BUCKET_NAME"),
+				ServiceAccount: p.param("GCS_SERVICE_ACCOUNT"),
+			},
+		}
+	case clusters.ObjectStorageAzure:
+		config = client.BucketConfig{
+			Type: client.AZURE,
+			Config: azure.Config{
+				ContainerName:      p.valueOr(storage.Bucket, "AZURE_CONTAINER_NAME"),
+				Endpoint:           storage.Endpoint,
+				StorageAccountName: p.param("AZURE_STORAGE_ACCOUNT"),
+				StorageAccountKey:  p.param("AZURE_STORAGE_ACCOUNT_KEY"),
+			},
+		}
+	case clusters.ObjectStorageSwift:
+		container := p.valueOr(storage.Bucket, "SWIFT_CONTAINER_NAME")
+		authURL := p.valueOr(storage.Endpoint, "SWIFT_AUTH_URL")
+		region := p.valueOr(storage.Region, "SWIFT_REGION")
+		config = client.BucketConfig{
+			Type: client.SWIFT,
+			Config: swift.Config{
+				ContainerName:     container,
+				AuthUrl:           authURL,
+				RegionName:        region,
+				Username:          p.param("SWIFT_USERNAME"),
+				Password:          p.param("SWIFT_PASSWORD"),
+				UserDomainName:    p.param("SWIFT_USER_DOMAIN_NAME"),
+				ProjectName:       p.param("SWIFT_PROJECT_NAME"),
+				ProjectDomainName: p.param("SWIFT_PROJECT_DOMAIN_NAME"),
+			},
+		}
+	case clusters.ObjectStorageFilesystem:
+		config = client.BucketConfig{
+			Type: client.FILESYSTEM,
+			Config: filesystem.Config{
+				Directory: p.valueOr(storage.Bucket, "FILESYSTEM_DIRECTORY"),
+			},
+		}
+	default:
+		panic(fmt.Sprintf("unknown object storage provider %q", storage.Provider))
+	}
+
+	b, err := yaml.Marshal(config)
+	if err != nil {
+		panic(err)
+	}
+
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      bucket.Name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/name": bucket.Name,
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		StringData: map[string]string{
+			bucket.Key: string(b),
+		},
+	}, p.params
+}
+
+// lokiObjectStoreSecret renders the bucket's storage in the secret layout expected by the Loki operator,
+// returning the template parameters it references. Its parameters are prefixed with LOKI_ so that they do not
+// collide with the ones of the Thanos buckets.
+func lokiObjectStoreSecret(namespace string, bucket clusters.ObjectStorageBucket) (*corev1.Secret, []templatev1.Parameter) {
+	p := &templateParams{prefix: "LOKI_"}
+	storage := bucket.Storage
+	var data map[string]string
+	switch storage.Provider {
+	case clusters.ObjectStorageS3:
+		data = map[string]string{
+			"bucketnames":       p.valueOr(storage.Bucket, "S3_BUCKET_NAME"),
+			"region":            p.valueOr(storage.Region, "S3_BUCKET_REGION"),
+			"endpoint":          p.valueOr(storage.Endpoint, "S3_BUCKET_ENDPOINT"),
+			"access_key_id":     p.param("ACCESS_KEY_ID"),
+			"access_key_secret": p.param("SECRET_ACCESS_KEY"),
+		}
+	case clusters.ObjectStorageGCS:
+		data = map[string]string{
+			"bucketname": p.valueOr(storage.Bucket, "GCS_BUCKET_NAME"),
+			"key.json":   p.param("GCS_SERVICE_ACCOUNT"),
+		}
+	case clusters.ObjectStorageAzure:
+		data = map[string]string{
+			"environment":  "AzureGlobal",
+			"container":    p.valueOr(storage.Bucket, "AZURE_CONTAINER_NAME"),
+			"account_name": p.param("AZURE_STORAGE_ACCOUNT"),
+			"account_key":  p.param("AZURE_STORAGE_ACCOUNT_KEY"),
+		}
+		if storage.Endpoint != "" {
+			data["endpoint_suffix"] = storage.Endpoint
+		}
+	case clusters.ObjectStorageSwift:
+		data = map[string]string{
+			"container_name":      p.valueOr(storage.Bucket, "SWIFT_CONTAINER_NAME"),
+			"auth_url":            p.valueOr(storage.Endpoint, "SWIFT_AUTH_URL"),
+			"region":              p.valueOr(storage.Region, "SWIFT_REGION"),
+			"username":            p.param("SWIFT_USERNAME"),
+			"password":            p.param("SWIFT_PASSWORD"),
+			"user_domain_name":    p.param("SWIFT_USER_DOMAIN_NAME"),
+			"project_name":        p.param("SWIFT_PROJECT_NAME"),
+			"project_domain_name": p.param("SWIFT_PROJECT_DOMAIN_NAME"),
+		}
+	default:
+		panic(fmt.Sprintf("object storage provider %q is not supported by the Loki operator", storage.Provider))
+	}
+
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      bucket.Name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/name": bucket.Name,
+			},
+		},
+		Type:       corev1.SecretTypeOpaque,
+		StringData: data,
+	}, p.params
+}
+
+// tempoObjectStoreSecret renders the bucket's storage in the secret layout expected by the Tempo operator,
+// returning the template parameters it references. Its parameters are prefixed with TEMPO_.
+func tempoObjectStoreSecret(namespace string, bucket clusters.ObjectStorageBucket) (*corev1.Secret, []templatev1.Parameter) {
+	p := &templateParams{prefix: "TEMPO_"}
+	storage := bucket.Storage
+	var data map[string]string
+	switch storage.Provider {
+	case clusters.ObjectStorageS3:
+		data = map[string]string{
+			"bucket":            p.valueOr(storage.Bucket, "S3_BUCKET_NAME"),
+			"region":            p.valueOr(storage.Region, "S3_BUCKET_REGION"),
+			"endpoint":          p.valueOr(storage.Endpoint, "S3_BUCKET_ENDPOINT"),
+			"access_key_id":     p.param("ACCESS_KEY_ID"),
+			"access_key_secret": p.param("SECRET_ACCESS_KEY"),
+		}
+	case clusters.ObjectStorageGCS:
+		data = map[string]string{
+			"bucketname": p.valueOr(storage.Bucket, "GCS_BUCKET_NAME"),
+			"key.json":   p.param("GCS_SERVICE_ACCOUNT"),
+		}
+	case clusters.ObjectStorageAzure:
+		data = map[string]string{
+			"container":    p.valueOr(storage.Bucket, "AZURE_CONTAINER_NAME"),
+			"account_name": p.param("AZURE_STORAGE_ACCOUNT"),
+			"account_key":  p.param("AZURE_STORAGE_ACCOUNT_KEY"),
+		}
+	case clusters.ObjectStorageSwift:
+		data = map[string]string{
+			"container_name":      p.valueOr(storage.Bucket, "SWIFT_CONTAINER_NAME"),
+			"auth_url":            p.valueOr(storage.Endpoint, "SWIFT_AUTH_URL"),
+			"region":              p.valueOr(storage.Region, "SWIFT_REGION"),
+			"username":            p.param("SWIFT_USERNAME"),
+			"password":            p.param("SWIFT_PASSWORD"),
+			"user_domain_name":    p.param("SWIFT_USER_DOMAIN_NAME"),
+			"project_name":        p.param("SWIFT_PROJECT_NAME"),
+			"project_domain_name": p.param("SWIFT_PROJECT_DOMAIN_NAME"),
+		}
+	default:
+		panic(fmt.Sprintf("object storage provider %q is not supported by the Tempo operator", storage.Provider))
+	}
+
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      bucket.Name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/name": bucket.Name,
+			},
+		},
+		Type:       corev1.SecretTypeOpaque,
+		StringData: data,
+	}, p.params
+}
+
+// lokiStorageSecretType returns the LokiStack storage secret type of the Loki bucket.
+func lokiStorageSecretType(templates clusters.TemplateMaps) lokiv1.ObjectStorageSecretType {
+	switch clusters.TemplateFn(clusters.LokiBucket, templates.ObjectStorageBucket).Storage.Provider {
+	case clusters.ObjectStorageGCS:
+		return lokiv1.ObjectStorageSecretGCS
+	case clusters.ObjectStorageAzure:
+		return lokiv1.ObjectStorageSecretAzure
+	case clusters.ObjectStorageSwift:
+		return lokiv1.ObjectStorageSecretSwift
+	default:
+		return lokiv1.ObjectStorageSecretS3
+	}
+}
+
+// tempoStorageSecretType returns the TempoStack storage secret type of the traces bucket.
+func tempoStorageSecretType(templates clusters.TemplateMaps) string {
+	switch clusters.TemplateFn(clusters.TracesBucket, templates.ObjectStorageBucket).Storage.Provider {
+	case clusters.ObjectStorageGCS:
+		return "gcs"
+	case clusters.ObjectStorageAzure:
+		return "azure"
+	case clusters.ObjectStorageSwift:
+		return "swift"
+	default:
+		return "s3"
+	}
+}
+
+// addObjectStoreSecretTemplates templates the secret of every Thanos bucket of the cluster, one template per bucket
+// so that the parameters of several buckets do not collide.
+func addObjectStoreSecretTemplates(gen *mimic.Generator, config clusters.ClusterConfig) {
+	for _, key := range slices.Sorted(maps.Keys(config.Templates.ObjectStorageBucket)) {
+		if key == clusters.LokiBucket || key == clusters.TracesBucket {
+			continue
+		}
+		bucket := config.Templates.ObjectStorageBucket[key]
+		secret, params := thanosObjectStoreSecret(config.Namespace, bucket)
+		gen.Add(bucket.Name+"-secret-template.yaml", encoding.GhodssYAML(
+			openshift.WrapInTemplate([]runtime.Object{secret}, metav1.ObjectMeta{Name: bucket.Name + "-secret"}, params),
+		))
+	}
+}
+
+// addLokiObjectStoreSecretTemplate templates the secret of the Loki bucket of the cluster.
+func addLokiObjectStoreSecretTemplate(gen *mimic.Generator, config clusters.ClusterConfig) {
+	bucket := clusters.TemplateFn(clusters.LokiBucket, config.Templates.ObjectStorageBucket)
+	secret, params := lokiObjectStoreSecret(config.Namespace, bucket)
+	gen.Add(bucket.Name+"-secret-template.yaml", encoding.GhodssYAML(
+		openshift.WrapInTemplate([]runtime.Object{secret}, metav1.ObjectMeta{Name: bucket.Name + "-secret"}, params),
+	))
+}
+
+// addTempoObjectStoreSecretTemplate templates the secret of the traces bucket of the cluster.
+func addTempoObjectStoreSecretTemplate(gen *mimic.Generator, config clusters.ClusterConfig) {
+	bucket := clusters.TemplateFn(clusters.TracesBucket, config.Templates.ObjectStorageBucket)
+	secret, params := tempoObjectStoreSecret(config.Namespace, bucket)
+	gen.Add(bucket.Name+"-secret-template.yaml", encoding.GhodssYAML(
+		openshift.WrapInTemplate([]runtime.Object{secret}, metav1.ObjectMeta{Name: bucket.Name + "-secret"}, params),
+	))
+}
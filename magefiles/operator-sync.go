@@ -40,6 +40,19 @@ func (s Sync) Operator(operator string, ref string) error {
 	return nil
 }
 
+// Upstream vendors the upstream manifests again at the pinned CRD refs of the operators, without syncing new refs.
+func (s Sync) Upstream() error {
+	for _, u := range []upstreamRepo{
+		thanosOperatorUpstream(thanosOperatorCRDRef),
+		lokiOperatorUpstream(lokiOperatorCRDRef),
+	} {
+		if err := u.vendor(); err != nil {
+			return fmt.Errorf("failed to vendor %s: %w", u.name, err)
+		}
+	}
+	return nil
+}
+
 // syncThanosOperator syncs Thanos Operator manifests from the given ref.
 func (s Sync) syncThanosOperator(ref string) error {
 	return operatorCRDSyncer{
@@ -57,6 +70,7 @@ func (s Sync) syncThanosOperator(ref string) error {
 			filename: "magefiles/thanos-operator.go",
 			name:     "thanosOperatorCRDRef",
 		},
+		upstream: thanosOperatorUpstream,
 	}.sync()
 }
 
@@ -77,6 +91,7 @@ func (s Sync) syncLokiOperator(ref string) error {
 			filename: "magefiles/loki-operator.go",
 			name:     "lokiOperatorCRDRef",
 		},
+		upstream:        lokiOperatorUpstream,
 		skipGoModUpdate: true,
 	}.sync()
 }
@@ -93,6 +108,8 @@ type operatorCRDSyncer struct {
 
 	operatorTagVariable goValue
 	crdVersionVariable  goValue
+	// upstream returns the repository whose manifests are vendored at the CRD ref.
+	upstream func(ref string) upstreamRepo
 
 	skipGoModUpdate bool
 }
@@ -139,10 +156,6 @@ func (s operatorCRDSyncer) sync() error {
 	}
 
 	fmt.Fprintf(os.Stdout, "Syncing %s at commit %s\n", component, ref)
-	if err = s.operatorTagVariable.updateConst(ref); err != nil {
-		return fmt.Errorf("failed to update %q variable: %w", s.operatorTagVariable, err)
-	}
-
 	info := submodule.Info{
 		Commit:        ref,
 		SubmodulePath: s.submodule,
@@ -154,6 +167,15 @@ func (s operatorCRDSyncer) sync() error {
 	}
 
 	fmt.Fprintf(os.Stdout, "Parsed submodule %s commit: %s\n", module.Path, module.Commit)
+
+	// Vendor before pinning the refs, so a failed download leaves the refs on the manifests in the store.
+	if err = s.upstream(module.Commit).vendor(); err != nil {
+		return fmt.Errorf("failed to vendor upstream manifests: %w", err)
+	}
+
+	if err = s.operatorTagVariable.updateConst(ref); err != nil {
+		return fmt.Errorf("failed to update %q variable: %w", s.operatorTagVariable, err)
+	}
 	err = s.crdVersionVariable.updateConst(module.Commit)
 	if err != nil {
 		return fmt.Errorf("failed to update Thanos Operator CRD ref: %w", err)
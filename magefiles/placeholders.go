@@ -0,0 +1,138 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/rhobs/configuration/clusters"
+)
+
+// placeholderPattern matches OpenShift template parameter references, both ${PARAM} and ${{PARAM}}.
+// The two forms are separate alternatives, so unbalanced braces such as ${{PARAM} are not taken for a reference.
+// Container environment references such as $(CLIENT_ID) are resolved by the kubelet and are not matched.
+var placeholderPattern = regexp.MustCompile(`\$\{([A-Za-z_]\w*)\}|\$\{\{([A-Za-z_]\w*)\}\}`)
+
+// checkPlaceholders verifies the generated output of the given clusters.
+// Templates must declare every parameter they reference and reference every parameter they declare.
+// Anything else is applied as-is, so no placeholder may survive in it.
+func checkPlaceholders(configs ...clusters.ClusterConfig) error {
+	var errs []error
+	for _, config := range configs {
+		dir := filepath.Join(templatePath, templateClustersPath, string(config.Environment), string(config.Name))
+		if err := checkPlaceholdersInDir(dir); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkServicePlaceholders verifies the generated output of the given components of a legacy environment,
+// resources/services/<component>/<env>, or resources/services/<component> for the unified templates, which have no env.
+// Components generated by other means, such as jsonnet, are not checked.
+func checkServicePlaceholders(env string, components ...string) error {
+	var errs []error
+	for _, component := range components {
+		dir := filepath.Join(templatePath, templateServicesPath, component, env)
+		if err := checkPlaceholdersInDir(dir); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func checkPlaceholdersInDir(dir string) error {
+	var errs []error
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == dir {
+				// Clusters with only no-op steps have no output to check.
+				return fs.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".yaml" {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := checkPlaceholdersInFile(content); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to walk %s: %w", dir, err)
+	}
+	return errors.Join(errs...)
+}
+
+func checkPlaceholdersInFile(content []byte) error {
+	var obj struct {
+		Kind       string `json:"kind"`
+		Objects    []any  `json:"objects"`
+		Parameters []struct {
+			Name string `json:"name"`
+		} `json:"parameters"`
+	}
+	if err := yaml.Unmarshal(content, &obj); err != nil {
+		return fmt.Errorf("failed to parse generated file: %w", err)
+	}
+
+	if obj.Kind != "Template" {
+		if used := placeholdersIn(string(content)); len(used) > 0 {
+			return fmt.Errorf("unresolved placeholders in bundle output: %s", strings.Join(used, ", "))
+		}
+		return nil
+	}
+
+	objects, err := yaml.Marshal(obj.Objects)
+	if err != nil {
+		return fmt.Errorf("failed to encode template objects: %w", err)
+	}
+	used := placeholdersIn(string(objects))
+
+	var declared []string
+	for _, p := range obj.Parameters {
+		declared = append(declared, p.Name)
+	}
+
+	var errs []error
+	if undeclared := difference(used, declared); len(undeclared) > 0 {
+		errs = append(errs, fmt.Errorf("placeholders used but not declared as parameters: %s", strings.Join(undeclared, ", ")))
+	}
+	if unused := difference(declared, used); len(unused) > 0 {
+		errs = append(errs, fmt.Errorf("parameters declared but never used: %s", strings.Join(unused, ", ")))
+	}
+	return errors.Join(errs...)
+}
+
+// placeholdersIn returns the sorted, de-duplicated parameter names referenced in s.
+func placeholdersIn(s string) []string {
+	var names []string
+	for _, match := range placeholderPattern.FindAllStringSubmatch(s, -1) {
+		names = append(names, match[1]+match[2])
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// difference returns the elements of a that are not in b.
+func difference(a, b []string) []string {
+	var result []string
+	for _, v := range a {
+		if !slices.Contains(b, v) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
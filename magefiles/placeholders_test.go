@@ -0,0 +1,26 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPlaceholdersIn(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want []string
+	}{
+		{in: "image: ${IMAGE}:${IMAGE_TAG}", want: []string{"IMAGE", "IMAGE_TAG"}},
+		{in: "replicas: ${{REPLICAS}}", want: []string{"REPLICAS"}},
+		{in: "replicas: ${{REPLICAS}", want: nil},
+		{in: "value: $(CLIENT_ID)", want: nil},
+		{in: "value: ${1PARAM}", want: nil},
+		{in: "a: ${A} b: ${{B}} c: ${A}", want: []string{"A", "B"}},
+	} {
+		t.Run(tc.in, func(t *testing.T) {
+			if got := placeholdersIn(tc.in); !slices.Equal(got, tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/rhobs/configuration/clusters"
+	"github.com/thanos-community/thanos-operator/api/v1alpha1"
+	"github.com/thanos-io/thanos/pkg/queryfrontend"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+)
+
+// applyQueryFrontendPolicy sets the splitting, retries, caching and logging of the query frontend from the
+// query frontend policy of the templates.
+func applyQueryFrontendPolicy(spec *v1alpha1.QueryFrontendSpec, templates clusters.TemplateMaps) error {
+	p := clusters.TemplateFn(clusters.QueryFrontend, templates.QueryFrontendPolicy)
+	spec.LogQueriesLongerThan = policyDuration(p.LogQueriesLongerThan)
+	spec.QueryRangeSplitInterval = policyDuration(p.QueryRangeSplitInterval)
+	spec.QueryRangeMaxRetries = p.QueryRangeMaxRetries
+	spec.LabelsSplitInterval = policyDuration(p.LabelsSplitInterval)
+	spec.LabelsMaxRetries = p.LabelsMaxRetries
+	spec.LabelsDefaultTimeRange = policyDuration(p.LabelsDefaultTimeRange)
+
+	switch p.ResultsCache {
+	case clusters.QueryFrontendCacheExternal:
+		// The secret is renamed after the backend of the query-range cache role by thanosCacheReferences.
+		spec.QueryRangeResponseCacheConfig = &v1alpha1.CacheConfig{
+			ExternalCacheConfig: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: thanosCacheSecretName(clusters.CacheRoleQueryRange, clusters.CacheBackendMemcached),
+				},
+				Key: "thanos.yaml",
+			},
+		}
+	case clusters.QueryFrontendCacheInMemory:
+		spec.QueryRangeResponseCacheConfig = &v1alpha1.CacheConfig{
+			InMemoryCacheConfig: &v1alpha1.InMemoryCacheConfig{
+				MaxSize: ptr.To(p.InMemoryCacheMaxSize),
+			},
+		}
+	default:
+		spec.QueryRangeResponseCacheConfig = nil
+	}
+
+	if p.LabelsCacheMaxSize != "" {
+		config, err := labelsCacheConfig(p)
+		if err != nil {
+			return err
+		}
+		spec.Additional.Args = append(spec.Additional.Args, "--labels.response-cache-config="+config)
+	}
+	if p.VerticalShards > 0 {
+		spec.Additional.Args = append(spec.Additional.Args, fmt.Sprintf("--query-frontend.vertical-shards=%d", p.VerticalShards))
+	}
+	return nil
+}
+
+// labelsCacheConfig renders the in-memory cache of the label responses
+func labelsCacheConfig(p clusters.QueryFrontendPolicy) (string, error) {
+	validity, err := p.LabelsCacheExpiration()
+	if err != nil {
+		return "", err
+	}
+	b, err := yaml.Marshal(queryfrontend.CacheProviderConfig{
+		Type: queryfrontend.INMEMORY,
+		Config: queryfrontend.InMemoryResponseCacheConfig{
+			MaxSize:  p.LabelsCacheMaxSize,
+			Validity: validity,
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal the labels cache config: %w", err)
+	}
+	return string(b), nil
+}
+
+// queryRangeCacheExpiration is the expiration of the query range results in the external cache
+func queryRangeCacheExpiration(templates clusters.TemplateMaps) (time.Duration, error) {
+	return clusters.TemplateFn(clusters.QueryFrontend, templates.QueryFrontendPolicy).ResultsCacheExpiration()
+}
+
+func policyDuration(d string) *v1alpha1.Duration {
+	if d == "" {
+		return nil
+	}
+	return ptr.To(v1alpha1.Duration(d))
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/magefile/mage/mg"
+	"github.com/rhobs/configuration/clusters"
+	cfgobservatorium "github.com/rhobs/configuration/configuration/observatorium"
+)
+
+type (
+	Rbac mg.Namespace
+)
+
+// rbacReportEntry is a single binding of the RBAC configuration generated for a gateway.
+type rbacReportEntry struct {
+	source string
+	cfgobservatorium.BindingMetadata
+}
+
+// Report lists the gateway RBAC bindings of the legacy configuration and every registered cluster grouped by owner.
+// It fails if any binding is past its expiry date.
+func (Rbac) Report() error {
+	sources := map[string]cfgobservatorium.ObservatoriumRBAC{
+		"legacy": *cfgobservatorium.GenerateRBAC(),
+	}
+	for _, cluster := range clusters.GetClusters() {
+		if cluster.GatewayConfig == nil {
+			continue
+		}
+		sources[string(cluster.Name)] = cluster.GatewayConfig.RBAC()
+	}
+
+	return rbacReport(os.Stdout, sources, time.Now())
+}
+
+// checkRBACExpiry fails if any gateway RBAC binding of the given clusters is past its expiry date.
+// Configs without a name are the legacy environments.
+func checkRBACExpiry(now time.Time, configs ...clusters.ClusterConfig) error {
+	var expired []string
+	for _, config := range configs {
+		if config.GatewayConfig == nil {
+			continue
+		}
+		expired = append(expired, expiredBindings(cmp.Or(string(config.Name), "legacy"), config.GatewayConfig.RBAC(), now)...)
+	}
+	if len(expired) > 0 {
+		return fmt.Errorf("found %d expired RBAC bindings, remove them or extend their expiry: %s", len(expired), strings.Join(expired, ", "))
+	}
+	return nil
+}
+
+// expiredBindings returns the bindings of the source which are past their expiry date, as <source>/<binding>.
+func expiredBindings(source string, rbac cfgobservatorium.ObservatoriumRBAC, now time.Time) []string {
+	var expired []string
+	for _, binding := range rbac.BindingMetadata() {
+		if binding.Expired(now) {
+			expired = append(expired, fmt.Sprintf("%s/%s", source, binding.Name))
+		}
+	}
+	return expired
+}
+
+func rbacReport(w io.Writer, sources map[string]cfgobservatorium.ObservatoriumRBAC, now time.Time) error {
+	byOwner := map[string][]rbacReportEntry{}
+	for source, rbac := range sources {
+		for _, binding := range rbac.BindingMetadata() {
+			byOwner[binding.Owner] = append(byOwner[binding.Owner], rbacReportEntry{source: source, BindingMetadata: binding})
+		}
+	}
+
+	owners := make([]string, 0, len(byOwner))
+	for owner := range byOwner {
+		owners = append(owners, owner)
+	}
+	slices.Sort(owners)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "OWNER / SOURCE\tBINDING\tCONTACT\tEXPIRES\tSTATUS")
+	for _, owner := range owners {
+		entries := byOwner[owner]
+		slices.SortFunc(entries, func(a, b rbacReportEntry) int {
+			if c := strings.Compare(a.source, b.source); c != 0 {
+				return c
+			}
+			return strings.Compare(a.Name, b.Name)
+		})
+
+		fmt.Fprintf(tw, "%s\t\t\t\t\n", owner)
+		for _, e := range entries {
+			expiry := "never"
+			if !e.Expires.IsZero() {
+				expiry = e.Expires.Format(time.DateOnly)
+			}
+			status := ""
+			if e.Expired(now) {
+				status = "EXPIRED"
+			}
+			fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%s\n", e.source, e.Name, e.Contact, expiry, status)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	var expired []string
+	for _, source := range slices.Sorted(maps.Keys(sources)) {
+		expired = append(expired, expiredBindings(source, sources[source], now)...)
+	}
+	if len(expired) > 0 {
+		return fmt.Errorf("found %d expired RBAC bindings: %s", len(expired), strings.Join(expired, ", "))
+	}
+	return nil
+}
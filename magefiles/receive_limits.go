@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+
+	kghelpers "github.com/observatorium/observatorium/configuration_go/kubegen/helpers"
+	"github.com/rhobs/configuration/clusters"
+	"github.com/thanos-community/thanos-operator/api/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	receiveLimitsConfigMap = "thanos-receive-limits"
+	receiveLimitsKey       = "limits.yaml"
+	receiveLimitsVolume    = "receive-limits"
+	receiveLimitsMountPath = "/etc/thanos/receive-limits"
+)
+
+// receiveLimitsConfig mirrors the limits configuration file of Thanos receive.
+// Its types are not exported by Thanos, and unset limits must be left out for tenants to inherit the default.
+type receiveLimitsConfig struct {
+	Write struct {
+		Global struct {
+			MaxConcurrency    int64  `yaml:"max_concurrency,omitempty"`
+			MetaMonitoringURL string `yaml:"meta_monitoring_url,omitempty"`
+		} `yaml:"global"`
+		Default receiveWriteLimits            `yaml:"default"`
+		Tenants map[string]receiveWriteLimits `yaml:"tenants,omitempty"`
+	} `yaml:"write"`
+}
+
+type receiveWriteLimits struct {
+	Request         *receiveRequestLimits `yaml:"request,omitempty"`
+	HeadSeriesLimit *uint64               `yaml:"head_series_limit,omitempty"`
+}
+
+type receiveRequestLimits struct {
+	SizeBytesLimit *int64 `yaml:"size_bytes_limit,omitempty"`
+	SeriesLimit    *int64 `yaml:"series_limit,omitempty"`
+	SamplesLimit   *int64 `yaml:"samples_limit,omitempty"`
+}
+
+// receiveLimitsObjects renders the receive limits of a cluster in a ConfigMap mounted into the receive router.
+// Tenants are keyed by their ID in the limits file, as that is what the router sees in the tenant header.
+func receiveLimitsObjects(config clusters.ClusterConfig, objs []runtime.Object) ([]runtime.Object, error) {
+	if config.ReceiveLimits == nil {
+		return objs, nil
+	}
+
+	var receive *v1alpha1.ThanosReceive
+	for _, obj := range objs {
+		if r, ok := obj.(*v1alpha1.ThanosReceive); ok {
+			receive = r
+		}
+	}
+	if receive == nil {
+		return nil, fmt.Errorf("cluster %s has no ThanosReceive to limit", config.Name)
+	}
+
+	limits, err := receiveLimitsFile(config)
+	if err != nil {
+		return nil, err
+	}
+
+	router := &receive.Spec.Router.Additional
+	router.Args = append(router.Args, fmt.Sprintf("--receive.limits-config-file=%s/%s", receiveLimitsMountPath, receiveLimitsKey))
+	router.Volumes = append(router.Volumes, kghelpers.NewPodVolumeFromConfigMap(receiveLimitsVolume, receiveLimitsConfigMap))
+	router.VolumeMounts = append(router.VolumeMounts, corev1.VolumeMount{
+		Name:      receiveLimitsVolume,
+		MountPath: receiveLimitsMountPath,
+		ReadOnly:  true,
+	})
+
+	return append(objs, &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      receiveLimitsConfigMap,
+			Namespace: config.Namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/part-of": "thanos",
+			},
+		},
+		Data: map[string]string{
+			receiveLimitsKey: limits,
+		},
+	}), nil
+}
+
+func receiveLimitsFile(config clusters.ClusterConfig) (string, error) {
+	l := config.ReceiveLimits
+	var file receiveLimitsConfig
+	file.Write.Global.MaxConcurrency = l.MaxConcurrency
+	file.Write.Global.MetaMonitoringURL = l.MetaMonitoringURL
+	file.Write.Default = receiveLimits(l.Default)
+
+	if len(l.Tenants) > 0 {
+		file.Write.Tenants = make(map[string]receiveWriteLimits, len(l.Tenants))
+	}
+	for name, limits := range l.Tenants {
+		id, ok := config.GatewayConfig.TenantID(name)
+		if !ok {
+			return "", fmt.Errorf("receive limits reference tenant %q, which is not registered in the gateway", name)
+		}
+		file.Write.Tenants[id] = receiveLimits(limits)
+	}
+
+	b, err := yaml.Marshal(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal receive limits: %w", err)
+	}
+	return string(b), nil
+}
+
+// receiveLimits leaves the zero limits out of the file
+func receiveLimits(w clusters.WriteLimits) receiveWriteLimits {
+	var limits receiveWriteLimits
+	if w.HeadSeriesLimit != 0 {
+		limits.HeadSeriesLimit = &w.HeadSeriesLimit
+	}
+	if w.SizeBytesLimit == 0 && w.SeriesLimit == 0 && w.SamplesLimit == 0 {
+		return limits
+	}
+	limits.Request = &receiveRequestLimits{}
+	if w.SizeBytesLimit != 0 {
+		limits.Request.SizeBytesLimit = &w.SizeBytesLimit
+	}
+	if w.SeriesLimit != 0 {
+		limits.Request.SeriesLimit = &w.SeriesLimit
+	}
+	if w.SamplesLimit != 0 {
+		limits.Request.SamplesLimit = &w.SamplesLimit
+	}
+	return limits
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+
+	observatoriumapi "github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/observatorium/api"
+	"github.com/rhobs/configuration/clusters"
+	"github.com/thanos-community/thanos-operator/api/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestReceiveLimitsObjects(t *testing.T) {
+	config := clusters.ClusterConfig{
+		Name:      "test",
+		Namespace: "rhobs",
+		GatewayConfig: clusters.NewGatewayConfig(clusters.WithTenants(observatoriumapi.Tenants{
+			Tenants: []observatoriumapi.Tenant{
+				{Name: "hcp", ID: "EFD08939-FE1D-41A1-A28A-BE9A9BC68003"},
+				{Name: "rhobs", ID: "0fc2b00e-201b-4c17-b9f2-19d91adc4fd2"},
+			},
+		})),
+		ReceiveLimits: &clusters.ReceiveLimits{
+			MaxConcurrency: 30,
+			Default:        clusters.WriteLimits{SizeBytesLimit: 5242880, SeriesLimit: 5000},
+			Tenants: map[string]clusters.WriteLimits{
+				"hcp":   {SamplesLimit: 10000},
+				"rhobs": {},
+			},
+		},
+	}
+
+	objs, err := receiveLimitsObjects(config, []runtime.Object{&v1alpha1.ThanosReceive{}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(objs) != 2 {
+		t.Fatalf("expected the receive and its limits ConfigMap, got %d objects", len(objs))
+	}
+	cm, ok := objs[1].(*corev1.ConfigMap)
+	if !ok {
+		t.Fatalf("expected a ConfigMap, got %T", objs[1])
+	}
+
+	// Tenants are keyed by ID, and the zero limits are left out to inherit the default.
+	want := `write:
+  global:
+    max_concurrency: 30
+  default:
+    request:
+      size_bytes_limit: 5242880
+      series_limit: 5000
+  tenants:
+    0fc2b00e-201b-4c17-b9f2-19d91adc4fd2: {}
+    EFD08939-FE1D-41A1-A28A-BE9A9BC68003:
+      request:
+        samples_limit: 10000
+`
+	if got := cm.Data[receiveLimitsKey]; got != want {
+		t.Fatalf("unexpected limits file:\n%s\nwant:\n%s", got, want)
+	}
+}
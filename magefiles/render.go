@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/bwplotka/mimic"
+	"github.com/bwplotka/mimic/encoding"
+	"github.com/observatorium/observatorium/configuration_go/kubegen/openshift"
+	templatev1 "github.com/openshift/api/template/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// renderer emits the objects of a component. Components build a single object graph for every output and ask
+// the renderer for the values that differ between outputs, so that new fields land in all of them.
+type renderer interface {
+	// param returns the value exposed as a parameter by templates, p.Value in bundles.
+	param(p templatev1.Parameter) string
+	// render adds the objects to the generator.
+	render(gen *mimic.Generator, objs []runtime.Object)
+}
+
+// templateRenderer renders the objects as a single OpenShift template. It declares the parameters the objects
+// reference, so a value templated by one object but left out of another template only lands in the one using it.
+type templateRenderer struct {
+	name     string
+	filename string
+	params   []templatev1.Parameter
+	// fixed is the number of leading params given up front, declared whether referenced or not.
+	fixed int
+}
+
+// newTemplateRenderer returns a renderer of the named template. The given parameters are known up front, for
+// placeholders only templates support such as secrets provided at deployment, and always declared.
+func newTemplateRenderer(name, filename string, params ...templatev1.Parameter) *templateRenderer {
+	return &templateRenderer{name: name, filename: filename, params: slices.Clone(params), fixed: len(params)}
+}
+
+func (t *templateRenderer) param(p templatev1.Parameter) string {
+	if !slices.ContainsFunc(t.params, func(declared templatev1.Parameter) bool { return declared.Name == p.Name }) {
+		t.params = append(t.params, p)
+	}
+	return fmt.Sprintf("${%s}", p.Name)
+}
+
+func (t *templateRenderer) render(gen *mimic.Generator, objs []runtime.Object) {
+	content, err := json.Marshal(objs)
+	mimic.PanicOnErr(err)
+	used := placeholdersIn(string(content))
+
+	params := slices.Clone(t.params[:t.fixed])
+	for _, p := range t.params[t.fixed:] {
+		if slices.Contains(used, p.Name) {
+			params = append(params, p)
+		}
+	}
+	gen.Add(t.filename, encoding.GhodssYAML(openshift.WrapInTemplate(objs, metav1.ObjectMeta{Name: t.name}, params)))
+}
+
+// bundleRenderer renders every object to its own file, with concrete values.
+type bundleRenderer struct {
+	// filename names the file of the i-th object.
+	filename func(i int, obj runtime.Object) string
+}
+
+func (bundleRenderer) param(p templatev1.Parameter) string {
+	return p.Value
+}
+
+func (b bundleRenderer) render(gen *mimic.Generator, objs []runtime.Object) {
+	for i, obj := range objs {
+		gen.Add(b.filename(i, obj), encoding.GhodssYAML(obj))
+	}
+}
+
+// numberedBundle names the files of a bundle after the position, name and kind of their object, e.g. 01-name-Kind.yaml.
+var numberedBundle = bundleRenderer{
+	filename: func(i int, obj runtime.Object) string {
+		return fmt.Sprintf("%02d-%s-%s.yaml", i+1, getKubernetesResourceName(obj), getResourceKind(obj))
+	},
+}
+
+// templateParams collects the template parameters standing in for the values a definition leaves unset, such as
+// the fields of an object storage or the address of a Redis, and for its credentials. The prefix keeps the
+// parameters of several definitions apart in one template.
+type templateParams struct {
+	prefix string
+	params []templatev1.Parameter
+}
+
+func (p *templateParams) valueOr(value, name string) string {
+	if value != "" {
+		return value
+	}
+	return p.param(name)
+}
+
+func (p *templateParams) param(name string) string {
+	name = p.prefix + name
+	p.params = append(p.params, templatev1.Parameter{Name: name})
+	return fmt.Sprintf("${%s}", name)
+}
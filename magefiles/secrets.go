@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"os"
 	"time"
 
@@ -12,10 +13,8 @@ import (
 	"github.com/observatorium/observatorium/configuration_go/kubegen/openshift"
 	templatev1 "github.com/openshift/api/template/v1"
 	"github.com/rhobs/configuration/clusters"
-	"github.com/thanos-io/objstore/client"
-	"github.com/thanos-io/objstore/providers/s3"
+	"github.com/thanos-community/thanos-operator/api/v1alpha1"
 	"github.com/thanos-io/thanos/pkg/cacheutil"
-	"github.com/thanos-io/thanos/pkg/queryfrontend"
 	storecache "github.com/thanos-io/thanos/pkg/store/cache"
 
 	corev1 "k8s.io/api/core/v1"
@@ -34,19 +33,19 @@ func ObjectStorageSecretTemplate() {
 	gen = gen.With(templatePath, templateServicesPath, objStoreSecretsTemplateDir)
 	gen.Logger = log.NewLogfmtLogger(log.NewSyncWriter(os.Stdout))
 
+	bucket := clusters.ObjectStorageBucket{
+		ObjectStorageConfig: v1alpha1.ObjectStorageConfig{
+			Key:                  "thanos.yaml",
+			LocalObjectReference: corev1.LocalObjectReference{Name: "${SECRET_NAME}"},
+		},
+		Storage: clusters.ObjectStorage{Provider: clusters.ObjectStorageS3},
+	}
+	secret, params := thanosObjectStoreSecret("${NAMESPACE}", bucket)
 	gen.Add("thanos-object-store-secret.yaml", encoding.GhodssYAML(
 		openshift.WrapInTemplate(
-			[]runtime.Object{thanosObjectStoreSecretTemplate()},
+			[]runtime.Object{secret},
 			metav1.ObjectMeta{Name: "thanos-object-store-secret"},
-			[]templatev1.Parameter{
-				{Name: "SECRET_NAME"},
-				{Name: "NAMESPACE"},
-				{Name: "S3_BUCKET_NAME"},
-				{Name: "S3_BUCKET_REGION"},
-				{Name: "S3_BUCKET_ENDPOINT"},
-				{Name: "ACCESS_KEY_ID"},
-				{Name: "SECRET_ACCESS_KEY"},
-			},
+			append([]templatev1.Parameter{{Name: "SECRET_NAME"}, {Name: "NAMESPACE"}}, params...),
 		),
 	))
 
@@ -56,13 +55,13 @@ func ObjectStorageSecretTemplate() {
 
 // Secrets generates the secrets for the Production environment
 func (p Production) Secrets() {
-	secrets(p.generator(objStoreSecretsTemplateDir), p.namespace())
+	secrets(p.generator(objStoreSecretsTemplateDir), p.namespace(), clusters.ProductionObjectStorageBucket)
 }
 
 // Secrets generates the secrets for the Stage environment
 func (s Stage) Secrets() {
 	ns := s.namespace()
-	secrets(s.generator(objStoreSecretsTemplateDir), ns)
+	secrets(s.generator(objStoreSecretsTemplateDir), ns, clusters.StageObjectStorageBucket)
 	var cacheObjs []runtime.Object
 	for _, secret := range cacheSecretsStage(ns) {
 		cacheObjs = append(cacheObjs, secret)
@@ -88,66 +87,65 @@ func cacheSecrets(gen *mimic.Generator, secrets []runtime.Object) {
 	gen.Generate()
 }
 
-func secrets(gen *mimic.Generator, ns string) {
+func secrets(gen *mimic.Generator, ns string, buckets clusters.ParamMap[clusters.ObjectStorageBucket]) {
+	telemeterSecret, params := thanosObjectStoreSecret(ns, clusters.TemplateFn("TELEMETER", buckets))
 	gen.Add("thanos-telemeter-secret.yaml", encoding.GhodssYAML(
 		openshift.WrapInTemplate(
-			[]runtime.Object{thanosObjectStoreSecret("thanos-objectstorage", ns)},
+			[]runtime.Object{telemeterSecret},
 			metav1.ObjectMeta{Name: "thanos-telemeter-secret"},
-			[]templatev1.Parameter{
-				{Name: "S3_BUCKET_NAME"},
-				{Name: "S3_BUCKET_REGION"},
-				{Name: "S3_BUCKET_ENDPOINT"},
-				{Name: "ACCESS_KEY_ID"},
-				{Name: "SECRET_ACCESS_KEY"},
-			},
+			params,
 		),
 	))
 
 	// Generate MST Thanos objectstorage secret
+	defaultSecret, params := thanosObjectStoreSecret(ns, clusters.TemplateFn("DEFAULT", buckets))
 	gen.Add("thanos-default-secret.yaml", encoding.GhodssYAML(
 		openshift.WrapInTemplate(
-			[]runtime.Object{thanosObjectStoreSecret("observatorium-mst-thanos-objectstorage", ns)},
+			[]runtime.Object{defaultSecret},
 			metav1.ObjectMeta{Name: "thanos-default-secret"},
-			[]templatev1.Parameter{
-				{Name: "S3_BUCKET_NAME"},
-				{Name: "S3_BUCKET_REGION"},
-				{Name: "S3_BUCKET_ENDPOINT"},
-				{Name: "ACCESS_KEY_ID"},
-				{Name: "SECRET_ACCESS_KEY"},
-			},
+			params,
 		),
 	))
 
 	gen.Generate()
 }
 
-func (b Build) Secrets(config clusters.ClusterConfig) {
+func (b Build) Secrets(config clusters.ClusterConfig) error {
 	gen := b.generator(config, "secrets")
-	cacheSecrets := memcachedCacheSecrets(config.Namespace)
 
-	secrets := []runtime.Object{
-		thanosObjectStoreSecret("default-thanos-bucket", config.Namespace),
-	}
+	bucketSecret, params := thanosObjectStoreSecret(config.Namespace, clusters.TemplateFn(clusters.DefaultBucket, config.Templates.ObjectStorageBucket))
+	secrets := []runtime.Object{bucketSecret}
 
-	for _, secret := range cacheSecrets {
-		secrets = append(secrets, secret)
+	for _, role := range thanosCacheRoles {
+		cacheSecret, cacheParams, err := thanosCacheSecret(config, role)
+		if err != nil {
+			return fmt.Errorf("failed to generate the %s cache secret: %w", role, err)
+		}
+		secrets = append(secrets, cacheSecret)
+		params = append(params, cacheParams...)
 	}
 
 	gen.Add("thanos-default-secret.yaml", encoding.GhodssYAML(
 		openshift.WrapInTemplate(
 			secrets,
 			metav1.ObjectMeta{Name: "thanos-object-store-secret"},
-			[]templatev1.Parameter{
-				{Name: "S3_BUCKET_NAME"},
-				{Name: "S3_BUCKET_REGION"},
-				{Name: "S3_BUCKET_ENDPOINT"},
-				{Name: "ACCESS_KEY_ID"},
-				{Name: "SECRET_ACCESS_KEY"},
-			},
+			params,
 		),
 	))
 
+	if bucket, ok := config.Templates.ObjectStorageBucket[clusters.LokiBucket]; ok {
+		lokiSecret, lokiParams := lokiObjectStoreSecret(config.Namespace, bucket)
+		gen.Add("loki-default-secret.yaml", encoding.GhodssYAML(
+			openshift.WrapInTemplate(
+				[]runtime.Object{lokiSecret},
+				metav1.ObjectMeta{Name: "loki-object-store-secret"},
+				lokiParams,
+			),
+		))
+	}
+
 	gen.Generate()
+	return nil
 }
 
 func cacheSecretsStage(namespace string) []*corev1.Secret {
@@ -226,211 +224,3 @@ func cacheSecretsStage(namespace string) []*corev1.Secret {
 		},
 	}
 }
-
-// thanosObjectStoreSecretTemplate creates a templated version of the Thanos object store secret
-func thanosObjectStoreSecretTemplate() *corev1.Secret {
-	config := client.BucketConfig{
-		Type: client.S3,
-		Config: s3.Config{
-			Bucket:    "${S3_BUCKET_NAME}",
-			Region:    "${S3_BUCKET_REGION}",
-			AccessKey: "${ACCESS_KEY_ID}",
-			SecretKey: "${SECRET_ACCESS_KEY}",
-			Endpoint:  "${S3_BUCKET_ENDPOINT}",
-		},
-	}
-	b, err := yaml.Marshal(config)
-	if err != nil {
-		panic(err)
-	}
-
-	return &corev1.Secret{
-		TypeMeta: metav1.TypeMeta{
-			APIVersion: "v1",
-			Kind:       "Secret",
-		},
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      "${SECRET_NAME}",
-			Namespace: "${NAMESPACE}",
-			Labels: map[string]string{
-				"app.kubernetes.io/name": "${SECRET_NAME}",
-			},
-		},
-		Type: corev1.SecretTypeOpaque,
-		StringData: map[string]string{
-			"thanos.yaml": string(b),
-		},
-	}
-}
-
-// thanosObjectStoreTemplate creates a templated version for stage environment
-func thanosObjectStoreSecret(name, namespace string) *corev1.Secret {
-	config := client.BucketConfig{
-		Type: client.S3,
-		Config: s3.Config{
-			Bucket:    "${S3_BUCKET_NAME}",
-			Region:    "${S3_BUCKET_REGION}",
-			AccessKey: "${ACCESS_KEY_ID}",
-			SecretKey: "${SECRET_ACCESS_KEY}",
-			Endpoint:  "${S3_BUCKET_ENDPOINT}",
-		},
-	}
-
-	b, err := yaml.Marshal(config)
-	if err != nil {
-		panic(err)
-	}
-
-	return &corev1.Secret{
-		TypeMeta: metav1.TypeMeta{
-			APIVersion: "v1",
-			Kind:       "Secret",
-		},
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      name,
-			Namespace: namespace,
-			Labels: map[string]string{
-				"app.kubernetes.io/name": name,
-			},
-		},
-		Type: corev1.SecretTypeOpaque,
-		StringData: map[string]string{
-			"thanos.yaml": string(b),
-		},
-	}
-}
-
-func memcachedCacheSecrets(namespace string) []*corev1.Secret {
-	indexCacheConfig := storecache.IndexCacheConfig{
-		Type: storecache.MEMCACHED,
-		Config: cacheutil.MemcachedClientConfig{
-			Addresses: []string{
-				"dnssrv+_client._tcp.thanos-index-cache." + namespace + ".svc",
-			},
-			DNSProviderUpdateInterval: 10 * time.Second,
-			MaxAsyncBufferSize:        2500000,
-			MaxAsyncConcurrency:       1000,
-			MaxGetMultiBatchSize:      100000,
-			MaxGetMultiConcurrency:    1000,
-			MaxIdleConnections:        2500,
-			// TODO(saswatamcode): Figure out a way to pass units for gen.
-			// MaxItemSize:               model.Bytes(5 * 1024 * 1024),
-			Timeout: 2 * time.Second,
-		},
-	}
-
-	indexCacheConfigYaml, err := yaml.Marshal(indexCacheConfig)
-	if err != nil {
-		panic(err)
-	}
-
-	bucketCacheConfig := storecache.CachingWithBackendConfig{
-		Type: storecache.MemcachedBucketCacheProvider,
-		BackendConfig: cacheutil.MemcachedClientConfig{
-			Addresses: []string{
-				"dnssrv+_client._tcp.thanos-bucket-cache." + namespace + ".svc",
-			},
-			DNSProviderUpdateInterval: 10 * time.Second,
-			MaxAsyncBufferSize:        25000,
-			MaxAsyncConcurrency:       50,
-			MaxGetMultiBatchSize:      100,
-			MaxGetMultiConcurrency:    1000,
-			MaxIdleConnections:        1100,
-			// TODO(saswatamcode): Figure out a way to pass units for gen.
-			// MaxItemSize:               model.Bytes(1 * 1024 * 1024),
-			Timeout: 2 * time.Second,
-		},
-		ChunkSubrangeSize:         16000,
-		ChunkObjectAttrsTTL:       24 * time.Hour,
-		ChunkSubrangeTTL:          24 * time.Hour,
-		MaxChunksGetRangeRequests: 3,
-		// MetafileMaxSize:           model.Bytes(1 * 1024 * 1024),
-		MetafileExistsTTL:      2 * time.Hour,
-		MetafileDoesntExistTTL: 15 * time.Minute,
-		MetafileContentTTL:     24 * time.Hour,
-	}
-
-	bucketCacheConfigYaml, err := yaml.Marshal(bucketCacheConfig)
-	if err != nil {
-		panic(err)
-	}
-
-	queryRangeCacheConfig := queryfrontend.CacheProviderConfig{
-		Type: queryfrontend.MEMCACHED,
-		Config: queryfrontend.MemcachedResponseCacheConfig{
-			Memcached: cacheutil.MemcachedClientConfig{
-				Addresses: []string{
-					"dnssrv+_client._tcp.thanos-query-range-cache." + namespace + ".svc",
-				},
-				DNSProviderUpdateInterval: 30 * time.Second,
-				MaxAsyncBufferSize:        1000000,
-				MaxAsyncConcurrency:       100,
-				MaxGetMultiBatchSize:      500,
-				MaxGetMultiConcurrency:    100,
-				MaxIdleConnections:        500,
-				// TODO(saswatamcode): Figure out a way to pass units for gen.
-				// MaxItemSize:               model.Bytes(100 * 1024 * 1024),
-				Timeout: 5 * time.Second,
-			},
-		},
-	}
-
-	queryRangeCacheConfigYaml, err := yaml.Marshal(queryRangeCacheConfig)
-	if err != nil {
-		panic(err)
-	}
-
-	return []*corev1.Secret{
-		{
-			TypeMeta: metav1.TypeMeta{
-				APIVersion: "v1",
-				Kind:       "Secret",
-			},
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      "thanos-index-cache-memcached",
-				Namespace: namespace,
-				Labels: map[string]string{
-					"app.kubernetes.io/name": "thanos-index-cache-memcached",
-				},
-			},
-			Type: corev1.SecretTypeOpaque,
-			StringData: map[string]string{
-				"thanos.yaml": string(indexCacheConfigYaml),
-			},
-		},
-		{
-			TypeMeta: metav1.TypeMeta{
-				APIVersion: "v1",
-				Kind:       "Secret",
-			},
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      "thanos-bucket-cache-memcached",
-				Namespace: namespace,
-				Labels: map[string]string{
-					"app.kubernetes.io/name": "thanos-bucket-cache-memcached",
-				},
-			},
-			Type: corev1.SecretTypeOpaque,
-			StringData: map[string]string{
-				"thanos.yaml": string(bucketCacheConfigYaml),
-			},
-		},
-		{
-			TypeMeta: metav1.TypeMeta{
-				APIVersion: "v1",
-				Kind:       "Secret",
-			},
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      "thanos-query-range-cache-memcached",
-				Namespace: namespace,
-				Labels: map[string]string{
-					"app.kubernetes.io/name": "thanos-query-range-cache-memcached",
-				},
-			},
-			Type: corev1.SecretTypeOpaque,
-			StringData: map[string]string{
-				"thanos.yaml": string(queryRangeCacheConfigYaml),
-			},
-		},
-	}
-}
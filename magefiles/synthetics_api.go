@@ -8,9 +8,7 @@ import (
 	"github.com/rhobs/configuration/clusters"
 
 	"github.com/bwplotka/mimic"
-	"github.com/bwplotka/mimic/encoding"
 	kitlog "github.com/go-kit/log"
-	"github.com/observatorium/observatorium/configuration_go/kubegen/openshift"
 	templatev1 "github.com/openshift/api/template/v1"
 	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 
@@ -80,10 +78,7 @@ func (s Stage) SyntheticsApi() {
 	gen := func() *mimic.Generator {
 		return s.generator(syntheticsApiName)
 	}
-	syntheticsApis := []*syntheticsApiConfig{
-		newSyntheticsApiConfig(clusters.StageMaps, s.namespace()),
-	}
-	syntheticsApi(gen, clusters.StageMaps, syntheticsApis)
+	syntheticsApi(gen, clusters.StageMaps, s.namespace())
 }
 
 // SyntheticsApi creates the syntheticsApi resources for the production environment
@@ -91,71 +86,58 @@ func (p Production) SyntheticsApi() {
 	gen := func() *mimic.Generator {
 		return p.generator(syntheticsApiName)
 	}
-	syntheticsApis := []*syntheticsApiConfig{
-		newSyntheticsApiConfig(clusters.ProductionMaps, p.namespace()),
-	}
-	syntheticsApi(gen, clusters.ProductionMaps, syntheticsApis)
+	syntheticsApi(gen, clusters.ProductionMaps, p.namespace())
 }
 
-func syntheticsApi(g func() *mimic.Generator, m clusters.TemplateMaps, confs []*syntheticsApiConfig) {
-	var sms []runtime.Object
-	var objs []runtime.Object
-
-	for _, c := range confs {
-		objs = append(objs, createBundleSyntheticsApiDeployment(c, m))
-		objs = append(objs, createSyntheticsApiServiceAccount(c))
-		objs = append(objs, createSyntheticsApiRole(c))
-		objs = append(objs, createSyntheticsApiRoleBinding(c))
-		objs = append(objs, createSyntheticsApiService(c))
-		sms = append(sms, createSyntheticsApiServiceMonitor(c))
-	}
-
-	// Set template params
-	params := []templatev1.Parameter{}
-	params = append(params, templatev1.Parameter{
-		Name:  "NAMESPACE",
-		Value: "rhobs",
-	}, templatev1.Parameter{
-		Name:  "IMAGE_TAG",
-		Value: "cea7d4656cd0ad338e580cc6ba266264a9938e5c",
-	}, templatev1.Parameter{
-		Name:  "IMAGE_DIGEST",
-		Value: "",
-	})
-
-	template := openshift.WrapInTemplate(objs, metav1.ObjectMeta{
-		Name: syntheticsApiName,
-	}, sortTemplateParams(params))
-	enc := encoding.GhodssYAML(template)
+// syntheticsApi generates the synthetics API template, and the template of its ServiceMonitor
+func syntheticsApi(g func() *mimic.Generator, m clusters.TemplateMaps, namespace string) {
+	r := newTemplateRenderer(syntheticsApiName, syntheticsApiTemplate)
+	c := newSyntheticsApiConfig(m, namespace, r)
 	gen := g()
-	gen.Add(syntheticsApiTemplate, enc)
+	r.render(gen, syntheticsApiObjects(c))
 	gen.Generate()
 
-	template = openshift.WrapInTemplate(sms, metav1.ObjectMeta{
-		Name: syntheticsApiName + "-service-monitor",
-	}, nil)
+	// The ServiceMonitor shares the config, and declares the parameters it references out of the same ones.
+	sm := newTemplateRenderer(syntheticsApiName+"-service-monitor", "service-monitor-"+syntheticsApiTemplate)
+	for _, p := range r.params {
+		sm.param(p)
+	}
 	gen = g()
-	gen.Add("service-monitor-"+syntheticsApiTemplate, encoding.GhodssYAML(template))
+	sm.render(gen, []runtime.Object{createSyntheticsApiServiceMonitor(c)})
 	gen.Generate()
 }
 
-func newSyntheticsApiConfig(m clusters.TemplateMaps, namespace string) *syntheticsApiConfig {
+// newSyntheticsApiConfig returns the synthetics API config of every output.
+// Templates expose its namespace and image tag as the NAMESPACE and IMAGE_TAG parameters.
+func newSyntheticsApiConfig(m clusters.TemplateMaps, namespace string, r renderer) *syntheticsApiConfig {
+	version := r.param(templatev1.Parameter{Name: "IMAGE_TAG", Value: m.Versions[clusters.SyntheticsAPI]})
 	return &syntheticsApiConfig{
 		Flags:              &syntheticsApiFlags{},
 		Name:               syntheticsApiName,
-		Namespace:          namespace,
-		SyntheticsApiImage: m.Images[syntheticsAPI],
+		Namespace:          r.param(templatev1.Parameter{Name: "NAMESPACE", Value: namespace}),
+		SyntheticsApiImage: m.Images[clusters.SyntheticsAPI],
 		Labels: map[string]string{
 			"app.kubernetes.io/component": syntheticsApiName,
 			"app.kubernetes.io/instance":  "rhobs",
 			"app.kubernetes.io/name":      syntheticsApiName,
 			"app.kubernetes.io/part-of":   "rhobs",
-			"app.kubernetes.io/version":   m.Versions[syntheticsAPI],
+			"app.kubernetes.io/version":   version,
 		},
 		Replicas: defaultGatewaySyntheticsApiReplicas,
 	}
 }
 
+// syntheticsApiObjects returns the synthetics API resources of every output
+func syntheticsApiObjects(config *syntheticsApiConfig) []runtime.Object {
+	return []runtime.Object{
+		createSyntheticsApiDeployment(config),
+		createSyntheticsApiServiceAccount(config),
+		createSyntheticsApiRole(config),
+		createSyntheticsApiRoleBinding(config),
+		createSyntheticsApiService(config),
+	}
+}
+
 func createSyntheticsApiServiceAccount(config *syntheticsApiConfig) *corev1.ServiceAccount {
 	return &corev1.ServiceAccount{
 		TypeMeta: metav1.TypeMeta{
@@ -286,14 +268,10 @@ func (b Build) SyntheticsApi(config clusters.ClusterConfig) {
 		return
 	}
 
-	ns := config.Namespace
 	gen := func() *mimic.Generator {
 		return b.generator(config, syntheticsApiName)
 	}
-	syntheticsApis := []*syntheticsApiConfig{
-		newSyntheticsApiConfig(clusters.ProductionMaps, ns),
-	}
-	syntheticsApi(gen, clusters.ProductionMaps, syntheticsApis)
+	syntheticsApi(gen, config.Templates, config.Namespace)
 }
 
 // generateUnifiedSyntheticsApi generates a single, environment-agnostic template
@@ -303,23 +281,16 @@ func generateUnifiedSyntheticsApi() {
 		return u.generator(syntheticsApiName)
 	}
 
-	// Create a single config without environment-specific values
-	config := &syntheticsApiConfig{
-		Flags:              &syntheticsApiFlags{},
-		Name:               syntheticsApiName,
-		Namespace:          "", // Will be parameterized
-		SyntheticsApiImage: "", // Not used since we use template parameter
-		Labels: map[string]string{
-			"app.kubernetes.io/component": syntheticsApiName,
-			"app.kubernetes.io/instance":  "rhobs",
-			"app.kubernetes.io/name":      syntheticsApiName,
-			"app.kubernetes.io/part-of":   "rhobs",
-			"app.kubernetes.io/version":   "${IMAGE_TAG}",
+	// The namespace and image tag are parameters of every synthetics API template, only their defaults are set here
+	m := clusters.TemplateMaps{
+		Images: clusters.ParamMap[string]{
+			clusters.SyntheticsAPI: clusters.DefaultBaseTemplate().Images[clusters.SyntheticsAPI] + ":${IMAGE_TAG}",
+		},
+		Versions: clusters.ParamMap[string]{
+			clusters.SyntheticsAPI: "cea7d4656cd0ad338e580cc6ba266264a9938e5c",
 		},
-		Replicas: defaultGatewaySyntheticsApiReplicas,
 	}
-
-	syntheticsApi(gen, clusters.TemplateMaps{}, []*syntheticsApiConfig{config})
+	syntheticsApi(gen, m, "rhobs")
 }
 
 // generateSyntheticsBundle generates individual synthetics component resources for bundle deployment
@@ -332,32 +303,21 @@ func generateSyntheticsBundle(config clusters.ClusterConfig) error {
 	bundleGen.Logger = kitlog.NewLogfmtLogger(kitlog.NewSyncWriter(os.Stdout))
 
 	// Create synthetics API resources with concrete values
-	syntheticsConfig := newBundleSyntheticsApiConfig(config.Templates, ns)
+	syntheticsConfig := newSyntheticsApiConfig(config.Templates, ns, numberedBundle)
 
 	// Create synthetics Agent resources with concrete values
 	syntheticsAgentConfig := newBundleSyntheticsAgentConfig(config.Templates, ns)
 
 	// Generate individual synthetics resource files (API + Agent)
-	syntheticsObjs := []runtime.Object{
-		createBundleSyntheticsApiDeployment(syntheticsConfig, config.Templates),
-		createSyntheticsApiServiceAccount(syntheticsConfig),
-		createSyntheticsApiRole(syntheticsConfig),
-		createSyntheticsApiRoleBinding(syntheticsConfig),
-		createSyntheticsApiService(syntheticsConfig),
+	syntheticsObjs := append(syntheticsApiObjects(syntheticsConfig),
 		createSyntheticsAgentDeployment(syntheticsAgentConfig, config.Templates),
 		createSyntheticsAgentService(syntheticsAgentConfig),
 		createSyntheticsAgentServiceAccount(syntheticsAgentConfig),
 		createSyntheticsAgentClusterRole(),
 		createSyntheticsAgentClusterRoleBinding(syntheticsAgentConfig),
 		createSyntheticsAgentConfigMap(syntheticsAgentConfig),
-	}
-
-	for i, obj := range syntheticsObjs {
-		resourceKind := getResourceKind(obj)
-		resourceName := getKubernetesResourceName(obj)
-		filename := fmt.Sprintf("%02d-%s-%s.yaml", i+1, resourceName, resourceKind)
-		bundleGen.Add(filename, encoding.GhodssYAML(obj))
-	}
+	)
+	numberedBundle.render(bundleGen, syntheticsObjs)
 
 	// Generate the bundle files
 	bundleGen.Generate()
@@ -375,26 +335,8 @@ func generateSyntheticsBundle(config clusters.ClusterConfig) error {
 	return nil
 }
 
-// newBundleSyntheticsApiConfig creates a synthetics API config with concrete values for bundle deployment
-func newBundleSyntheticsApiConfig(m clusters.TemplateMaps, namespace string) *syntheticsApiConfig {
-	return &syntheticsApiConfig{
-		Flags:              &syntheticsApiFlags{},
-		Name:               syntheticsApiName,
-		Namespace:          namespace,
-		SyntheticsApiImage: m.Images[clusters.SyntheticsAPI], // Use cluster parameter for image
-		Labels: map[string]string{
-			"app.kubernetes.io/component": syntheticsApiName,
-			"app.kubernetes.io/instance":  "rhobs",
-			"app.kubernetes.io/name":      syntheticsApiName,
-			"app.kubernetes.io/part-of":   "rhobs",
-			"app.kubernetes.io/version":   m.Versions[clusters.SyntheticsAPI], // Use cluster parameter for version
-		},
-		Replicas: defaultGatewaySyntheticsApiReplicas,
-	}
-}
-
-// createBundleSyntheticsApiDeployment creates a Deployment with concrete values following the exact template
-func createBundleSyntheticsApiDeployment(config *syntheticsApiConfig, m clusters.TemplateMaps) *appsv1.Deployment {
+// createSyntheticsApiDeployment creates the synthetics API Deployment
+func createSyntheticsApiDeployment(config *syntheticsApiConfig) *appsv1.Deployment {
 	labels := config.Labels
 
 	syntheticsApiContainer := corev1.Container{
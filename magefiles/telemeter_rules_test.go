@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+
+	"github.com/rhobs/configuration/internal/ruletest"
+)
+
+func TestTelemeterRules(t *testing.T) {
+	const tenantID = "FB870BF3-9F3A-44FF-9BF7-D7A047A52F43"
+
+	ruletest.Run(t, &monitoringv1.PrometheusRule{Spec: monitoringv1.PrometheusRuleSpec{Groups: rules()}},
+		ruletest.Test{
+			Name: "cluster availability",
+			Input: []ruletest.Series{
+				{Series: `cluster_version{_id="a", type="current", version="4.16.0"}`, Values: "1x10"},
+				{Series: `cluster_version{_id="b", type="current", version="4.16.0"}`, Values: "1x10"},
+				{Series: `cluster_version{_id="b", type="failure", version="4.15.0"}`, Values: "1x10"},
+				{Series: `cluster_operator_conditions{_id="b", condition="Degraded", name="etcd", reason="Unhealthy"}`, Values: "1x10"},
+				{Series: `cluster_operator_conditions{_id="a", condition="Degraded", name="etcd", reason="AsExpected"}`, Values: "0x10"},
+			},
+			Exprs: []ruletest.ExprTest{
+				{
+					At:   8 * time.Minute,
+					Expr: `id_version:cluster_available`,
+					Expected: []ruletest.Sample{
+						{Labels: map[string]string{"__name__": "id_version:cluster_available", "_id": "a", "version": "4.16.0", "tenant_id": tenantID}, Value: 1},
+						{Labels: map[string]string{"__name__": "id_version:cluster_available", "_id": "b", "version": "4.15.0", "tenant_id": tenantID}, Value: 0},
+					},
+				},
+				{
+					At:   8 * time.Minute,
+					Expr: `name_reason:cluster_operator_degraded:count`,
+					Expected: []ruletest.Sample{
+						{Labels: map[string]string{"__name__": "name_reason:cluster_operator_degraded:count", "name": "etcd", "reason": "Unhealthy", "tenant_id": tenantID}, Value: 1},
+					},
+				},
+			},
+		},
+		ruletest.Test{
+			Name: "virtual cpu hours",
+			Input: []ruletest.Series{
+				{Series: `cluster:capacity_cpu_cores:sum{_id="a", label_node_role_kubernetes_io=""}`, Values: "4x70"},
+			},
+			Exprs: []ruletest.ExprTest{
+				{
+					At:   time.Hour,
+					Expr: `steps:count1h`,
+					Expected: []ruletest.Sample{
+						{Labels: map[string]string{"__name__": "steps:count1h", "tenant_id": tenantID}, Value: 12},
+					},
+				},
+				// Without hypershift, ROSA vCPU hours are the capacity of the cluster over the hour.
+				{
+					At:   time.Hour,
+					Expr: `rosa:cluster:vcpu_hours`,
+					Expected: []ruletest.Sample{
+						{Labels: map[string]string{"__name__": "rosa:cluster:vcpu_hours", "_id": "a", "tenant_id": tenantID}, Value: 4},
+					},
+				},
+			},
+		},
+	)
+}
@@ -0,0 +1,416 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bwplotka/mimic"
+	"github.com/bwplotka/mimic/encoding"
+	"github.com/ghodss/yaml"
+	kitlog "github.com/go-kit/log"
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/rhobs/configuration/clusters"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+const (
+	// tenantRulesPath holds a directory of rule sources per tenant, named after the tenant in the gateway.
+	// Each source is an OpenShift template of PrometheusRules parameterized by NAMESPACE and TENANT.
+	tenantRulesPath = "resources/tenant-rules"
+
+	// tenantLabel is the label the ruler scopes the rules of a tenant with, see RuleTenancyConfig of the ruler.
+	tenantLabel = "tenant_id"
+	// tenantRuleLabel is the PrometheusRule label the ruler reads the tenant of the rules from.
+	tenantRuleLabel = "operator.thanos.io/tenant"
+)
+
+// tenantRulesTemplate is the part of a tenant rule source read by the build.
+type tenantRulesTemplate struct {
+	Objects []monitoringv1.PrometheusRule `json:"objects"`
+}
+
+// TenantRules generates the PrometheusRules of the tenants hosted by the cluster for its Thanos ruler.
+// A tenant is hosted if it is registered in the gateway of the cluster and has rule sources under resources/tenant-rules.
+func (b Build) TenantRules(config clusters.ClusterConfig) error {
+	rules, err := clusterTenantRules(config)
+	if err != nil {
+		return err
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+
+	gen := b.generator(config, "tenant-rules")
+	prefix := ""
+	if isMigratedCluster(config) {
+		// The rules follow the Thanos resources of the metrics bundle, see generateMetricsBundle
+		gen = &mimic.Generator{}
+		gen = gen.With(templatePath, templateClustersPath, string(config.Environment), string(config.Name), "metrics", "bundle")
+		gen.Logger = kitlog.NewLogfmtLogger(kitlog.NewSyncWriter(os.Stdout))
+		prefix = "05-tenant-rules-"
+	}
+	for _, rule := range rules {
+		gen.Add(prefix+rule.Name+"-PrometheusRule.yaml", encoding.GhodssYAML(rule))
+	}
+	gen.Generate()
+	return nil
+}
+
+// clusterTenantRules loads and validates the rules of every tenant hosted by the cluster.
+func clusterTenantRules(config clusters.ClusterConfig) ([]*monitoringv1.PrometheusRule, error) {
+	if config.GatewayConfig == nil {
+		return nil, nil
+	}
+
+	var rules []*monitoringv1.PrometheusRule
+	for _, tenant := range config.GatewayConfig.Tenants().Tenants {
+		files, err := tenantRuleFiles(tenant.Name)
+		if err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			continue
+		}
+		if !slices.Contains(config.BuildSteps, clusters.StepDefaultThanosStack) {
+			return nil, fmt.Errorf("cluster %s hosts the rules of tenant %s but has no Thanos ruler", config.Name, tenant.Name)
+		}
+
+		tenantRules, err := loadTenantRules(files, config.Namespace, tenant.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the rules of tenant %s: %w", tenant.Name, err)
+		}
+		rules = append(rules, tenantRules...)
+	}
+
+	// The rules of all tenants share the namespace of the cluster.
+	seen := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		if seen[rule.Name] {
+			return nil, fmt.Errorf("PrometheusRule %s is defined by more than one tenant of cluster %s", rule.Name, config.Name)
+		}
+		seen[rule.Name] = true
+	}
+	return rules, nil
+}
+
+// tenantRuleFiles returns the rule sources of the tenant in lexical order, which is the order they are assembled in.
+// Sources are numbered to keep related domains together.
+func tenantRuleFiles(tenant string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(tenantRulesPath, tenant, "*.yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the rules of tenant %s: %w", tenant, err)
+	}
+	return files, nil
+}
+
+// loadTenantRules reads the PrometheusRules of the rule sources for the given namespace and tenant ID,
+// and validates them. The rules are labelled for the ruler to pick them up and scope them to the tenant.
+func loadTenantRules(files []string, namespace, tenantID string) ([]*monitoringv1.PrometheusRule, error) {
+	params := strings.NewReplacer("${NAMESPACE}", namespace, "${TENANT}", tenantID)
+
+	var rules []*monitoringv1.PrometheusRule
+	var errs []error
+	seen := make(map[string]string)
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		var source tenantRulesTemplate
+		if err := yaml.Unmarshal([]byte(params.Replace(string(content))), &source); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+
+		for _, obj := range source.Objects {
+			if other, ok := seen[obj.Name]; ok {
+				errs = append(errs, fmt.Errorf("%s: PrometheusRule %s is already defined in %s", file, obj.Name, other))
+				continue
+			}
+			seen[obj.Name] = file
+
+			if err := validateTenantRule(obj, tenantID); err != nil {
+				errs = append(errs, fmt.Errorf("%s: PrometheusRule %s: %w", file, obj.Name, err))
+				continue
+			}
+
+			labels := map[string]string{}
+			for k, v := range obj.Labels {
+				labels[k] = v
+			}
+			labels[tenantRuleLabel] = tenantID
+			rules = append(rules, NewPrometheusTenantRule(obj.Name, namespace, labels, obj.Annotations, obj.Spec.Groups))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// validateTenantRule checks that the PrometheusRule is scoped to the tenant, and that its groups and rules would be
+// accepted by the ruler: names, durations and expressions.
+func validateTenantRule(obj monitoringv1.PrometheusRule, tenantID string) error {
+	if obj.Kind != monitoringv1.PrometheusRuleKind {
+		return fmt.Errorf("unexpected kind %q", obj.Kind)
+	}
+	if obj.Namespace == "" {
+		return fmt.Errorf("namespace cannot be empty")
+	}
+	if tenant, ok := obj.Labels[tenantRuleLabel]; ok && tenant != tenantID {
+		return fmt.Errorf("label %s must be the tenant, got %q", tenantRuleLabel, tenant)
+	}
+	if len(obj.Spec.Groups) == 0 {
+		return fmt.Errorf("no rule groups")
+	}
+
+	var errs []error
+	groups := make(map[string]bool, len(obj.Spec.Groups))
+	for _, group := range obj.Spec.Groups {
+		if group.Name == "" {
+			errs = append(errs, fmt.Errorf("rule group name cannot be empty"))
+			continue
+		}
+		if groups[group.Name] {
+			errs = append(errs, fmt.Errorf("rule group %s is defined more than once", group.Name))
+		}
+		groups[group.Name] = true
+
+		if group.Interval != nil {
+			if _, err := model.ParseDuration(string(*group.Interval)); err != nil {
+				errs = append(errs, fmt.Errorf("rule group %s: invalid interval: %w", group.Name, err))
+			}
+		}
+		if len(group.Rules) == 0 {
+			errs = append(errs, fmt.Errorf("rule group %s has no rules", group.Name))
+		}
+		for i, rule := range group.Rules {
+			if err := validateTenantRuleEntry(rule, tenantID); err != nil {
+				errs = append(errs, fmt.Errorf("rule group %s: rule %d (%s): %w", group.Name, i, cmp.Or(rule.Alert, rule.Record), err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func validateTenantRuleEntry(rule monitoringv1.Rule, tenantID string) error {
+	switch {
+	case rule.Alert != "" && rule.Record != "":
+		return fmt.Errorf("a rule cannot both alert and record")
+	case rule.Record != "":
+		if !model.IsValidLegacyMetricName(rule.Record) {
+			return fmt.Errorf("invalid recording rule name")
+		}
+		if rule.For != nil || rule.KeepFiringFor != nil || len(rule.Annotations) > 0 {
+			return fmt.Errorf("recording rules cannot have a for duration or annotations")
+		}
+	case rule.Alert != "":
+		if !model.LabelValue(rule.Alert).IsValid() {
+			return fmt.Errorf("invalid alert name")
+		}
+		if rule.For != nil {
+			if _, err := model.ParseDuration(string(*rule.For)); err != nil {
+				return fmt.Errorf("invalid for duration: %w", err)
+			}
+		}
+		if rule.KeepFiringFor != nil {
+			if _, err := model.ParseDuration(string(*rule.KeepFiringFor)); err != nil {
+				return fmt.Errorf("invalid keep_firing_for duration: %w", err)
+			}
+		}
+	default:
+		return fmt.Errorf("a rule must either alert or record")
+	}
+
+	for name, value := range rule.Labels {
+		if !model.LabelName(name).IsValidLegacy() {
+			return fmt.Errorf("invalid label name %q", name)
+		}
+		if name == tenantLabel && value != tenantID {
+			return fmt.Errorf("label %s must be the tenant, got %q", tenantLabel, value)
+		}
+	}
+
+	expr, err := parser.ParseExpr(rule.Expr.String())
+	if err != nil {
+		return fmt.Errorf("invalid expression: %w", err)
+	}
+	// The ruler scopes the expressions to the tenant, matchers on other tenants would never select anything.
+	var matcherErr error
+	parser.Inspect(expr, func(node parser.Node, _ []parser.Node) error {
+		vs, ok := node.(*parser.VectorSelector)
+		if !ok {
+			return nil
+		}
+		for _, m := range vs.LabelMatchers {
+			if m.Name == tenantLabel && !m.Matches(tenantID) {
+				matcherErr = fmt.Errorf("expression selects %s other than the tenant: %s", tenantLabel, m)
+			}
+		}
+		return nil
+	})
+	return matcherErr
+}
+
+// TenantRuleTemplates validates the rule sources of every tenant and assembles them in a single OpenShift template
+// per tenant, resources/tenant-rules/<tenant>.yaml, keeping its NAMESPACE and TENANT parameters.
+func (Build) TenantRuleTemplates() error {
+	dirs, err := os.ReadDir(tenantRulesPath)
+	if err != nil {
+		return fmt.Errorf("failed to list tenant rules: %w", err)
+	}
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		files, err := tenantRuleFiles(dir.Name())
+		if err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			continue
+		}
+		if _, err := loadTenantRules(files, "${NAMESPACE}", "${TENANT}"); err != nil {
+			return fmt.Errorf("invalid rules for tenant %s: %w", dir.Name(), err)
+		}
+		if err := writeTenantRulesTemplate(dir.Name(), files); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tenantRulesTemplateMeta is the assembled template of a tenant without its objects, in the order it is written.
+type tenantRulesTemplateMeta struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Metadata   struct {
+		Name        string            `yaml:"name"`
+		Annotations map[string]string `yaml:"annotations"`
+	} `yaml:"metadata"`
+	Parameters []tenantRulesParameter `yaml:"parameters"`
+}
+
+type tenantRulesParameter struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Required    bool   `yaml:"required"`
+}
+
+// writeTenantRulesTemplate concatenates the objects of the rule sources as they are written.
+// Comments are dropped, the sources are the place to read them, but the first line comment of each source
+// describes it in the header of the template.
+func writeTenantRulesTemplate(tenant string, files []string) error {
+	var objects []*yamlv3.Node
+	descriptions := make([]string, 0, len(files))
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		first, _, _ := strings.Cut(string(content), "\n")
+		description, _ := strings.CutPrefix(first, "# ")
+		if description == first {
+			description = ""
+		}
+		descriptions = append(descriptions, description)
+
+		var doc yamlv3.Node
+		if err := yamlv3.Unmarshal(content, &doc); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		if len(doc.Content) == 0 {
+			continue
+		}
+		source := doc.Content[0]
+		for i := 0; i+1 < len(source.Content); i += 2 {
+			if source.Content[i].Value == "objects" {
+				objects = append(objects, source.Content[i+1].Content...)
+			}
+		}
+	}
+
+	template := tenantRulesTemplateMeta{
+		APIVersion: "template.openshift.io/v1",
+		Kind:       "Template",
+	}
+	template.Metadata.Name = fmt.Sprintf("rhobs-%s-rules", tenant)
+	template.Metadata.Annotations = map[string]string{
+		"description": fmt.Sprintf("Template for deploying %s rules to RHOBS", strings.ToUpper(tenant)),
+	}
+	template.Parameters = []tenantRulesParameter{
+		{Name: "NAMESPACE", Description: "Namespace to deploy the rules to", Required: true},
+		{Name: "TENANT", Description: "Tenant to deploy the rules to", Required: true},
+	}
+	var node yamlv3.Node
+	if err := node.Encode(template); err != nil {
+		return fmt.Errorf("failed to encode the rules template of tenant %s: %w", tenant, err)
+	}
+	node.Content = append(node.Content,
+		&yamlv3.Node{Kind: yamlv3.ScalarNode, Value: "objects"},
+		&yamlv3.Node{Kind: yamlv3.SequenceNode, Content: objects},
+	)
+	resetYAMLStyle(&node)
+
+	var out bytes.Buffer
+	out.WriteString(tenantRulesTemplateHeader(tenant, files, descriptions))
+	enc := yamlv3.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return fmt.Errorf("failed to encode the rules template of tenant %s: %w", tenant, err)
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+
+	path := filepath.Join(tenantRulesPath, tenant+".yaml")
+	if err := os.WriteFile(path, out.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	logger := kitlog.NewLogfmtLogger(kitlog.NewSyncWriter(os.Stdout))
+	_ = logger.Log("msg", "generated output", "file", path)
+	return nil
+}
+
+// resetYAMLStyle lets the encoder pick the style of every node and drops the comments.
+func resetYAMLStyle(node *yamlv3.Node) {
+	node.Style = 0
+	node.HeadComment, node.LineComment, node.FootComment = "", "", ""
+	for _, child := range node.Content {
+		resetYAMLStyle(child)
+	}
+}
+
+func tenantRulesTemplateHeader(tenant string, files, descriptions []string) string {
+	var b strings.Builder
+	rule := "# " + strings.Repeat("=", 76) + "\n"
+	b.WriteString(rule)
+	fmt.Fprintf(&b, "# WARNING: This file is auto-generated from %s/%s/*.yaml\n", tenantRulesPath, tenant)
+	b.WriteString("# DO NOT EDIT THIS FILE DIRECTLY!\n#\n")
+	fmt.Fprintf(&b, "# To modify %s tenant rules:\n", strings.ToUpper(tenant))
+	fmt.Fprintf(&b, "#   1. Edit the appropriate file in %s/%s/\n", tenantRulesPath, tenant)
+	b.WriteString("#   2. Run: make tenant-rules\n")
+	b.WriteString("#   3. Commit both the source file and this generated file\n#\n")
+	b.WriteString("# Source files:\n")
+	width := 0
+	for _, file := range files {
+		width = max(width, len(tenant)+1+len(filepath.Base(file)))
+	}
+	for i, file := range files {
+		source := tenant + "/" + filepath.Base(file)
+		if descriptions[i] == "" {
+			fmt.Fprintf(&b, "#   - %s\n", source)
+			continue
+		}
+		fmt.Fprintf(&b, "#   - %-*s (%s)\n", width, source, descriptions[i])
+	}
+	b.WriteString(rule)
+	return b.String()
+}
@@ -23,7 +23,7 @@ const (
 )
 
 // ThanosOperatorCRDS Generates the CRDs for the Thanos operator.
-// This is synced from the latest upstream ref at:
+// They are read from the upstream store, vendored by mage sync:operator from the ref at:
 // https://github.com/thanos-community/thanos-operator/tree/main/config/crd/bases
 func (b Build) ThanosOperatorCRDS(config clusters.ClusterConfig) error {
 	// For rhobss01ue1 and rhobsi01uw2 clusters, CRDs are handled in metrics bundle
@@ -35,37 +35,23 @@ func (b Build) ThanosOperatorCRDS(config clusters.ClusterConfig) error {
 }
 
 // CRDS Generates the CRDs for the Thanos operator.
-// This is synced from the latest upstream ref at:
+// They are read from the upstream store, vendored by mage sync:operator from the ref at:
 // https://github.com/thanos-community/thanos-operator/tree/main/config/crd/bases
 func (p Production) CRDS() error {
 	return crds(p.generator(crdTemplateDir))
 }
 
 // CRDS Generates the CRDs for the Thanos operator.
-// This is synced from the latest upstream ref at:
+// They are read from the upstream store, vendored by mage sync:operator from the ref at:
 // https://github.com/thanos-community/thanos-operator/tree/main/config/crd/bases
 func (s Stage) CRDS() error {
 	return crds(s.generator(crdTemplateDir))
 }
 
 func crds(gen *mimic.Generator) error {
-	const (
-		compact   = "thanoscompacts.yaml"
-		queries   = "thanosqueries.yaml"
-		receivers = "thanosreceives.yaml"
-		rulers    = "thanosrulers.yaml"
-		stores    = "thanosstores.yaml"
-		base      = "https://raw.githubusercontent.com/thanos-community/thanos-operator/" + thanosOperatorCRDRef + "/config/crd/bases/monitoring.thanos.io_"
-	)
-
-	var objs []runtime.Object
-	for _, component := range []string{compact, queries, receivers, rulers, stores} {
-		crd, err := getCustomResourceDefinition(base + component)
-		if err != nil {
-			return err
-		}
-
-		objs = append(objs, crd)
+	objs, err := upstreamCRDObjects(thanosOperatorUpstream(thanosOperatorCRDRef), thanosOperatorCRDs)
+	if err != nil {
+		return err
 	}
 
 	gen.Add("thanos-operator-crds.yaml", encoding.GhodssYAML(
@@ -133,10 +119,6 @@ func operator(namespace string, gen *mimic.Generator, m clusters.TemplateMaps) e
 }
 
 func operatorResources(namespace string, m clusters.TemplateMaps) ([]runtime.Object, error) {
-	const (
-		// thanosOperatorRBACBase is the base URL for fetching RBAC resources from upstream.
-		thanosOperatorRBACBase = "https://raw.githubusercontent.com/thanos-community/thanos-operator/" + thanosOperatorCRDRef + "/config/rbac/"
-	)
 	config.SetGlobalPrefix("thanos-operator-")
 	config.SetGlobalNamespace(namespace)
 	config.SetGlobalCommonLabels("thanos-operator", "thanos-operator", "rhobs")
@@ -216,8 +198,8 @@ func operatorResources(namespace string, m clusters.TemplateMaps) ([]runtime.Obj
 		}
 	}
 
-	// Fetch Manager ClusterRole from upstream
-	managerRole, err := getClusterRole(thanosOperatorRBACBase + "role.yaml")
+	// Manager ClusterRole vendored from upstream
+	managerRole, err := getClusterRole(thanosOperatorUpstream(thanosOperatorCRDRef), thanosOperatorManagerRole)
 	if err != nil {
 		return nil, err
 	}
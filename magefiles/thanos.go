@@ -4,16 +4,18 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"regexp"
+	"slices"
 	"sort"
 	"strings"
 
 	"github.com/bwplotka/mimic"
 	"github.com/bwplotka/mimic/encoding"
 	kitlog "github.com/go-kit/log"
+	observatoriumapi "github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/observatorium/api"
 	kghelpers "github.com/observatorium/observatorium/configuration_go/kubegen/helpers"
 	"github.com/observatorium/observatorium/configuration_go/kubegen/openshift"
 	routev1 "github.com/openshift/api/route/v1"
-	templatev1 "github.com/openshift/api/template/v1"
 	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	"github.com/rhobs/configuration/clusters"
 	"github.com/thanos-community/thanos-operator/api/v1alpha1"
@@ -25,23 +27,38 @@ import (
 	"k8s.io/utils/ptr"
 )
 
-func (b Build) DefaultThanosStack(config clusters.ClusterConfig) {
+func (b Build) DefaultThanosStack(config clusters.ClusterConfig) error {
 	// For rhobss01ue1 and rhobsi01uw2 clusters, generate metrics bundle with individual resources
 	if isMigratedCluster(config) {
 		if err := generateMetricsBundle(config); err != nil {
-			log.Printf("Error generating metrics bundle: %v", err)
+			return fmt.Errorf("failed to generate metrics bundle: %w", err)
 		}
-		return
+		return nil
 	}
 
 	gen := b.generator(config, "thanos-operator-default-cr")
-	var objs []runtime.Object
-
-	objs = append(objs, defaultQueryCR(config.Namespace, config.Templates, true)...)
-	objs = append(objs, defaultReceiveCR(config.Namespace, config.Templates))
-	objs = append(objs, defaultCompactCR(config.Namespace, config.Templates, true)...)
+	objs, err := defaultQueryCR(config.Namespace, config.Templates, true)
+	if err != nil {
+		return fmt.Errorf("failed to generate query: %w", err)
+	}
+	objs = append(objs, defaultReceiveCR(config.Namespace, config.Templates, config.Hashrings))
+	objs = append(objs, defaultCompactCR(config.Namespace, config.Templates, config.Compaction, compactShardingConfig(config), true)...)
 	objs = append(objs, defaultRulerCR(config.Namespace, config.Templates))
-	objs = append(objs, defaultStoreCR(config.Namespace, config.Templates))
+	objs = append(objs, defaultStoreCRs(config.Namespace, config.Templates)...)
+	objs = thanosCacheReferences(config, objs)
+
+	objs, err = receiveLimitsObjects(config, objs)
+	if err != nil {
+		return fmt.Errorf("failed to generate receive limits: %w", err)
+	}
+	objs, err = federationObjects(config, objs)
+	if err != nil {
+		return fmt.Errorf("failed to generate query federation: %w", err)
+	}
+	secretGen := b.generator(config, "thanos-federation-secret")
+	if addFederationSecretTemplate(secretGen, config) {
+		secretGen.Generate()
+	}
 
 	// Sort objects by Kind then Name
 	sort.Slice(objs, func(i, j int) bool {
@@ -60,21 +77,16 @@ func (b Build) DefaultThanosStack(config clusters.ClusterConfig) {
 		openshift.WrapInTemplate(
 			objs,
 			metav1.ObjectMeta{Name: "thanos-rhobs"},
-			[]templatev1.Parameter{
-				{
-					Name:     "OAUTH_PROXY_COOKIE_SECRET",
-					Generate: "expression",
-					From:     `[a-zA-Z0-9]{40}`,
-				},
-			},
+			nil,
 		),
 	))
 
 	gen.Generate()
+	return nil
 }
 
 // Thanos Generates the RHOBS-specific CRs for Thanos Operator.
-func (p Production) Thanos() {
+func (p Production) Thanos() error {
 	templateDir := "rhobs-thanos-operator"
 
 	gen := p.generator(templateDir)
@@ -86,7 +98,11 @@ func (p Production) Thanos() {
 		`--endpoint=dnssrv+_grpc._tcp.observatorium-thanos-receive-default.observatorium-metrics-production.svc.cluster.local`,
 	}
 
-	objs = append(objs, queryCR(ns, clusters.ProductionMaps, true, tmpAdditionalQueryArgs...)...)
+	queryObjs, err := queryCR(ns, clusters.ProductionMaps, true, tmpAdditionalQueryArgs...)
+	if err != nil {
+		return fmt.Errorf("failed to generate query: %w", err)
+	}
+	objs = append(objs, queryObjs...)
 	objs = append(objs, tmpStoreProduction(ns, clusters.ProductionMaps)...)
 	objs = append(objs, compactTempProduction(clusters.ProductionMaps)...)
 	// objs = append(objs, tmpRulerCR(ns, clusters.ProductionMaps))
@@ -108,21 +124,16 @@ func (p Production) Thanos() {
 		openshift.WrapInTemplate(
 			objs,
 			metav1.ObjectMeta{Name: "thanos-rhobs"},
-			[]templatev1.Parameter{
-				{
-					Name:     "OAUTH_PROXY_COOKIE_SECRET",
-					Generate: "expression",
-					From:     `[a-zA-Z0-9]{40}`,
-				},
-			},
+			nil,
 		),
 	))
 
 	gen.Generate()
+	return nil
 }
 
 // Thanos Generates the RHOBS-specific CRs for Thanos Operator.
-func (s Stage) Thanos() {
+func (s Stage) Thanos() error {
 	templateDir := "rhobs-thanos-operator"
 
 	gen := s.generator(templateDir)
@@ -132,7 +143,11 @@ func (s Stage) Thanos() {
 	var objs []runtime.Object
 
 	objs = append(objs, receiveCR(s.namespace(), clusters.StageMaps))
-	objs = append(objs, queryCR(s.namespace(), clusters.StageMaps, true, tmpAdditionalQueryArgs...)...)
+	queryObjs, err := queryCR(s.namespace(), clusters.StageMaps, true, tmpAdditionalQueryArgs...)
+	if err != nil {
+		return fmt.Errorf("failed to generate query: %w", err)
+	}
+	objs = append(objs, queryObjs...)
 	objs = append(objs, rulerCR(s.namespace(), clusters.StageMaps)...)
 	// TODO: Add compact CRs for stage once we shut down previous
 	// objs = append(objs, compactCR(s.namespace(), templates, true)...)
@@ -156,190 +171,23 @@ func (s Stage) Thanos() {
 		openshift.WrapInTemplate(
 			objs,
 			metav1.ObjectMeta{Name: "thanos-rhobs"},
-			[]templatev1.Parameter{
-				{
-					Name:     "OAUTH_PROXY_COOKIE_SECRET",
-					Generate: "expression",
-					From:     `[a-zA-Z0-9]{40}`,
-				},
-			},
+			nil,
 		),
 	))
 
 	gen.Generate()
+	return nil
 }
 
 func storeCR(namespace string, m clusters.TemplateMaps) []runtime.Object {
-	store0to2w := &v1alpha1.ThanosStore{
-		TypeMeta: metav1.TypeMeta{
-			APIVersion: "monitoring.thanos.io/v1alpha1",
-			Kind:       "ThanosStore",
-		},
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      "telemeter-0to2w",
-			Namespace: namespace,
-		},
-		Spec: v1alpha1.ThanosStoreSpec{
-			CommonFields: v1alpha1.CommonFields{
-				Image:                ptr.To(clusters.TemplateFn("STORE02W", m.Images)),
-				Version:              ptr.To(clusters.TemplateFn("STORE02W", m.Versions)),
-				ImagePullPolicy:      ptr.To(corev1.PullIfNotPresent),
-				LogLevel:             ptr.To(clusters.TemplateFn("STORE02W", m.LogLevels)),
-				LogFormat:            ptr.To("logfmt"),
-				ResourceRequirements: ptr.To(clusters.TemplateFn("STORE02W", m.ResourceRequirements)),
-				SecurityContext: &corev1.PodSecurityContext{
-					SeccompProfile: &corev1.SeccompProfile{
-						Type: corev1.SeccompProfileTypeRuntimeDefault,
-					},
-				},
-			},
-			Replicas:            clusters.TemplateFn("STORE02W", m.Replicas),
-			ObjectStorageConfig: clusters.TemplateFn("TELEMETER", m.ObjectStorageBucket),
-			ShardingStrategy: v1alpha1.ShardingStrategy{
-				Type:   v1alpha1.Block,
-				Shards: 1,
-			},
-			IndexHeaderConfig: &v1alpha1.IndexHeaderConfig{
-				EnableLazyReader:      ptr.To(true),
-				LazyDownloadStrategy:  ptr.To("lazy"),
-				LazyReaderIdleTimeout: ptr.To(v1alpha1.Duration("5m")),
-			},
-			StoreLimitsOptions: &v1alpha1.StoreLimitsOptions{
-				StoreLimitsRequestSamples: 627040000,
-				StoreLimitsRequestSeries:  1000000,
-			},
-			BlockConfig: &v1alpha1.BlockConfig{
-				BlockDiscoveryStrategy:    v1alpha1.BlockDiscoveryStrategy("concurrent"),
-				BlockFilesConcurrency:     ptr.To(int32(1)),
-				BlockMetaFetchConcurrency: ptr.To(int32(32)),
-			},
-			IgnoreDeletionMarksDelay: v1alpha1.Duration("24h"),
-			TimeRangeConfig: &v1alpha1.TimeRangeConfig{
-				MaxTime: ptr.To(v1alpha1.Duration("-2w")),
-			},
-			StorageConfiguration: v1alpha1.StorageConfiguration{
-				Size: clusters.TemplateFn("STORE02W", m.StorageSize),
-			},
-			Additional: v1alpha1.Additional{
-				Args: []string{},
-			},
-		},
+	store := telemeterStoreCR(namespace, m)
+	store.Spec.StoreLimitsOptions = &v1alpha1.StoreLimitsOptions{
+		StoreLimitsRequestSamples: 627040000,
+		StoreLimitsRequestSeries:  1000000,
 	}
-
-	store2wto90d := &v1alpha1.ThanosStore{
-		TypeMeta: metav1.TypeMeta{
-			APIVersion: "monitoring.thanos.io/v1alpha1",
-			Kind:       "ThanosStore",
-		},
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      "telemeter-2wto90d",
-			Namespace: namespace,
-		},
-		Spec: v1alpha1.ThanosStoreSpec{
-			CommonFields: v1alpha1.CommonFields{
-				Image:                ptr.To(clusters.TemplateFn("STORE2W90D", m.Images)),
-				Version:              ptr.To(clusters.TemplateFn("STORE2W90D", m.Versions)),
-				ImagePullPolicy:      ptr.To(corev1.PullIfNotPresent),
-				LogLevel:             ptr.To(clusters.TemplateFn("STORE2W90D", m.LogLevels)),
-				LogFormat:            ptr.To("logfmt"),
-				ResourceRequirements: ptr.To(clusters.TemplateFn("STORE2W90D", m.ResourceRequirements)),
-				SecurityContext: &corev1.PodSecurityContext{
-					SeccompProfile: &corev1.SeccompProfile{
-						Type: corev1.SeccompProfileTypeRuntimeDefault,
-					},
-				},
-				PodDisruptionBudgetConfig: &v1alpha1.PodDisruptionBudgetConfig{
-					Enable: ptr.To(false),
-				},
-			},
-			Replicas:            clusters.TemplateFn("STORE2W90D", m.Replicas),
-			ObjectStorageConfig: clusters.TemplateFn("TELEMETER", m.ObjectStorageBucket),
-			ShardingStrategy: v1alpha1.ShardingStrategy{
-				Type:   v1alpha1.Block,
-				Shards: 1,
-			},
-			IndexHeaderConfig: &v1alpha1.IndexHeaderConfig{
-				EnableLazyReader:      ptr.To(true),
-				LazyDownloadStrategy:  ptr.To("lazy"),
-				LazyReaderIdleTimeout: ptr.To(v1alpha1.Duration("5m")),
-			},
-			StoreLimitsOptions: &v1alpha1.StoreLimitsOptions{
-				StoreLimitsRequestSamples: 627040000,
-				StoreLimitsRequestSeries:  1000000,
-			},
-			BlockConfig: &v1alpha1.BlockConfig{
-				BlockDiscoveryStrategy:    v1alpha1.BlockDiscoveryStrategy("concurrent"),
-				BlockFilesConcurrency:     ptr.To(int32(1)),
-				BlockMetaFetchConcurrency: ptr.To(int32(32)),
-			},
-			IgnoreDeletionMarksDelay: v1alpha1.Duration("24h"),
-			TimeRangeConfig: &v1alpha1.TimeRangeConfig{
-				MinTime: ptr.To(v1alpha1.Duration("-90d")),
-				MaxTime: ptr.To(v1alpha1.Duration("-2w")),
-			},
-			StorageConfiguration: v1alpha1.StorageConfiguration{
-				Size: clusters.TemplateFn("STORE2W90D", m.StorageSize),
-			},
-			Additional: v1alpha1.Additional{
-				Args: []string{},
-			},
-		},
-	}
-
-	store90dplus := &v1alpha1.ThanosStore{
-		TypeMeta: metav1.TypeMeta{
-			APIVersion: "monitoring.thanos.io/v1alpha1",
-			Kind:       "ThanosStore",
-		},
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      "telemeter-90dplus",
-			Namespace: namespace,
-		},
-		Spec: v1alpha1.ThanosStoreSpec{
-			CommonFields: v1alpha1.CommonFields{
-				Image:                ptr.To(clusters.TemplateFn("STORE90D+", m.Images)),
-				Version:              ptr.To(clusters.TemplateFn("STORE90D+", m.Versions)),
-				ImagePullPolicy:      ptr.To(corev1.PullIfNotPresent),
-				LogLevel:             ptr.To(clusters.TemplateFn("STORE90D+", m.LogLevels)),
-				LogFormat:            ptr.To("logfmt"),
-				ResourceRequirements: ptr.To(clusters.TemplateFn("STORE90D+", m.ResourceRequirements)),
-				SecurityContext: &corev1.PodSecurityContext{
-					SeccompProfile: &corev1.SeccompProfile{
-						Type: corev1.SeccompProfileTypeRuntimeDefault,
-					},
-				},
-			},
-			Replicas:            clusters.TemplateFn("STORE90D+", m.Replicas),
-			ObjectStorageConfig: clusters.TemplateFn("TELEMETER", m.ObjectStorageBucket),
-			ShardingStrategy: v1alpha1.ShardingStrategy{
-				Type:   v1alpha1.Block,
-				Shards: 1,
-			},
-			IndexHeaderConfig: &v1alpha1.IndexHeaderConfig{
-				EnableLazyReader:      ptr.To(true),
-				LazyDownloadStrategy:  ptr.To("lazy"),
-				LazyReaderIdleTimeout: ptr.To(v1alpha1.Duration("5m")),
-			},
-			StoreLimitsOptions: &v1alpha1.StoreLimitsOptions{
-				StoreLimitsRequestSamples: 627040000,
-				StoreLimitsRequestSeries:  1000000,
-			},
-			BlockConfig: &v1alpha1.BlockConfig{
-				BlockDiscoveryStrategy:    v1alpha1.BlockDiscoveryStrategy("concurrent"),
-				BlockFilesConcurrency:     ptr.To(int32(1)),
-				BlockMetaFetchConcurrency: ptr.To(int32(32)),
-			},
-			IgnoreDeletionMarksDelay: v1alpha1.Duration("24h"),
-			TimeRangeConfig: &v1alpha1.TimeRangeConfig{
-				MinTime: ptr.To(v1alpha1.Duration("-90d")),
-			},
-			StorageConfiguration: v1alpha1.StorageConfiguration{
-				Size: clusters.TemplateFn("STORE90D+", m.StorageSize),
-			},
-			Additional: v1alpha1.Additional{
-				Args: []string{},
-			},
-		},
+	var objs []runtime.Object
+	for _, tier := range telemeterStoreTiers(m) {
+		objs = append(objs, storeTierCR(store, tier))
 	}
 
 	// RHOBS-904: Standalone Store for RH Resource Optimisation (ROS) Managed Service
@@ -367,7 +215,7 @@ func storeCR(namespace string, m clusters.TemplateMaps) []runtime.Object {
 				},
 			},
 			Replicas:            clusters.TemplateFn("STORE_ROS", m.Replicas),
-			ObjectStorageConfig: clusters.TemplateFn("ROS", m.ObjectStorageBucket),
+			ObjectStorageConfig: clusters.TemplateFn("ROS", m.ObjectStorageBucket).ObjectStorageConfig,
 			ShardingStrategy: v1alpha1.ShardingStrategy{
 				Type:   v1alpha1.Block,
 				Shards: 1,
@@ -420,7 +268,7 @@ func storeCR(namespace string, m clusters.TemplateMaps) []runtime.Object {
 				},
 			},
 			Replicas:            clusters.TemplateFn("STORE_DEFAULT", m.Replicas),
-			ObjectStorageConfig: clusters.TemplateFn("DEFAULT", m.ObjectStorageBucket),
+			ObjectStorageConfig: clusters.TemplateFn("DEFAULT", m.ObjectStorageBucket).ObjectStorageConfig,
 			ShardingStrategy: v1alpha1.ShardingStrategy{
 				Type:   v1alpha1.Block,
 				Shards: 1,
@@ -452,7 +300,7 @@ func storeCR(namespace string, m clusters.TemplateMaps) []runtime.Object {
 		},
 	}
 
-	objs := []runtime.Object{store0to2w, store2wto90d, store90dplus, storeDefault}
+	objs = append(objs, storeDefault)
 
 	//TODO @moadz RHOBS-904: Temporary block, only return in stage
 	if clusters.TemplateFn("STORE_ROS", m.Replicas) > 0 {
@@ -510,161 +358,52 @@ func tmpStoreProduction(namespace string, m clusters.TemplateMaps) []runtime.Obj
 		},
 	}
 
-	zero2wArgs := v1alpha1.Additional{
+	memcachedArgs := v1alpha1.Additional{
 		Args: []string{
 			bc,
 			iC,
 		},
 	}
 
-	store0to2w := &v1alpha1.ThanosStore{
-		TypeMeta: metav1.TypeMeta{
-			APIVersion: "monitoring.thanos.io/v1alpha1",
-			Kind:       "ThanosStore",
-		},
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      "telemeter-0to2w",
-			Namespace: namespace,
-		},
-		Spec: v1alpha1.ThanosStoreSpec{
-			Additional: zero2wArgs,
-			CommonFields: v1alpha1.CommonFields{
-				Affinity: &corev1.Affinity{
-					NodeAffinity: &corev1.NodeAffinity{
-						RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
-							NodeSelectorTerms: []corev1.NodeSelectorTerm{
-								{
-									MatchExpressions: []corev1.NodeSelectorRequirement{
-										{
-											Key:      "workload-type",
-											Operator: corev1.NodeSelectorOpIn,
-											Values:   []string{"query"},
-										},
-									},
-								},
-							},
-						},
-					},
-					PodAntiAffinity: &corev1.PodAntiAffinity{
-						RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
-							{
-								TopologyKey: "kubernetes.io/hostname",
-								LabelSelector: &metav1.LabelSelector{
-									MatchExpressions: []metav1.LabelSelectorRequirement{
-										{
-											Key:      "app.kubernetes.io/instance",
-											Operator: metav1.LabelSelectorOpIn,
-											Values:   []string{"telemeter-0to2w"},
-										},
-									},
-								},
-							},
-						},
-					},
-				},
-				Image:                ptr.To(clusters.TemplateFn("STORE02W", m.Images)),
-				Version:              ptr.To(clusters.TemplateFn("STORE02W", m.Versions)),
-				ImagePullPolicy:      ptr.To(corev1.PullIfNotPresent),
-				LogLevel:             ptr.To(clusters.TemplateFn("STORE02W", m.LogLevels)),
-				LogFormat:            ptr.To("logfmt"),
-				ResourceRequirements: ptr.To(clusters.TemplateFn("STORE02W", m.ResourceRequirements)),
-				SecurityContext: &corev1.PodSecurityContext{
-					SeccompProfile: &corev1.SeccompProfile{
-						Type: corev1.SeccompProfileTypeRuntimeDefault,
-					},
-				},
-			},
-			Replicas:            clusters.TemplateFn("STORE02W", m.Replicas),
-			ObjectStorageConfig: clusters.TemplateFn("TELEMETER", m.ObjectStorageBucket),
-			ShardingStrategy: v1alpha1.ShardingStrategy{
-				Type:   v1alpha1.Block,
-				Shards: 1,
-			},
-			IndexHeaderConfig: &v1alpha1.IndexHeaderConfig{
-				EnableLazyReader:      ptr.To(true),
-				LazyReaderIdleTimeout: ptr.To(v1alpha1.Duration("5m")),
-			},
-			StoreLimitsOptions: &v1alpha1.StoreLimitsOptions{
-				StoreLimitsRequestSamples: 0,
-				StoreLimitsRequestSeries:  0,
-			},
-			BlockConfig: &v1alpha1.BlockConfig{
-				BlockDiscoveryStrategy:    v1alpha1.BlockDiscoveryStrategy("concurrent"),
-				BlockFilesConcurrency:     ptr.To(int32(1)),
-				BlockMetaFetchConcurrency: ptr.To(int32(32)),
-			},
-			IgnoreDeletionMarksDelay: v1alpha1.Duration("12h"),
-			TimeRangeConfig: &v1alpha1.TimeRangeConfig{
-				MinTime: ptr.To(v1alpha1.Duration("-336h")),
-			},
-			StorageConfiguration: v1alpha1.StorageConfiguration{
-				Size: clusters.TemplateFn("STORE02W", m.StorageSize),
-			},
-		},
+	store := telemeterStoreCR(namespace, m)
+	var objs []runtime.Object
+	for _, tier := range telemeterStoreTiers(m) {
+		s := storeTierCR(store, tier)
+		s.Spec.Affinity = queryNodeAffinity(s.Name)
+		s.Spec.Additional = additionalCacheArgs
+		if tier.ExternalCaches {
+			s.Spec.Additional = memcachedArgs
+		}
+		objs = append(objs, s)
 	}
 
-	store2wto90d := &v1alpha1.ThanosStore{
+	storeDefault := &v1alpha1.ThanosStore{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "monitoring.thanos.io/v1alpha1",
 			Kind:       "ThanosStore",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "telemeter-2wto90d",
+			Name:      "default",
 			Namespace: namespace,
 		},
 		Spec: v1alpha1.ThanosStoreSpec{
 			Additional: additionalCacheArgs,
 			CommonFields: v1alpha1.CommonFields{
-				Affinity: &corev1.Affinity{
-					NodeAffinity: &corev1.NodeAffinity{
-						RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
-							NodeSelectorTerms: []corev1.NodeSelectorTerm{
-								{
-									MatchExpressions: []corev1.NodeSelectorRequirement{
-										{
-											Key:      "workload-type",
-											Operator: corev1.NodeSelectorOpIn,
-											Values:   []string{"query"},
-										},
-									},
-								},
-							},
-						},
-					},
-					PodAntiAffinity: &corev1.PodAntiAffinity{
-						RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
-							{
-								TopologyKey: "kubernetes.io/hostname",
-								LabelSelector: &metav1.LabelSelector{
-									MatchExpressions: []metav1.LabelSelectorRequirement{
-										{
-											Key:      "app.kubernetes.io/instance",
-											Operator: metav1.LabelSelectorOpIn,
-											Values:   []string{"telemeter-2wto90d"},
-										},
-									},
-								},
-							},
-						},
-					},
-				},
-				Image:                ptr.To(clusters.TemplateFn("STORE2W90D", m.Images)),
-				Version:              ptr.To(clusters.TemplateFn("STORE2W90D", m.Versions)),
+				Affinity:             queryNodeAffinity("default"),
+				Image:                ptr.To(clusters.TemplateFn("STORE_DEFAULT", m.Images)),
+				Version:              ptr.To(clusters.TemplateFn("STORE_DEFAULT", m.Versions)),
 				ImagePullPolicy:      ptr.To(corev1.PullIfNotPresent),
-				LogLevel:             ptr.To(clusters.TemplateFn("STORE2W90D", m.LogLevels)),
+				LogLevel:             ptr.To(clusters.TemplateFn("STORE_DEFAULT", m.LogLevels)),
 				LogFormat:            ptr.To("logfmt"),
-				ResourceRequirements: ptr.To(clusters.TemplateFn("STORE2W90D", m.ResourceRequirements)),
+				ResourceRequirements: ptr.To(clusters.TemplateFn("STORE_DEFAULT", m.ResourceRequirements)),
 				SecurityContext: &corev1.PodSecurityContext{
 					SeccompProfile: &corev1.SeccompProfile{
 						Type: corev1.SeccompProfileTypeRuntimeDefault,
 					},
 				},
-				PodDisruptionBudgetConfig: &v1alpha1.PodDisruptionBudgetConfig{
-					Enable: ptr.To(false),
-				},
 			},
-			Replicas:            clusters.TemplateFn("STORE2W90D", m.Replicas),
-			ObjectStorageConfig: clusters.TemplateFn("TELEMETER", m.ObjectStorageBucket),
+			Replicas:            clusters.TemplateFn("STORE_DEFAULT", m.Replicas),
+			ObjectStorageConfig: clusters.TemplateFn("DEFAULT", m.ObjectStorageBucket).ObjectStorageConfig,
 			ShardingStrategy: v1alpha1.ShardingStrategy{
 				Type:   v1alpha1.Block,
 				Shards: 1,
@@ -685,74 +424,50 @@ func tmpStoreProduction(namespace string, m clusters.TemplateMaps) []runtime.Obj
 			},
 			IgnoreDeletionMarksDelay: v1alpha1.Duration("24h"),
 			TimeRangeConfig: &v1alpha1.TimeRangeConfig{
-				MinTime: ptr.To(v1alpha1.Duration("-2160h")),
-				MaxTime: ptr.To(v1alpha1.Duration("-336h")),
+				MaxTime: ptr.To(v1alpha1.Duration("-22h")),
 			},
 			StorageConfiguration: v1alpha1.StorageConfiguration{
-				Size: clusters.TemplateFn("STORE2W90D", m.StorageSize),
+				Size: clusters.TemplateFn("STORE_DEFAULT", m.StorageSize),
 			},
 		},
 	}
+	return append(objs, storeDefault)
+}
 
-	store90dplus := &v1alpha1.ThanosStore{
+// telemeterStoreTiers returns the STORE_TELEMETER tiers of the legacy environments.
+func telemeterStoreTiers(m clusters.TemplateMaps) []clusters.StoreTier {
+	tiers := clusters.TemplateFn(clusters.StoreTelemeter, m.StoreTiers)
+	if err := clusters.ValidateStoreTiers(tiers); err != nil {
+		panic(fmt.Sprintf("invalid telemeter store tiers: %v", err))
+	}
+	return tiers
+}
+
+// telemeterStoreCR returns the telemeter store of the legacy environments, which their tiers partition.
+func telemeterStoreCR(namespace string, m clusters.TemplateMaps) *v1alpha1.ThanosStore {
+	return &v1alpha1.ThanosStore{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "monitoring.thanos.io/v1alpha1",
 			Kind:       "ThanosStore",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "telemeter-90dplus",
+			Name:      "telemeter",
 			Namespace: namespace,
 		},
 		Spec: v1alpha1.ThanosStoreSpec{
-			Additional: additionalCacheArgs,
 			CommonFields: v1alpha1.CommonFields{
-				Affinity: &corev1.Affinity{
-					NodeAffinity: &corev1.NodeAffinity{
-						RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
-							NodeSelectorTerms: []corev1.NodeSelectorTerm{
-								{
-									MatchExpressions: []corev1.NodeSelectorRequirement{
-										{
-											Key:      "workload-type",
-											Operator: corev1.NodeSelectorOpIn,
-											Values:   []string{"query"},
-										},
-									},
-								},
-							},
-						},
-					},
-					PodAntiAffinity: &corev1.PodAntiAffinity{
-						RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
-							{
-								TopologyKey: "kubernetes.io/hostname",
-								LabelSelector: &metav1.LabelSelector{
-									MatchExpressions: []metav1.LabelSelectorRequirement{
-										{
-											Key:      "app.kubernetes.io/instance",
-											Operator: metav1.LabelSelectorOpIn,
-											Values:   []string{"telemeter-90dplus"},
-										},
-									},
-								},
-							},
-						},
-					},
-				},
-				Image:                ptr.To(clusters.TemplateFn("STORE90D+", m.Images)),
-				Version:              ptr.To(clusters.TemplateFn("STORE90D+", m.Versions)),
-				ImagePullPolicy:      ptr.To(corev1.PullIfNotPresent),
-				LogLevel:             ptr.To(clusters.TemplateFn("STORE90D+", m.LogLevels)),
-				LogFormat:            ptr.To("logfmt"),
-				ResourceRequirements: ptr.To(clusters.TemplateFn("STORE90D+", m.ResourceRequirements)),
+				Image:           ptr.To(clusters.TemplateFn(clusters.StoreTelemeter, m.Images)),
+				Version:         ptr.To(clusters.TemplateFn(clusters.StoreTelemeter, m.Versions)),
+				ImagePullPolicy: ptr.To(corev1.PullIfNotPresent),
+				LogLevel:        ptr.To(clusters.TemplateFn(clusters.StoreTelemeter, m.LogLevels)),
+				LogFormat:       ptr.To("logfmt"),
 				SecurityContext: &corev1.PodSecurityContext{
 					SeccompProfile: &corev1.SeccompProfile{
 						Type: corev1.SeccompProfileTypeRuntimeDefault,
 					},
 				},
 			},
-			Replicas:            clusters.TemplateFn("STORE90D+", m.Replicas),
-			ObjectStorageConfig: clusters.TemplateFn("TELEMETER", m.ObjectStorageBucket),
+			ObjectStorageConfig: clusters.TemplateFn("TELEMETER", m.ObjectStorageBucket).ObjectStorageConfig,
 			ShardingStrategy: v1alpha1.ShardingStrategy{
 				Type:   v1alpha1.Block,
 				Shards: 1,
@@ -762,113 +477,52 @@ func tmpStoreProduction(namespace string, m clusters.TemplateMaps) []runtime.Obj
 				LazyDownloadStrategy:  ptr.To("lazy"),
 				LazyReaderIdleTimeout: ptr.To(v1alpha1.Duration("5m")),
 			},
-			StoreLimitsOptions: &v1alpha1.StoreLimitsOptions{
-				StoreLimitsRequestSamples: 0,
-				StoreLimitsRequestSeries:  0,
-			},
+			StoreLimitsOptions: &v1alpha1.StoreLimitsOptions{},
 			BlockConfig: &v1alpha1.BlockConfig{
 				BlockDiscoveryStrategy:    v1alpha1.BlockDiscoveryStrategy("concurrent"),
 				BlockFilesConcurrency:     ptr.To(int32(1)),
 				BlockMetaFetchConcurrency: ptr.To(int32(32)),
 			},
 			IgnoreDeletionMarksDelay: v1alpha1.Duration("24h"),
-			TimeRangeConfig: &v1alpha1.TimeRangeConfig{
-				MinTime: ptr.To(v1alpha1.Duration("-8760h")),
-				MaxTime: ptr.To(v1alpha1.Duration("-2160h")),
-			},
-			StorageConfiguration: v1alpha1.StorageConfiguration{
-				Size: clusters.TemplateFn("STORE90D+", m.StorageSize),
-			},
 		},
 	}
+}
 
-	storeDefault := &v1alpha1.ThanosStore{
-		TypeMeta: metav1.TypeMeta{
-			APIVersion: "monitoring.thanos.io/v1alpha1",
-			Kind:       "ThanosStore",
-		},
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      "default",
-			Namespace: namespace,
-		},
-		Spec: v1alpha1.ThanosStoreSpec{
-			Additional: additionalCacheArgs,
-			CommonFields: v1alpha1.CommonFields{
-				Affinity: &corev1.Affinity{
-					NodeAffinity: &corev1.NodeAffinity{
-						RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
-							NodeSelectorTerms: []corev1.NodeSelectorTerm{
-								{
-									MatchExpressions: []corev1.NodeSelectorRequirement{
-										{
-											Key:      "workload-type",
-											Operator: corev1.NodeSelectorOpIn,
-											Values:   []string{"query"},
-										},
-									},
-								},
+// queryNodeAffinity schedules the pods of the instance on the query nodes, one per node.
+func queryNodeAffinity(instance string) *corev1.Affinity {
+	return &corev1.Affinity{
+		NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{
+					{
+						MatchExpressions: []corev1.NodeSelectorRequirement{
+							{
+								Key:      "workload-type",
+								Operator: corev1.NodeSelectorOpIn,
+								Values:   []string{"query"},
 							},
 						},
 					},
-					PodAntiAffinity: &corev1.PodAntiAffinity{
-						RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+				},
+			},
+		},
+		PodAntiAffinity: &corev1.PodAntiAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+				{
+					TopologyKey: "kubernetes.io/hostname",
+					LabelSelector: &metav1.LabelSelector{
+						MatchExpressions: []metav1.LabelSelectorRequirement{
 							{
-								TopologyKey: "kubernetes.io/hostname",
-								LabelSelector: &metav1.LabelSelector{
-									MatchExpressions: []metav1.LabelSelectorRequirement{
-										{
-											Key:      "app.kubernetes.io/instance",
-											Operator: metav1.LabelSelectorOpIn,
-											Values:   []string{"default"},
-										},
-									},
-								},
+								Key:      "app.kubernetes.io/instance",
+								Operator: metav1.LabelSelectorOpIn,
+								Values:   []string{instance},
 							},
 						},
 					},
 				},
-				Image:                ptr.To(clusters.TemplateFn("STORE_DEFAULT", m.Images)),
-				Version:              ptr.To(clusters.TemplateFn("STORE_DEFAULT", m.Versions)),
-				ImagePullPolicy:      ptr.To(corev1.PullIfNotPresent),
-				LogLevel:             ptr.To(clusters.TemplateFn("STORE_DEFAULT", m.LogLevels)),
-				LogFormat:            ptr.To("logfmt"),
-				ResourceRequirements: ptr.To(clusters.TemplateFn("STORE_DEFAULT", m.ResourceRequirements)),
-				SecurityContext: &corev1.PodSecurityContext{
-					SeccompProfile: &corev1.SeccompProfile{
-						Type: corev1.SeccompProfileTypeRuntimeDefault,
-					},
-				},
-			},
-			Replicas:            clusters.TemplateFn("STORE_DEFAULT", m.Replicas),
-			ObjectStorageConfig: clusters.TemplateFn("DEFAULT", m.ObjectStorageBucket),
-			ShardingStrategy: v1alpha1.ShardingStrategy{
-				Type:   v1alpha1.Block,
-				Shards: 1,
-			},
-			IndexHeaderConfig: &v1alpha1.IndexHeaderConfig{
-				EnableLazyReader:      ptr.To(true),
-				LazyDownloadStrategy:  ptr.To("lazy"),
-				LazyReaderIdleTimeout: ptr.To(v1alpha1.Duration("5m")),
-			},
-			StoreLimitsOptions: &v1alpha1.StoreLimitsOptions{
-				StoreLimitsRequestSamples: 0,
-				StoreLimitsRequestSeries:  0,
-			},
-			BlockConfig: &v1alpha1.BlockConfig{
-				BlockDiscoveryStrategy:    v1alpha1.BlockDiscoveryStrategy("concurrent"),
-				BlockFilesConcurrency:     ptr.To(int32(1)),
-				BlockMetaFetchConcurrency: ptr.To(int32(32)),
-			},
-			IgnoreDeletionMarksDelay: v1alpha1.Duration("24h"),
-			TimeRangeConfig: &v1alpha1.TimeRangeConfig{
-				MaxTime: ptr.To(v1alpha1.Duration("-22h")),
-			},
-			StorageConfiguration: v1alpha1.StorageConfiguration{
-				Size: clusters.TemplateFn("STORE_DEFAULT", m.StorageSize),
 			},
 		},
 	}
-	return []runtime.Object{store0to2w, store2wto90d, store90dplus, storeDefault}
 }
 
 func TmpRulerCR(namespace string, templates clusters.TemplateMaps) *v1alpha1.ThanosRuler {
@@ -909,7 +563,7 @@ func TmpRulerCR(namespace string, templates clusters.TemplateMaps) *v1alpha1.Tha
 				TenantLabel:      "tenant_id",
 				TenantValueLabel: "operator.thanos.io/tenant",
 			},
-			ObjectStorageConfig: clusters.TemplateFn("TELEMETER", templates.ObjectStorageBucket),
+			ObjectStorageConfig: clusters.TemplateFn("TELEMETER", templates.ObjectStorageBucket).ObjectStorageConfig,
 			ExternalLabels: map[string]string{
 				"rule_replica": "$(NAME)",
 			},
@@ -954,85 +608,48 @@ func receiveCR(namespace string, templates clusters.TemplateMaps) *v1alpha1.Than
 				},
 			},
 			Ingester: v1alpha1.IngesterSpec{
-				DefaultObjectStorageConfig: clusters.TemplateFn("TELEMETER", templates.ObjectStorageBucket),
+				DefaultObjectStorageConfig: clusters.TemplateFn("TELEMETER", templates.ObjectStorageBucket).ObjectStorageConfig,
 				Additional: v1alpha1.Additional{
 					Args: []string{},
 				},
-				Hashrings: []v1alpha1.IngesterHashringSpec{
-					{
-						Name: "telemeter",
-						CommonFields: v1alpha1.CommonFields{
-							Image:                ptr.To(clusters.TemplateFn("RECEIVE_INGESTOR_TELEMETER", templates.Images)),
-							Version:              ptr.To(clusters.TemplateFn("RECEIVE_INGESTOR_TELEMETER", templates.Versions)),
-							ImagePullPolicy:      ptr.To(corev1.PullIfNotPresent),
-							LogLevel:             ptr.To(clusters.TemplateFn("RECEIVE_INGESTOR_TELEMETER", templates.LogLevels)),
-							LogFormat:            ptr.To("logfmt"),
-							ResourceRequirements: ptr.To(clusters.TemplateFn("RECEIVE_INGESTOR_TELEMETER", templates.ResourceRequirements)),
-						},
-						ExternalLabels: map[string]string{
-							"replica": "$(POD_NAME)",
-						},
-						Replicas: clusters.TemplateFn("RECEIVE_INGESTOR_TELEMETER", templates.Replicas),
-						TSDBConfig: v1alpha1.TSDBConfig{
-							Retention: v1alpha1.Duration("4h"),
-						},
-						AsyncForwardWorkerCount:  ptr.To(uint64(50)),
-						TooFarInFutureTimeWindow: ptr.To(v1alpha1.Duration("5m")),
-						StoreLimitsOptions: &v1alpha1.StoreLimitsOptions{
-							StoreLimitsRequestSamples: 627040000,
-							StoreLimitsRequestSeries:  1000000,
-						},
-						TenancyConfig: &v1alpha1.TenancyConfig{
-							TenantMatcherType: "exact",
-							DefaultTenantID:   "FB870BF3-9F3A-44FF-9BF7-D7A047A52F43",
-							TenantHeader:      "THANOS-TENANT",
-							TenantLabelName:   "tenant_id",
-						},
-						StorageConfiguration: v1alpha1.StorageConfiguration{
-							Size: clusters.TemplateFn("RECEIVE_TELEMETER", templates.StorageSize),
-						},
-					},
-					{
-						Name: "default",
-						CommonFields: v1alpha1.CommonFields{
-							Image:                ptr.To(clusters.TemplateFn("RECEIVE_INGESTOR_DEFAULT", templates.Images)),
-							Version:              ptr.To(clusters.TemplateFn("RECEIVE_INGESTOR_DEFAULT", templates.Versions)),
-							ImagePullPolicy:      ptr.To(corev1.PullIfNotPresent),
-							LogLevel:             ptr.To(clusters.TemplateFn("RECEIVE_INGESTOR_DEFAULT", templates.LogLevels)),
-							LogFormat:            ptr.To("logfmt"),
-							ResourceRequirements: ptr.To(clusters.TemplateFn("RECEIVE_INGESTOR_DEFAULT", templates.ResourceRequirements)),
-						},
-						ExternalLabels: map[string]string{
-							"replica": "$(POD_NAME)",
-						},
-						Replicas: clusters.TemplateFn("RECEIVE_INGESTOR_DEFAULT", templates.Replicas),
-						TSDBConfig: v1alpha1.TSDBConfig{
-							Retention: v1alpha1.Duration("1d"),
-						},
-						AsyncForwardWorkerCount:  ptr.To(uint64(5)),
-						TooFarInFutureTimeWindow: ptr.To(v1alpha1.Duration("5m")),
-						StoreLimitsOptions: &v1alpha1.StoreLimitsOptions{
-							StoreLimitsRequestSamples: 0,
-							StoreLimitsRequestSeries:  0,
-						},
-						TenancyConfig: &v1alpha1.TenancyConfig{
-							TenantMatcherType: "exact",
-							DefaultTenantID:   "FB870BF3-9F3A-44FF-9BF7-D7A047A52F43",
-							TenantHeader:      "THANOS-TENANT",
-							TenantLabelName:   "tenant_id",
-						},
-						ObjectStorageConfig: ptr.To(clusters.TemplateFn("DEFAULT", templates.ObjectStorageBucket)),
-						StorageConfiguration: v1alpha1.StorageConfiguration{
-							Size: clusters.TemplateFn("RECEIVE_DEFAULT", templates.StorageSize),
-						},
-					},
-				},
+				Hashrings: legacyReceiveHashrings(templates),
 			},
 		},
 	}
 }
 
-func defaultQueryCR(namespace string, templates clusters.TemplateMaps, oauth bool, withAdditionalArgs ...string) []runtime.Object {
+// legacyReceiveHashrings returns the hashrings of the stage receivers. Both take every tenant, the first one,
+// telemeter, receives all of them.
+func legacyReceiveHashrings(templates clusters.TemplateMaps) []v1alpha1.IngesterHashringSpec {
+	hashrings := receiveHashrings(templates, []clusters.Hashring{
+		{
+			Name:        "telemeter",
+			Component:   "RECEIVE_INGESTOR_TELEMETER",
+			Retention:   "4h",
+			StorageSize: clusters.TemplateFn("RECEIVE_TELEMETER", templates.StorageSize),
+			// The default bucket of the receivers is the TELEMETER one
+			ReceiveBucket: true,
+			StoreLimits: v1alpha1.StoreLimitsOptions{
+				StoreLimitsRequestSamples: 627040000,
+				StoreLimitsRequestSeries:  1000000,
+			},
+		},
+		{
+			Name:                "default",
+			Retention:           "1d",
+			StorageSize:         clusters.TemplateFn("RECEIVE_DEFAULT", templates.StorageSize),
+			Bucket:              "DEFAULT",
+			AsyncForwardWorkers: 5,
+		},
+	})
+
+	for i := range hashrings {
+		hashrings[i].SecurityContext = nil
+	}
+	return hashrings
+}
+
+func defaultQueryCR(namespace string, templates clusters.TemplateMaps, oauth bool, withAdditionalArgs ...string) ([]runtime.Object, error) {
 	var objs []runtime.Object
 
 	query := &v1alpha1.ThanosQuery{
@@ -1099,30 +716,19 @@ func defaultQueryCR(namespace string, templates clusters.TemplateMaps, oauth boo
 						},
 					},
 				},
-				Replicas:             clusters.TemplateFn("QUERY_FRONTEND", templates.Replicas),
-				CompressResponses:    true,
-				LogQueriesLongerThan: ptr.To(v1alpha1.Duration("10s")),
-				LabelsMaxRetries:     3,
-				QueryRangeMaxRetries: 3,
+				Replicas:          clusters.TemplateFn("QUERY_FRONTEND", templates.Replicas),
+				CompressResponses: true,
 				QueryLabelSelector: &metav1.LabelSelector{
 					MatchLabels: map[string]string{
 						"operator.thanos.io/query-api": "true",
 					},
 				},
-				QueryRangeSplitInterval: ptr.To(v1alpha1.Duration("48h")),
-				LabelsSplitInterval:     ptr.To(v1alpha1.Duration("48h")),
-				LabelsDefaultTimeRange:  ptr.To(v1alpha1.Duration("336h")),
-				QueryRangeResponseCacheConfig: &v1alpha1.CacheConfig{
-					ExternalCacheConfig: &corev1.SecretKeySelector{
-						LocalObjectReference: corev1.LocalObjectReference{
-							Name: "thanos-query-range-cache-memcached",
-						},
-						Key: "thanos.yaml",
-					},
-				},
 			},
 		},
 	}
+	if err := applyQueryFrontendPolicy(query.Spec.QueryFrontend, templates); err != nil {
+		return nil, fmt.Errorf("invalid query frontend policy: %w", err)
+	}
 	if oauth {
 		route := &routev1.Route{
 			TypeMeta: metav1.TypeMeta{
@@ -1170,10 +776,67 @@ func defaultQueryCR(namespace string, templates clusters.TemplateMaps, oauth boo
 	}
 
 	objs = append(objs, query)
+	return objs, nil
+}
+
+// defaultStoreCRs returns a ThanosStore per configured store tier, or a single store serving everything older than 22h.
+func defaultStoreCRs(namespace string, templates clusters.TemplateMaps) []runtime.Object {
+	store := defaultStoreCR(namespace, templates)
+	tiers := templates.StoreTiers[clusters.StoreDefault]
+	if len(tiers) == 0 {
+		return []runtime.Object{store}
+	}
+
+	objs := make([]runtime.Object, 0, len(tiers))
+	for _, tier := range tiers {
+		objs = append(objs, storeTierCR(store, tier))
+	}
 	return objs
 }
 
-func defaultStoreCR(namespace string, templates clusters.TemplateMaps) runtime.Object {
+// storeTierCR derives the ThanosStore of a tier from the store it partitions.
+func storeTierCR(store *v1alpha1.ThanosStore, tier clusters.StoreTier) *v1alpha1.ThanosStore {
+	s := store.DeepCopy()
+	s.Name = fmt.Sprintf("%s-%s", store.Name, tier.Name)
+
+	// The bounds the tier leaves unset keep the time range of the store
+	if s.Spec.TimeRangeConfig == nil {
+		s.Spec.TimeRangeConfig = &v1alpha1.TimeRangeConfig{}
+	}
+	if tier.MinAge != "" {
+		s.Spec.TimeRangeConfig.MaxTime = ptr.To(v1alpha1.Duration("-" + tier.MinAge))
+	}
+	if tier.MaxAge != "" {
+		s.Spec.TimeRangeConfig.MinTime = ptr.To(v1alpha1.Duration("-" + tier.MaxAge))
+	}
+
+	if tier.Replicas != 0 {
+		s.Spec.Replicas = tier.Replicas
+	}
+	if tier.Shards != 0 {
+		s.Spec.ShardingStrategy.Shards = tier.Shards
+	}
+	if tier.Resources.Limits != nil || tier.Resources.Requests != nil {
+		s.Spec.ResourceRequirements = ptr.To(tier.Resources)
+	}
+	if tier.StorageSize != "" {
+		s.Spec.StorageConfiguration.Size = tier.StorageSize
+	}
+	if tier.IgnoreDeletionMarksDelay != "" {
+		s.Spec.IgnoreDeletionMarksDelay = tier.IgnoreDeletionMarksDelay
+	}
+	if tier.EagerIndexHeaders && s.Spec.IndexHeaderConfig != nil {
+		s.Spec.IndexHeaderConfig.LazyDownloadStrategy = nil
+	}
+	if tier.DisablePodDisruptionBudget {
+		s.Spec.PodDisruptionBudgetConfig = &v1alpha1.PodDisruptionBudgetConfig{
+			Enable: ptr.To(false),
+		}
+	}
+	return s
+}
+
+func defaultStoreCR(namespace string, templates clusters.TemplateMaps) *v1alpha1.ThanosStore {
 	return &v1alpha1.ThanosStore{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "monitoring.thanos.io/v1alpha1",
@@ -1198,7 +861,7 @@ func defaultStoreCR(namespace string, templates clusters.TemplateMaps) runtime.O
 				},
 			},
 			Replicas:            clusters.TemplateFn(clusters.StoreDefault, templates.Replicas),
-			ObjectStorageConfig: clusters.TemplateFn(clusters.DefaultBucket, templates.ObjectStorageBucket),
+			ObjectStorageConfig: clusters.TemplateFn(clusters.DefaultBucket, templates.ObjectStorageBucket).ObjectStorageConfig,
 			IndexCacheConfig: &v1alpha1.CacheConfig{
 				ExternalCacheConfig: &corev1.SecretKeySelector{
 					LocalObjectReference: corev1.LocalObjectReference{
@@ -1245,7 +908,7 @@ func defaultStoreCR(namespace string, templates clusters.TemplateMaps) runtime.O
 	}
 }
 
-func defaultReceiveCR(namespace string, templates clusters.TemplateMaps) runtime.Object {
+func defaultReceiveCR(namespace string, templates clusters.TemplateMaps, hashrings []clusters.Hashring) runtime.Object {
 	grpcDisableEndlessRetry := `{
   "loadBalancingPolicy":"round_robin",
   "retryPolicy": {
@@ -1294,55 +957,103 @@ func defaultReceiveCR(namespace string, templates clusters.TemplateMaps) runtime
 				},
 			},
 			Ingester: v1alpha1.IngesterSpec{
-				DefaultObjectStorageConfig: clusters.TemplateFn(clusters.DefaultBucket, templates.ObjectStorageBucket),
+				DefaultObjectStorageConfig: clusters.TemplateFn(clusters.DefaultBucket, templates.ObjectStorageBucket).ObjectStorageConfig,
 				Additional:                 v1alpha1.Additional{},
-				Hashrings: []v1alpha1.IngesterHashringSpec{
-					{
-						Name: "default",
-						CommonFields: v1alpha1.CommonFields{
-							Image:                ptr.To(clusters.TemplateFn(clusters.ReceiveIngestorDefault, templates.Images)),
-							Version:              ptr.To(clusters.TemplateFn(clusters.ReceiveIngestorDefault, templates.Versions)),
-							ImagePullPolicy:      ptr.To(corev1.PullIfNotPresent),
-							LogLevel:             ptr.To(clusters.TemplateFn(clusters.ReceiveIngestorDefault, templates.LogLevels)),
-							LogFormat:            ptr.To("logfmt"),
-							ResourceRequirements: ptr.To(clusters.TemplateFn(clusters.ReceiveIngestorDefault, templates.ResourceRequirements)),
-							SecurityContext: &corev1.PodSecurityContext{
-								SeccompProfile: &corev1.SeccompProfile{
-									Type: corev1.SeccompProfileTypeRuntimeDefault,
-								},
-							},
-						},
-						ExternalLabels: map[string]string{
-							"replica": "$(POD_NAME)",
-						},
-						Replicas: clusters.TemplateFn(clusters.ReceiveIngestorDefault, templates.Replicas),
-						TSDBConfig: v1alpha1.TSDBConfig{
-							Retention: v1alpha1.Duration("1d"),
-						},
-						AsyncForwardWorkerCount:  ptr.To(uint64(50)),
-						TooFarInFutureTimeWindow: ptr.To(v1alpha1.Duration("5m")),
-						StoreLimitsOptions: &v1alpha1.StoreLimitsOptions{
-							StoreLimitsRequestSamples: 0,
-							StoreLimitsRequestSeries:  0,
-						},
-						TenancyConfig: &v1alpha1.TenancyConfig{
-							TenantMatcherType: "exact",
-							DefaultTenantID:   "FB870BF3-9F3A-44FF-9BF7-D7A047A52F43",
-							TenantHeader:      "THANOS-TENANT",
-							TenantLabelName:   "tenant_id",
-						},
-						ObjectStorageConfig: ptr.To(clusters.TemplateFn(clusters.DefaultBucket, templates.ObjectStorageBucket)),
-						StorageConfiguration: v1alpha1.StorageConfiguration{
-							Size: clusters.TemplateFn(clusters.ReceiveIngestorDefault, templates.StorageSize),
-						},
+				Hashrings:                  receiveHashrings(templates, hashrings),
+			},
+		},
+	}
+}
+
+// receiveHashrings renders the configured hashrings, or a single default hashring taking every tenant.
+// Unset hashring fields fall back to the default ingester settings.
+func receiveHashrings(templates clusters.TemplateMaps, hashrings []clusters.Hashring) []v1alpha1.IngesterHashringSpec {
+	if len(hashrings) == 0 {
+		hashrings = []clusters.Hashring{{Name: "default"}}
+	}
+
+	specs := make([]v1alpha1.IngesterHashringSpec, 0, len(hashrings))
+	for _, h := range hashrings {
+		component := clusters.ReceiveIngestorDefault
+		if h.Component != "" {
+			component = h.Component
+		}
+		// The defaults are only looked up when the hashring does not set them, the legacy maps do not hold them all
+		replicas := h.Replicas
+		if replicas == 0 {
+			replicas = clusters.TemplateFn(component, templates.Replicas)
+		}
+		resources := h.Resources
+		if resources.Limits == nil && resources.Requests == nil {
+			resources = clusters.TemplateFn(component, templates.ResourceRequirements)
+		}
+		retention := "1d"
+		if h.Retention != "" {
+			retention = h.Retention
+		}
+		storageSize := h.StorageSize
+		if storageSize == "" {
+			storageSize = clusters.TemplateFn(component, templates.StorageSize)
+		}
+		var objectStorage *v1alpha1.ObjectStorageConfig
+		if !h.ReceiveBucket {
+			bucket := clusters.DefaultBucket
+			if h.Bucket != "" {
+				bucket = h.Bucket
+			}
+			objectStorage = ptr.To(clusters.TemplateFn(bucket, templates.ObjectStorageBucket).ObjectStorageConfig)
+		}
+		asyncForwardWorkers := uint64(50)
+		if h.AsyncForwardWorkers != 0 {
+			asyncForwardWorkers = h.AsyncForwardWorkers
+		}
+		matcherType := clusters.TenantMatcherExact
+		if h.MatcherType != "" {
+			matcherType = h.MatcherType
+		}
+
+		specs = append(specs, v1alpha1.IngesterHashringSpec{
+			Name: h.Name,
+			CommonFields: v1alpha1.CommonFields{
+				Image:                ptr.To(clusters.TemplateFn(component, templates.Images)),
+				Version:              ptr.To(clusters.TemplateFn(component, templates.Versions)),
+				ImagePullPolicy:      ptr.To(corev1.PullIfNotPresent),
+				LogLevel:             ptr.To(clusters.TemplateFn(component, templates.LogLevels)),
+				LogFormat:            ptr.To("logfmt"),
+				ResourceRequirements: ptr.To(resources),
+				SecurityContext: &corev1.PodSecurityContext{
+					SeccompProfile: &corev1.SeccompProfile{
+						Type: corev1.SeccompProfileTypeRuntimeDefault,
 					},
 				},
 			},
-		},
+			ExternalLabels: map[string]string{
+				"replica": "$(POD_NAME)",
+			},
+			Replicas: replicas,
+			TSDBConfig: v1alpha1.TSDBConfig{
+				Retention: v1alpha1.Duration(retention),
+			},
+			AsyncForwardWorkerCount:  ptr.To(asyncForwardWorkers),
+			TooFarInFutureTimeWindow: ptr.To(v1alpha1.Duration("5m")),
+			StoreLimitsOptions:       ptr.To(h.StoreLimits),
+			TenancyConfig: &v1alpha1.TenancyConfig{
+				Tenants:           h.Tenants,
+				TenantMatcherType: string(matcherType),
+				DefaultTenantID:   clusters.ReceiveDefaultTenantID,
+				TenantHeader:      "THANOS-TENANT",
+				TenantLabelName:   "tenant_id",
+			},
+			ObjectStorageConfig: objectStorage,
+			StorageConfiguration: v1alpha1.StorageConfiguration{
+				Size: storageSize,
+			},
+		})
 	}
+	return specs
 }
 
-func defaultCompactCR(namespace string, templates clusters.TemplateMaps, oauth bool) []runtime.Object {
+func defaultCompactCR(namespace string, templates clusters.TemplateMaps, policy clusters.CompactionPolicy, shards []v1alpha1.ShardingConfig, oauth bool) []runtime.Object {
 	var objs []runtime.Object
 	defaultCompact := &v1alpha1.ThanosCompact{
 		TypeMeta: metav1.TypeMeta{
@@ -1367,15 +1078,16 @@ func defaultCompactCR(namespace string, templates clusters.TemplateMaps, oauth b
 					},
 				},
 			},
-			ObjectStorageConfig: clusters.TemplateFn(clusters.DefaultBucket, templates.ObjectStorageBucket),
+			ObjectStorageConfig: clusters.TemplateFn(clusters.DefaultBucket, templates.ObjectStorageBucket).ObjectStorageConfig,
+			ShardingConfig:      shards,
 			RetentionConfig: v1alpha1.RetentionResolutionConfig{
-				Raw:         v1alpha1.Duration("365d"),
-				FiveMinutes: v1alpha1.Duration("365d"),
-				OneHour:     v1alpha1.Duration("365d"),
+				Raw:         compactRetention(policy.RawRetention),
+				FiveMinutes: compactRetention(policy.FiveMinutesRetention),
+				OneHour:     compactRetention(policy.OneHourRetention),
 			},
 			DownsamplingConfig: &v1alpha1.DownsamplingConfig{
 				Concurrency: ptr.To(int32(1)),
-				Disable:     ptr.To(false),
+				Disable:     ptr.To(policy.DisableDownsampling),
 			},
 			CompactConfig: &v1alpha1.CompactConfig{
 				CompactConcurrency: ptr.To(int32(1)),
@@ -1446,6 +1158,54 @@ func defaultCompactCR(namespace string, templates clusters.TemplateMaps, oauth b
 	return objs
 }
 
+func compactRetention(retention string) v1alpha1.Duration {
+	if retention == "" {
+		return v1alpha1.Duration("365d")
+	}
+	return v1alpha1.Duration(retention)
+}
+
+// compactShardingConfig shards the compactor on the external labels and tenants assigned to each shard.
+func compactShardingConfig(config clusters.ClusterConfig) []v1alpha1.ShardingConfig {
+	if len(config.Compaction.Shards) == 0 || config.GatewayConfig == nil {
+		return nil
+	}
+	return compactShards(config.Compaction.Shards, config.GatewayConfig.Tenants())
+}
+
+// compactShards returns the sharding config of the given shards, each keeping the blocks matching its external
+// labels and the tenant_id of its tenants.
+func compactShards(shards []clusters.CompactionShard, tenants observatoriumapi.Tenants) []v1alpha1.ShardingConfig {
+	ids := map[string]string{}
+	for _, tenant := range tenants.Tenants {
+		ids[tenant.Name] = tenant.ID
+	}
+
+	configs := make([]v1alpha1.ShardingConfig, 0, len(shards))
+	for _, shard := range shards {
+		tenantIDs := make([]string, 0, len(shard.Tenants)+1)
+		for _, tenant := range shard.Tenants {
+			tenantIDs = append(tenantIDs, regexp.QuoteMeta(ids[tenant]))
+		}
+		if shard.DefaultTenant {
+			tenantIDs = append(tenantIDs, regexp.QuoteMeta(clusters.ReceiveDefaultTenantID))
+		}
+
+		labels := slices.Clone(shard.ExternalLabels)
+		if len(tenantIDs) > 0 {
+			labels = append(labels, v1alpha1.ExternalLabelShardingConfig{
+				Label: "tenant_id",
+				Value: strings.Join(tenantIDs, "|"),
+			})
+		}
+		configs = append(configs, v1alpha1.ShardingConfig{
+			ShardName:             shard.Name,
+			ExternalLabelSharding: labels,
+		})
+	}
+	return configs
+}
+
 func defaultRulerCR(namespace string, templates clusters.TemplateMaps) runtime.Object {
 	return &v1alpha1.ThanosRuler{
 		TypeMeta: metav1.TypeMeta{
@@ -1489,7 +1249,7 @@ func defaultRulerCR(namespace string, templates clusters.TemplateMaps) runtime.O
 			ExternalLabels: map[string]string{
 				"rule_replica": "$(NAME)",
 			},
-			ObjectStorageConfig: clusters.TemplateFn(clusters.DefaultBucket, templates.ObjectStorageBucket),
+			ObjectStorageConfig: clusters.TemplateFn(clusters.DefaultBucket, templates.ObjectStorageBucket).ObjectStorageConfig,
 			AlertmanagerURL:     "dnssrv+http://alertmanager-cluster." + namespace + ".svc.cluster.local:9093",
 			AlertLabelDrop:      []string{"rule_replica"},
 			Retention:           v1alpha1.Duration("48h"),
@@ -1502,7 +1262,7 @@ func defaultRulerCR(namespace string, templates clusters.TemplateMaps) runtime.O
 	}
 }
 
-func queryCR(namespace string, templates clusters.TemplateMaps, oauth bool, withAdditonalArgs ...string) []runtime.Object {
+func queryCR(namespace string, templates clusters.TemplateMaps, oauth bool, withAdditonalArgs ...string) ([]runtime.Object, error) {
 	// placeholder for prod caches - temp removed whilst debugging
 	qfeCacheTempProd := v1alpha1.Additional{
 		Args: []string{`--query-range.response-cache-config=
@@ -1583,22 +1343,19 @@ func queryCR(namespace string, templates clusters.TemplateMaps, oauth bool, with
 						},
 					},
 				},
-				Replicas:             clusters.TemplateFn("QUERY_FRONTEND", templates.Replicas),
-				CompressResponses:    true,
-				LogQueriesLongerThan: ptr.To(v1alpha1.Duration("10s")),
-				LabelsMaxRetries:     3,
-				QueryRangeMaxRetries: 3,
+				Replicas:          clusters.TemplateFn("QUERY_FRONTEND", templates.Replicas),
+				CompressResponses: true,
 				QueryLabelSelector: &metav1.LabelSelector{
 					MatchLabels: map[string]string{
 						"operator.thanos.io/query-api": "true",
 					},
 				},
-				QueryRangeSplitInterval: ptr.To(v1alpha1.Duration("48h")),
-				LabelsSplitInterval:     ptr.To(v1alpha1.Duration("48h")),
-				LabelsDefaultTimeRange:  ptr.To(v1alpha1.Duration("336h")),
 			},
 		},
 	}
+	if err := applyQueryFrontendPolicy(query.Spec.QueryFrontend, templates); err != nil {
+		return nil, fmt.Errorf("invalid query frontend policy: %w", err)
+	}
 	if oauth {
 		route := &routev1.Route{
 			TypeMeta: metav1.TypeMeta{
@@ -1646,7 +1403,7 @@ func queryCR(namespace string, templates clusters.TemplateMaps, oauth bool, with
 	}
 
 	objs = append(objs, query)
-	return objs
+	return objs, nil
 }
 
 func rulerCR(namespace string, templates clusters.TemplateMaps) []runtime.Object {
@@ -1684,7 +1441,7 @@ func rulerCR(namespace string, templates clusters.TemplateMaps) []runtime.Object
 				ExternalLabels: map[string]string{
 					"rule_replica": "$(NAME)",
 				},
-				ObjectStorageConfig: clusters.TemplateFn("TELEMETER", templates.ObjectStorageBucket),
+				ObjectStorageConfig: clusters.TemplateFn("TELEMETER", templates.ObjectStorageBucket).ObjectStorageConfig,
 				RuleTenancyConfig: &v1alpha1.RuleTenancyConfig{
 					TenantLabel:      "tenant_id",
 					TenantValueLabel: "operator.thanos.io/tenant",
@@ -1709,6 +1466,27 @@ func compactTempProduction(templates clusters.TemplateMaps) []runtime.Object {
 
 	m := clusters.ProductionMaps
 
+	policy := clusters.ProductionCompaction
+	tenants := prodGatewayTenants()
+	if err := policy.Validate(tenants); err != nil {
+		panic(fmt.Sprintf("invalid production compaction policy: %v", err))
+	}
+	shards := compactShards(policy.Shards, tenants)
+	shardsNamed := func(names ...string) []v1alpha1.ShardingConfig {
+		var selected []v1alpha1.ShardingConfig
+		for _, shard := range shards {
+			if slices.Contains(names, shard.ShardName) {
+				selected = append(selected, shard)
+			}
+		}
+		return selected
+	}
+	retention := v1alpha1.RetentionResolutionConfig{
+		Raw:         compactRetention(policy.RawRetention),
+		FiveMinutes: compactRetention(policy.FiveMinutesRetention),
+		OneHour:     compactRetention(policy.OneHourRetention),
+	}
+
 	notTelemeter := &v1alpha1.ThanosCompact{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "monitoring.thanos.io/v1alpha1",
@@ -1724,30 +1502,7 @@ func compactTempProduction(templates clusters.TemplateMaps) []runtime.Object {
 					`--deduplication.replica-label=replica`,
 				},
 			},
-			ShardingConfig: []v1alpha1.ShardingConfig{
-				{
-					ShardName: "rhobs",
-					ExternalLabelSharding: []v1alpha1.ExternalLabelShardingConfig{
-						{
-							Label: "receive",
-							Value: "true",
-						},
-						{
-							Label: "tenant_id",
-							Value: "0fc2b00e-201b-4c17-b9f2-19d91adc4fd2",
-						},
-					},
-				},
-				{
-					ShardName: "rules",
-					ExternalLabelSharding: []v1alpha1.ExternalLabelShardingConfig{
-						{
-							Label: "receive",
-							Value: "!true",
-						},
-					},
-				},
-			},
+			ShardingConfig: shardsNamed("rhobs", "rules"),
 			CommonFields: v1alpha1.CommonFields{
 				Image:           ptr.To(image),
 				Version:         ptr.To(version),
@@ -1755,15 +1510,11 @@ func compactTempProduction(templates clusters.TemplateMaps) []runtime.Object {
 				LogLevel:        ptr.To("warn"),
 				LogFormat:       ptr.To("logfmt"),
 			},
-			ObjectStorageConfig: clusters.TemplateFn(storageBucket, m.ObjectStorageBucket),
-			RetentionConfig: v1alpha1.RetentionResolutionConfig{
-				Raw:         v1alpha1.Duration("3650d"),
-				FiveMinutes: v1alpha1.Duration("3650d"),
-				OneHour:     v1alpha1.Duration("3650d"),
-			},
+			ObjectStorageConfig: clusters.TemplateFn(storageBucket, m.ObjectStorageBucket).ObjectStorageConfig,
+			RetentionConfig:     retention,
 			DownsamplingConfig: &v1alpha1.DownsamplingConfig{
 				Concurrency: ptr.To(int32(4)),
-				Disable:     ptr.To(false),
+				Disable:     ptr.To(policy.DisableDownsampling),
 			},
 			CompactConfig: &v1alpha1.CompactConfig{
 				BlockFetchConcurrency: ptr.To(int32(8)),
@@ -1795,21 +1546,7 @@ func compactTempProduction(templates clusters.TemplateMaps) []runtime.Object {
 					`--deduplication.replica-label=replica`,
 				},
 			},
-			ShardingConfig: []v1alpha1.ShardingConfig{
-				{
-					ShardName: "telemeter",
-					ExternalLabelSharding: []v1alpha1.ExternalLabelShardingConfig{
-						{
-							Label: "receive",
-							Value: "true",
-						},
-						{
-							Label: "tenant_id",
-							Value: "FB870BF3-9F3A-44FF-9BF7-D7A047A52F43",
-						},
-					},
-				},
-			},
+			ShardingConfig: shardsNamed("telemeter"),
 			CommonFields: v1alpha1.CommonFields{
 				Image:           ptr.To(image),
 				Version:         ptr.To(version),
@@ -1817,15 +1554,11 @@ func compactTempProduction(templates clusters.TemplateMaps) []runtime.Object {
 				LogLevel:        ptr.To("info"),
 				LogFormat:       ptr.To("logfmt"),
 			},
-			ObjectStorageConfig: clusters.TemplateFn(storageBucket, m.ObjectStorageBucket),
-			RetentionConfig: v1alpha1.RetentionResolutionConfig{
-				Raw:         v1alpha1.Duration("3650d"),
-				FiveMinutes: v1alpha1.Duration("3650d"),
-				OneHour:     v1alpha1.Duration("3650d"),
-			},
+			ObjectStorageConfig: clusters.TemplateFn(storageBucket, m.ObjectStorageBucket).ObjectStorageConfig,
+			RetentionConfig:     retention,
 			DownsamplingConfig: &v1alpha1.DownsamplingConfig{
 				Concurrency: ptr.To(int32(4)),
-				Disable:     ptr.To(false),
+				Disable:     ptr.To(policy.DisableDownsampling),
 			},
 			CompactConfig: &v1alpha1.CompactConfig{
 				BlockFetchConcurrency: ptr.To(int32(4)),
@@ -1860,21 +1593,7 @@ func compactTempProduction(templates clusters.TemplateMaps) []runtime.Object {
 					`--deduplication.replica-label=replica`,
 				},
 			},
-			ShardingConfig: []v1alpha1.ShardingConfig{
-				{
-					ShardName: "telemeter",
-					ExternalLabelSharding: []v1alpha1.ExternalLabelShardingConfig{
-						{
-							Label: "receive",
-							Value: "true",
-						},
-						{
-							Label: "tenant_id",
-							Value: "FB870BF3-9F3A-44FF-9BF7-D7A047A52F43",
-						},
-					},
-				},
-			},
+			ShardingConfig: shardsNamed("telemeter"),
 			CommonFields: v1alpha1.CommonFields{
 				Image:           ptr.To(image),
 				Version:         ptr.To(version),
@@ -1882,15 +1601,11 @@ func compactTempProduction(templates clusters.TemplateMaps) []runtime.Object {
 				LogLevel:        ptr.To("info"),
 				LogFormat:       ptr.To("logfmt"),
 			},
-			ObjectStorageConfig: clusters.TemplateFn(storageBucket, m.ObjectStorageBucket),
-			RetentionConfig: v1alpha1.RetentionResolutionConfig{
-				Raw:         v1alpha1.Duration("3650d"),
-				FiveMinutes: v1alpha1.Duration("3650d"),
-				OneHour:     v1alpha1.Duration("3650d"),
-			},
+			ObjectStorageConfig: clusters.TemplateFn(storageBucket, m.ObjectStorageBucket).ObjectStorageConfig,
+			RetentionConfig:     retention,
 			DownsamplingConfig: &v1alpha1.DownsamplingConfig{
 				Concurrency: ptr.To(int32(4)),
-				Disable:     ptr.To(false),
+				Disable:     ptr.To(policy.DisableDownsampling),
 			},
 			CompactConfig: &v1alpha1.CompactConfig{
 				BlockFetchConcurrency: ptr.To(int32(4)),
@@ -1932,7 +1647,7 @@ func stageCompactCR(namespace string, templates clusters.TemplateMaps) []runtime
 				LogFormat:            ptr.To("logfmt"),
 				ResourceRequirements: ptr.To(clusters.TemplateFn("COMPACT_ROS", templates.ResourceRequirements)),
 			},
-			ObjectStorageConfig: clusters.TemplateFn("ROS", templates.ObjectStorageBucket),
+			ObjectStorageConfig: clusters.TemplateFn("ROS", templates.ObjectStorageBucket).ObjectStorageConfig,
 			RetentionConfig: v1alpha1.RetentionResolutionConfig{
 				Raw:         v1alpha1.Duration("14d"),
 				FiveMinutes: v1alpha1.Duration("14d"),
@@ -1973,14 +1688,12 @@ func generateMetricsBundle(config clusters.ClusterConfig) error {
 	bundleGen.Logger = kitlog.NewLogfmtLogger(kitlog.NewSyncWriter(os.Stdout))
 
 	// 1. CRDs (prefix: 01-*)
-	crdObjs := getCRDObjects()
-	crdNames := []string{"compacts", "queries", "receives", "rulers", "stores"}
+	crdObjs, err := upstreamCRDObjects(thanosOperatorUpstream(thanosOperatorCRDRef), thanosOperatorCRDs)
+	if err != nil {
+		return fmt.Errorf("failed to read CRDs: %w", err)
+	}
 	for i, crd := range crdObjs {
-		crdName := "unknown"
-		if i < len(crdNames) {
-			crdName = crdNames[i]
-		}
-		filename := fmt.Sprintf("01-crd-%s.yaml", crdName)
+		filename := fmt.Sprintf("01-crd-%s.yaml", thanosOperatorCRDs[i].name)
 		bundleGen.Add(filename, encoding.GhodssYAML(crd))
 	}
 
@@ -1997,7 +1710,10 @@ func generateMetricsBundle(config clusters.ClusterConfig) error {
 	}
 
 	// 3. CACHE (prefix: 03-*)
-	cacheObjs := getThanosCacheObjects(ns, config.Templates)
+	cacheObjs, err := getThanosCacheObjects(config)
+	if err != nil {
+		return fmt.Errorf("failed to generate cache resources: %w", err)
+	}
 	for _, obj := range cacheObjs {
 		cacheKind := getResourceKind(obj)
 		cacheName := getResourceName(obj)
@@ -2009,11 +1725,24 @@ func generateMetricsBundle(config clusters.ClusterConfig) error {
 
 	// 4. CUSTOM RESOURCES (prefix: 04-*)
 	thanosObjs := make([]runtime.Object, 0, 7) // Pre-allocate for expected ~7 resources (query+route, receive, compact+route, ruler, store)
-	thanosObjs = append(thanosObjs, defaultQueryCR(ns, config.Templates, true)...)
-	thanosObjs = append(thanosObjs, defaultReceiveCR(ns, config.Templates))
-	thanosObjs = append(thanosObjs, defaultCompactCR(ns, config.Templates, true)...)
+	queryObjs, err := defaultQueryCR(ns, config.Templates, true)
+	if err != nil {
+		return fmt.Errorf("failed to generate query: %w", err)
+	}
+	thanosObjs = append(thanosObjs, queryObjs...)
+	thanosObjs = append(thanosObjs, defaultReceiveCR(ns, config.Templates, config.Hashrings))
+	thanosObjs = append(thanosObjs, defaultCompactCR(ns, config.Templates, config.Compaction, compactShardingConfig(config), true)...)
 	thanosObjs = append(thanosObjs, defaultRulerCR(ns, config.Templates))
-	thanosObjs = append(thanosObjs, defaultStoreCR(ns, config.Templates))
+	thanosObjs = append(thanosObjs, defaultStoreCRs(ns, config.Templates)...)
+	thanosObjs = thanosCacheReferences(config, thanosObjs)
+	thanosObjs, err = receiveLimitsObjects(config, thanosObjs)
+	if err != nil {
+		return fmt.Errorf("failed to generate receive limits: %w", err)
+	}
+	thanosObjs, err = federationObjects(config, thanosObjs)
+	if err != nil {
+		return fmt.Errorf("failed to generate query federation: %w", err)
+	}
 
 	for i, obj := range thanosObjs {
 		resourceKind := getResourceKind(obj)
@@ -2033,6 +1762,16 @@ func generateMetricsBundle(config clusters.ClusterConfig) error {
 	// Generate the bundle files
 	bundleGen.Generate()
 
+	templatesGen := &mimic.Generator{}
+	templatesGen = templatesGen.With(templatePath, templateClustersPath, string(config.Environment), string(config.Name), "metrics", "templates")
+	templatesGen.Logger = kitlog.NewLogfmtLogger(kitlog.NewSyncWriter(os.Stdout))
+	addObjectStoreSecretTemplates(templatesGen, config)
+	addFederationSecretTemplate(templatesGen, config)
+	if _, err := addCacheSecretTemplate(templatesGen, config); err != nil {
+		return fmt.Errorf("failed to generate cache secrets: %w", err)
+	}
+	templatesGen.Generate()
+
 	// Add consolidated ServiceMonitors to monitoring bundle
 	monBundle := GetMonitoringBundle(config)
 	thanosServiceMonitors := createConsolidatedThanosServiceMonitors(ns)
@@ -2063,76 +1802,37 @@ func generateMetricsBundle(config clusters.ClusterConfig) error {
 	return nil
 }
 
-// getCRDObjects retrieves Thanos operator CRDs
-func getCRDObjects() []runtime.Object {
-	const (
-		compact   = "thanoscompacts.yaml"
-		queries   = "thanosqueries.yaml"
-		receivers = "thanosreceives.yaml"
-		rulers    = "thanosrulers.yaml"
-		stores    = "thanosstores.yaml"
-		base      = "https://raw.githubusercontent.com/thanos-community/thanos-operator/" + thanosOperatorCRDRef + "/config/crd/bases/monitoring.thanos.io_"
-	)
-
-	var objs []runtime.Object
-	for _, component := range []string{compact, queries, receivers, rulers, stores} {
-		crd, err := getCustomResourceDefinition(base + component)
-		if err != nil {
-			log.Printf("Error fetching CRD %s: %v", component, err)
-			continue
-		}
-		objs = append(objs, crd)
-	}
-	return objs
-}
-
-// getThanosCacheObjects returns cache objects for Thanos components
-func getThanosCacheObjects(namespace string, templates clusters.TemplateMaps) []runtime.Object {
+// getThanosCacheObjects returns cache objects for Thanos components.
+// Roles running on an external backend only get their secret, which is templated by addCacheSecretTemplate.
+func getThanosCacheObjects(config clusters.ClusterConfig) ([]runtime.Object, error) {
 	var objs []runtime.Object
 
-	// Index cache
-	indexCacheConfig := indexCache(templates, namespace)
-	objs = append(objs, memcachedStatefulSet(indexCacheConfig, templates))
-	objs = append(objs, createServiceAccount(indexCacheConfig.Name, indexCacheConfig.Namespace, indexCacheConfig.Labels))
-	objs = append(objs, createCacheHeadlessService(indexCacheConfig))
-
-	// Bucket cache
-	bucketCacheConfig := bucketCache(templates, namespace)
-	objs = append(objs, memcachedStatefulSet(bucketCacheConfig, templates))
-	objs = append(objs, createServiceAccount(bucketCacheConfig.Name, bucketCacheConfig.Namespace, bucketCacheConfig.Labels))
-	objs = append(objs, createCacheHeadlessService(bucketCacheConfig))
-
-	// Query range cache
-	queryRangeCacheConfig := queryRangeCache(templates, namespace)
-	objs = append(objs, memcachedStatefulSet(queryRangeCacheConfig, templates))
-	objs = append(objs, createServiceAccount(queryRangeCacheConfig.Name, queryRangeCacheConfig.Namespace, queryRangeCacheConfig.Labels))
-	objs = append(objs, createCacheHeadlessService(queryRangeCacheConfig))
+	for _, cacheConfig := range thanosMemcachedCaches(config, config.Templates) {
+		objs = append(objs, memcachedStatefulSet(cacheConfig, config.Templates))
+		objs = append(objs, createServiceAccount(cacheConfig.Name, cacheConfig.Namespace, cacheConfig.Labels))
+		objs = append(objs, createCacheHeadlessService(cacheConfig))
+	}
 
 	// Cache secrets
-	cacheSecrets := memcachedCacheSecrets(namespace)
-	for _, secret := range cacheSecrets {
-		objs = append(objs, secret)
+	for _, role := range thanosCacheRoles {
+		if config.Cache(role).Backend == clusters.CacheBackendMemcached {
+			secret, _, err := thanosCacheSecret(config, role)
+			if err != nil {
+				return nil, err
+			}
+			objs = append(objs, secret)
+		}
 	}
 
-	return objs
+	return objs, nil
 }
 
 // createThanosCacheServiceMonitors creates ServiceMonitors for Thanos cache components
 func createThanosCacheServiceMonitors(config clusters.ClusterConfig) []*monitoringv1.ServiceMonitor {
-	ns := config.Namespace
-	templates := config.Templates
-
 	var serviceMonitors []*monitoringv1.ServiceMonitor
 
-	// Create cache configurations
-	cacheConfigs := []*memcachedConfig{
-		indexCache(templates, ns),
-		bucketCache(templates, ns),
-		queryRangeCache(templates, ns),
-	}
-
-	// Generate ServiceMonitor for each cache
-	for _, cacheConfig := range cacheConfigs {
+	// Generate ServiceMonitor for each in-cluster cache
+	for _, cacheConfig := range thanosMemcachedCaches(config, config.Templates) {
 		sm := createCacheServiceMonitor(cacheConfig)
 		serviceMonitors = append(serviceMonitors, sm)
 	}
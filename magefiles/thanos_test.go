@@ -0,0 +1,76 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rhobs/configuration/clusters"
+)
+
+func TestDefaultThanosStackErrors(t *testing.T) {
+	base := clusters.ClusterConfig{
+		Name:        "test",
+		Environment: clusters.EnvironmentStaging,
+		Namespace:   "rhobs",
+		Templates:   clusters.DefaultBaseTemplate(),
+	}
+
+	for _, tc := range []struct {
+		name   string
+		modify func(*clusters.ClusterConfig)
+		err    string
+	}{
+		{
+			name: "federating an unregistered cluster",
+			modify: func(c *clusters.ClusterConfig) {
+				c.QueryFederation = &clusters.QueryFederation{Remotes: []clusters.FederatedCluster{{Name: "unregistered"}}}
+			},
+			err: "failed to generate query federation",
+		},
+		{
+			name: "limiting a tenant missing from the gateway",
+			modify: func(c *clusters.ClusterConfig) {
+				c.GatewayConfig = clusters.NewGatewayConfig()
+				c.ReceiveLimits = &clusters.ReceiveLimits{Tenants: map[string]clusters.WriteLimits{"unregistered": {SeriesLimit: 1}}}
+			},
+			err: "failed to generate receive limits",
+		},
+		{
+			name: "caching labels for an invalid validity",
+			modify: func(c *clusters.ClusterConfig) {
+				c.Templates.QueryFrontendPolicy = clusters.ParamMap[clusters.QueryFrontendPolicy]{
+					clusters.QueryFrontend: {
+						ResultsCache:        clusters.QueryFrontendCacheDisabled,
+						LabelsSplitInterval: "24h",
+						LabelsCacheMaxSize:  "256MB",
+						LabelsCacheValidity: "1x",
+					},
+				}
+			},
+			err: "invalid labels cache validity",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config := base
+			tc.modify(&config)
+			err := Build{}.DefaultThanosStack(config)
+			if err == nil {
+				t.Fatalf("expected an error containing %q", tc.err)
+			}
+			if !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("expected an error containing %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestSecretsInvalidResultsCacheTTL(t *testing.T) {
+	templates := clusters.DefaultBaseTemplate()
+	templates.QueryFrontendPolicy = clusters.ParamMap[clusters.QueryFrontendPolicy]{
+		clusters.QueryFrontend: {ResultsCache: clusters.QueryFrontendCacheExternal, ResultsCacheTTL: "1x"},
+	}
+	err := Build{}.Secrets(clusters.ClusterConfig{Name: "test", Namespace: "rhobs", Templates: templates})
+	if err == nil || !strings.Contains(err.Error(), "invalid results cache TTL") {
+		t.Fatalf("expected an invalid results cache TTL error, got %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/bwplotka/mimic"
+	"github.com/bwplotka/mimic/encoding"
+	kitlog "github.com/go-kit/log"
+	"github.com/observatorium/observatorium/configuration_go/kubegen/openshift"
+	templatev1 "github.com/openshift/api/template/v1"
+	"github.com/rhobs/configuration/clusters"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	tempoStackName      = "rhobs"
+	tempoStorageClass   = "gp3-csi"
+	tempoTraceRetention = "48h0m0s"
+)
+
+// DefaultTracesStack Generates the TempoStack backing the traces signal of the gateway.
+func (b Build) DefaultTracesStack(config clusters.ClusterConfig) error {
+	if isMigratedCluster(config) {
+		return generateTracesBundle(config)
+	}
+
+	bucket := clusters.TemplateFn(clusters.TracesBucket, config.Templates.ObjectStorageBucket)
+	tempoStack, err := newTempoStack(config.Namespace, config.Templates, "${TEMPO_STORAGE_SECRET_NAME}", "${TEMPO_STORAGE_CLASS}")
+	if err != nil {
+		return err
+	}
+
+	gen := b.generator(config, "tempo-operator-default-cr")
+	gen.Add("tempo-operator-default-cr.yaml", encoding.GhodssYAML(
+		openshift.WrapInTemplate(
+			[]runtime.Object{tempoStack},
+			metav1.ObjectMeta{Name: "tempo-rhobs"},
+			[]templatev1.Parameter{
+				{
+					Name:  "TEMPO_STORAGE_SECRET_NAME",
+					Value: bucket.Name,
+				},
+				{
+					Name:  "TEMPO_STORAGE_CLASS",
+					Value: tempoStorageClass,
+				},
+			},
+		),
+	))
+	addTempoObjectStoreSecretTemplate(gen, config)
+	gen.Generate()
+	return nil
+}
+
+func generateTracesBundle(config clusters.ClusterConfig) error {
+	bucket := clusters.TemplateFn(clusters.TracesBucket, config.Templates.ObjectStorageBucket)
+	tempoStack, err := newTempoStack(config.Namespace, config.Templates, bucket.Name, tempoStorageClass)
+	if err != nil {
+		return err
+	}
+
+	bundleGen := &mimic.Generator{}
+	bundleGen = bundleGen.With(templatePath, templateClustersPath, string(config.Environment), string(config.Name), "traces", "bundle")
+	bundleGen.Logger = kitlog.NewLogfmtLogger(kitlog.NewSyncWriter(os.Stdout))
+
+	filename := fmt.Sprintf("01-%s-%s.yaml", getKubernetesResourceName(tempoStack), getResourceKind(tempoStack))
+	bundleGen.Add(filename, encoding.GhodssYAML(tempoStack))
+	bundleGen.Generate()
+
+	// The storage secret references its credentials, it is templated next to the bundle
+	templatesGen := &mimic.Generator{}
+	templatesGen = templatesGen.With(templatePath, templateClustersPath, string(config.Environment), string(config.Name), "traces", "templates")
+	templatesGen.Logger = kitlog.NewLogfmtLogger(kitlog.NewSyncWriter(os.Stdout))
+	addTempoObjectStoreSecretTemplate(templatesGen, config)
+	templatesGen.Generate()
+	return nil
+}
+
+// newTempoStack builds the TempoStack CR as an unstructured object since we do not depend on the
+// tempo-operator API module. The operator creates its own ServiceMonitors for the stack.
+func newTempoStack(namespace string, m clusters.TemplateMaps, secretName, storageClass string) (*unstructured.Unstructured, error) {
+	requirements := clusters.TemplateFn(clusters.TempoStack, m.ResourceRequirements)
+	resources, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&requirements)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert TempoStack resources: %w", err)
+	}
+
+	tempoStack := &unstructured.Unstructured{
+		Object: map[string]any{
+			"spec": map[string]any{
+				"managementState":   "Managed",
+				"replicationFactor": int64(1),
+				"storageClassName":  storageClass,
+				"storageSize":       string(clusters.TemplateFn(clusters.TempoStack, m.StorageSize)),
+				"storage": map[string]any{
+					"secret": map[string]any{
+						"name": secretName,
+						"type": tempoStorageSecretType(m),
+					},
+				},
+				"retention": map[string]any{
+					"global": map[string]any{
+						"traces": tempoTraceRetention,
+					},
+				},
+				"resources": map[string]any{
+					"total": resources,
+				},
+				"observability": map[string]any{
+					"metrics": map[string]any{
+						"createServiceMonitors": true,
+					},
+				},
+				"template": map[string]any{
+					"queryFrontend": map[string]any{
+						"jaegerQuery": map[string]any{
+							"enabled": true,
+						},
+					},
+				},
+			},
+		},
+	}
+	tempoStack.SetAPIVersion("tempo.grafana.com/v1alpha1")
+	tempoStack.SetKind("TempoStack")
+	tempoStack.SetName(tempoStackName)
+	tempoStack.SetNamespace(namespace)
+	return tempoStack, nil
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+const (
+	// upstreamPath is the store of the upstream operator manifests the build reads, vendored per operator and ref
+	// by mage sync:operator. Builds never fetch manifests, so they work offline and always match the pinned refs.
+	upstreamPath = "upstream"
+	// upstreamChecksums lists the SHA-256 of every vendored file of a ref, in the format of sha256sum.
+	upstreamChecksums = "SHA256SUMS"
+)
+
+// upstreamRepo is an operator repository whose manifests are vendored in the upstream store.
+type upstreamRepo struct {
+	// name is the directory of the operator in the store.
+	name string
+	// rawURL is the base URL of the raw files of the repository, the ref and file path are appended to it.
+	rawURL string
+	ref    string
+	// files are the paths of the vendored files in the repository.
+	files []string
+}
+
+// upstreamCRD is a vendored CRD and the name it is generated under in the bundles.
+type upstreamCRD struct {
+	name string
+	file string
+}
+
+const thanosOperatorManagerRole = "config/rbac/role.yaml"
+
+var (
+	thanosOperatorCRDs = []upstreamCRD{
+		{name: "compacts", file: "config/crd/bases/monitoring.thanos.io_thanoscompacts.yaml"},
+		{name: "queries", file: "config/crd/bases/monitoring.thanos.io_thanosqueries.yaml"},
+		{name: "receives", file: "config/crd/bases/monitoring.thanos.io_thanosreceives.yaml"},
+		{name: "rulers", file: "config/crd/bases/monitoring.thanos.io_thanosrulers.yaml"},
+		{name: "stores", file: "config/crd/bases/monitoring.thanos.io_thanosstores.yaml"},
+	}
+
+	lokiOperatorCRDs = []upstreamCRD{
+		{name: "projectconfigs", file: "operator/config/crd/bases/config.grafana.com_projectconfigs.yaml"},
+		{name: "alertingrules", file: "operator/config/crd/bases/loki.grafana.com_alertingrules.yaml"},
+		{name: "lokistacks", file: "operator/config/crd/bases/loki.grafana.com_lokistacks.yaml"},
+		{name: "recordingrules", file: "operator/config/crd/bases/loki.grafana.com_recordingrules.yaml"},
+		{name: "rulerconfigs", file: "operator/config/crd/bases/loki.grafana.com_rulerconfigs.yaml"},
+	}
+)
+
+// thanosOperatorUpstream returns the Thanos Operator repository at ref.
+func thanosOperatorUpstream(ref string) upstreamRepo {
+	files := []string{thanosOperatorManagerRole}
+	for _, crd := range thanosOperatorCRDs {
+		files = append(files, crd.file)
+	}
+	return upstreamRepo{
+		name:   "thanos-operator",
+		rawURL: "https://raw.githubusercontent.com/thanos-community/thanos-operator/",
+		ref:    ref,
+		files:  files,
+	}
+}
+
+// lokiOperatorUpstream returns the Loki repository the Loki Operator is built from at ref.
+func lokiOperatorUpstream(ref string) upstreamRepo {
+	var files []string
+	for _, crd := range lokiOperatorCRDs {
+		files = append(files, crd.file)
+	}
+	return upstreamRepo{
+		name:   "loki-operator",
+		rawURL: "https://raw.githubusercontent.com/openshift/loki/",
+		ref:    ref,
+		files:  files,
+	}
+}
+
+func (u upstreamRepo) dir() string {
+	return filepath.Join(upstreamPath, u.name, u.ref)
+}
+
+// read returns a vendored file after verifying its checksum.
+func (u upstreamRepo) read(file string) ([]byte, error) {
+	sums, err := u.checksums()
+	if err != nil {
+		return nil, err
+	}
+	want, ok := sums[file]
+	if !ok {
+		return nil, fmt.Errorf("%s is not vendored for %s at %s, run mage sync:operator", file, u.name, u.ref)
+	}
+	content, err := os.ReadFile(filepath.Join(u.dir(), file))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vendored %s of %s at %s: %w", file, u.name, u.ref, err)
+	}
+	if got := sha256Hex(content); got != want {
+		return nil, fmt.Errorf("vendored %s of %s at %s does not match its checksum, run mage sync:operator", file, u.name, u.ref)
+	}
+	return content, nil
+}
+
+func (u upstreamRepo) checksums() (map[string]string, error) {
+	f, err := os.Open(filepath.Join(u.dir(), upstreamChecksums))
+	if err != nil {
+		return nil, fmt.Errorf("no vendored manifests of %s at %s, run mage sync:operator: %w", u.name, u.ref, err)
+	}
+	defer f.Close()
+
+	sums := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		sum, file, ok := strings.Cut(scanner.Text(), "  ")
+		if !ok {
+			return nil, fmt.Errorf("malformed %s of %s at %s: %q", upstreamChecksums, u.name, u.ref, scanner.Text())
+		}
+		sums[file] = sum
+	}
+	return sums, scanner.Err()
+}
+
+// vendor downloads the files of the repository at its ref into the store and replaces the other refs of the operator.
+func (u upstreamRepo) vendor() error {
+	if err := os.MkdirAll(upstreamPath, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.MkdirTemp(upstreamPath, "."+u.name+"-")
+	if err != nil {
+		return fmt.Errorf("failed to create a staging directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	var sums bytes.Buffer
+	for _, file := range u.files {
+		content, err := fetch(u.rawURL + u.ref + "/" + file)
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(tmp, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			return err
+		}
+		fmt.Fprintf(&sums, "%s  %s\n", sha256Hex(content), file)
+	}
+	if err := os.WriteFile(filepath.Join(tmp, upstreamChecksums), sums.Bytes(), 0o644); err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(filepath.Join(upstreamPath, u.name)); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(upstreamPath, u.name), 0o755); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, u.dir()); err != nil {
+		return fmt.Errorf("failed to move the vendored manifests of %s into place: %w", u.name, err)
+	}
+	fmt.Fprintf(os.Stdout, "Vendored %d files of %s at %s into %s\n", len(u.files), u.name, u.ref, u.dir())
+	return nil
+}
+
+func fetch(url string) ([]byte, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func sha256Hex(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// getCustomResourceDefinition returns a vendored CRD.
+func getCustomResourceDefinition(u upstreamRepo, file string) (*v1.CustomResourceDefinition, error) {
+	var obj v1.CustomResourceDefinition
+	if err := decodeUpstream(u, file, &obj); err != nil {
+		return nil, err
+	}
+	return &obj, nil
+}
+
+// getClusterRole returns a vendored ClusterRole.
+func getClusterRole(u upstreamRepo, file string) (*rbacv1.ClusterRole, error) {
+	var obj rbacv1.ClusterRole
+	if err := decodeUpstream(u, file, &obj); err != nil {
+		return nil, err
+	}
+	return &obj, nil
+}
+
+func decodeUpstream(u upstreamRepo, file string, obj any) error {
+	content, err := u.read(file)
+	if err != nil {
+		return err
+	}
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(content), 100000)
+	if err := decoder.Decode(obj); err != nil {
+		return fmt.Errorf("failed to decode vendored %s of %s: %w", file, u.name, err)
+	}
+	return nil
+}
+
+// upstreamCRDObjects returns the vendored CRDs in order, failing on any missing one.
+func upstreamCRDObjects(u upstreamRepo, crds []upstreamCRD) ([]runtime.Object, error) {
+	objs := make([]runtime.Object, 0, len(crds))
+	for _, crd := range crds {
+		obj, err := getCustomResourceDefinition(u, crd.file)
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, obj)
+	}
+	return objs, nil
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"github.com/bwplotka/mimic"
 	cfgobservatorium "github.com/rhobs/configuration/configuration/observatorium"
+	"github.com/rhobs/configuration/internal/slo"
 )
 
 func main() {
@@ -10,7 +11,7 @@ func main() {
 
 	defer gen.Generate()
 
-	cfgobservatorium.GenSLO(gen.With("observability", "prometheusrules", "pyrra"), gen.With("observability", "prometheusrules"))
+	slo.GenSLO(gen.With("observability", "prometheusrules", "pyrra"), gen.With("observability", "prometheusrules"))
 
 	cfgobservatorium.GenerateRBACFile(gen.With(".tmp", "tenants"))
 